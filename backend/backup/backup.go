@@ -0,0 +1,345 @@
+package backup
+
+import (
+	"backend/database"
+	"backend/models"
+	"backend/snmp"
+	"backend/utils"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Backuper interface {
+	Run(ctx context.Context)
+	BackupAll(ctx context.Context) error
+	BackupHardware(hardwareID int, userID int32) (*models.ConfigBackupResult, error)
+}
+
+type DefaultBackuper struct {
+	Client     snmp.Client
+	Server     *TFTPServer
+	SnmpRepo   database.SnmpRepository
+	ConfigRepo database.ConfigRepository
+	ServerIP   string
+	Dir        string
+	Timeout    time.Duration
+	Interval   time.Duration
+	Workers    int
+	utils.Logger
+}
+
+// ErrNoSaveConfig Оборудование без IP адреса, модели коммутатора или SaveConfigOID в шаблоне модели
+var ErrNoSaveConfig = errors.New("hardware has no ip address or save config oid")
+
+func NewBackuper(db *database.Database, logger *utils.Logger) Backuper {
+	return &DefaultBackuper{
+		Client: snmp.NewClient(),
+		Server: NewTFTPServer(os.Getenv("TFTP_ADDRESS")),
+		SnmpRepo: &database.DefaultSnmpRepository{
+			Database: *db,
+		},
+		ConfigRepo: &database.DefaultConfigRepository{
+			Database: *db,
+		},
+		ServerIP: os.Getenv("TFTP_SERVER_IP"),
+		Dir:      os.Getenv("CONFIG_BACKUP_DIR"),
+		Timeout:  time.Duration(getEnvInt("CONFIG_BACKUP_TIMEOUT", 60)) * time.Second,
+		Interval: time.Duration(getEnvInt("CONFIG_BACKUP_INTERVAL", 86400)) * time.Second,
+		Workers:  getEnvInt("CONFIG_BACKUP_WORKERS", 4),
+		Logger:   *logger,
+	}
+}
+
+// Run Запускает TFTP сервер и периодически снимает конфигурации. При CONFIG_BACKUP_INTERVAL=0
+// работает только снятие по запросу. Без TFTP_SERVER_IP снятие конфигураций выключено
+func (b *DefaultBackuper) Run(ctx context.Context) {
+	if b.ServerIP == "" || b.Server.Address == "" {
+		return
+	}
+
+	go func() {
+		if err := b.Server.ListenAndServe(ctx); err != nil {
+			log.Printf("failed to start tftp server: %v\n", err)
+			b.Logger.Println(err)
+		}
+	}()
+
+	if b.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := b.BackupAll(ctx); err != nil {
+			log.Printf("failed to backup configs: %v\n", err)
+			b.Logger.Println(err)
+		}
+	}
+}
+
+// BackupAll Снимает конфигурации со всего оборудования, у модели которого задан SaveConfigOID
+func (b *DefaultBackuper) BackupAll(ctx context.Context) error {
+	targets, err := b.SnmpRepo.GetSnmpTargets(0)
+	if err != nil {
+		return err
+	}
+
+	workers := b.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	queue := make(chan models.Hardware)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for hd := range queue {
+				if _, e := b.backup(hd, 0); e != nil {
+					log.Printf("failed to backup config of hardware %d: %v\n", hd.ID, e)
+					b.Logger.Println(e)
+				}
+			}
+		}()
+	}
+
+	for _, hd := range targets {
+		if !hd.Switch.SaveConfigOID.Valid || strings.TrimSpace(hd.Switch.SaveConfigOID.String) == "" {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			close(queue)
+			wg.Wait()
+			return ctx.Err()
+		case queue <- hd:
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+
+	return nil
+}
+
+// BackupHardware Снимает конфигурацию одного оборудования вне расписания
+func (b *DefaultBackuper) BackupHardware(hardwareID int, userID int32) (*models.ConfigBackupResult, error) {
+	targets, err := b.SnmpRepo.GetSnmpTargets(hardwareID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(targets) == 0 || !targets[0].Switch.SaveConfigOID.Valid || strings.TrimSpace(targets[0].Switch.SaveConfigOID.String) == "" {
+		return nil, ErrNoSaveConfig
+	}
+
+	return b.backup(targets[0], userID)
+}
+
+func (b *DefaultBackuper) backup(hd models.Hardware, userID int32) (*models.ConfigBackupResult, error) {
+	if b.ServerIP == "" {
+		return nil, errors.New("TFTP_SERVER_IP is not set")
+	}
+
+	now := time.Now()
+	fileName := fmt.Sprintf("%d_%d.cfg", hd.ID, now.UnixNano())
+
+	variables, err := ParseSaveConfigOID(hd.Switch.SaveConfigOID.String, map[string]string{
+		"{server}": b.ServerIP,
+		"{file}":   fileName,
+		"{ip}":     hd.IpAddress.String,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	target := snmp.Target{Address: hd.IpAddress.String, Community: "private"}
+
+	if hd.Switch.CommunityWrite.Valid && hd.Switch.CommunityWrite.String != "" {
+		target.Community = hd.Switch.CommunityWrite.String
+	}
+
+	upload, err := b.Server.Expect(fileName, hd.IpAddress.String)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Server.Cancel(fileName)
+
+	if err = b.Client.Set(target, variables); err != nil {
+		return nil, err
+	}
+
+	var content []byte
+
+	select {
+	case content = <-upload:
+	case <-time.After(b.Timeout):
+		return nil, fmt.Errorf("config of hardware %d was not uploaded in %s", hd.ID, b.Timeout)
+	}
+
+	if len(content) == 0 {
+		return nil, fmt.Errorf("config of hardware %d is empty", hd.ID)
+	}
+
+	hash := sha256.Sum256(content)
+
+	result := &models.ConfigBackupResult{
+		Config: models.HardwareConfig{
+			Hardware:  models.Hardware{ID: hd.ID},
+			Hash:      hex.EncodeToString(hash[:]),
+			Size:      len(content),
+			UserId:    userID,
+			CreatedAt: now.Unix(),
+		},
+	}
+
+	latest, err := b.ConfigRepo.GetHardwareConfig(hd.ID, 0)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if latest != nil && latest.Hash == result.Config.Hash {
+		result.Config = *latest
+		return result, nil
+	}
+
+	dir := b.Dir
+	if dir == "" {
+		dir = filepath.Join("./upload", "configs")
+	}
+
+	// В конфигурациях SNMP community, хэши паролей и ключи, поэтому читать их может только сервис
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	result.Config.Path = filepath.Join(dir, fileName)
+
+	if err = os.WriteFile(result.Config.Path, content, 0600); err != nil {
+		return nil, err
+	}
+
+	var event *models.Event
+
+	if latest != nil {
+		event = &models.Event{
+			HouseId:     hd.Node.HouseId,
+			Node:        &models.Node{ID: hd.Node.ID},
+			Hardware:    &models.Hardware{ID: hd.ID},
+			UserId:      userID,
+			Description: fmt.Sprintf("Изменение конфигурации коммутатора: %s", hd.Switch.Name),
+			CreatedAt:   now.Unix(),
+		}
+	}
+
+	if err = b.ConfigRepo.CreateHardwareConfig(&result.Config, event); err != nil {
+		os.Remove(result.Config.Path)
+		return nil, err
+	}
+
+	result.Changed = true
+
+	// Версия уже сохранена, поэтому ошибка построения diff не делает снятие неудачным
+	if latest != nil {
+		if result.Diff, err = b.diffConfig(*latest, result.Config, content); err != nil {
+			log.Printf("failed to diff config of hardware %d: %v\n", hd.ID, err)
+			b.Logger.Println(err)
+			result.Diff = ""
+		}
+	}
+
+	return result, nil
+}
+
+func (b *DefaultBackuper) diffConfig(previous models.HardwareConfig, current models.HardwareConfig, content []byte) (string, error) {
+	previousContent, err := os.ReadFile(previous.Path)
+	if err != nil {
+		return "", err
+	}
+
+	return UnifiedDiff(previous, previousContent, current, content)
+}
+
+// ParseSaveConfigOID Разбирает SaveConfigOID шаблона модели. Это один или несколько SET через ";"
+// в виде OID=значение, например "1.3.6.1.4.1.x.1=a:{server};1.3.6.1.4.1.x.2={file};1.3.6.1.4.1.x.3=i:1".
+// Если значение не указано, отправляется строка tftp://{server}/{file}. В значениях подставляются
+// {server} - адрес TFTP сервера, {file} - имя файла и {ip} - адрес оборудования
+func ParseSaveConfigOID(template string, values map[string]string) ([]snmp.Variable, error) {
+	var variables []snmp.Variable
+
+	for _, part := range strings.Split(template, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		oid, value, ok := strings.Cut(part, "=")
+		if !ok {
+			value = "tftp://{server}/{file}"
+		}
+
+		oid = strings.Trim(strings.TrimSpace(oid), ".")
+
+		if oid == "" || strings.Trim(oid, "0123456789.") != "" {
+			return nil, fmt.Errorf("invalid save config oid: %s", part)
+		}
+
+		for placeholder, replacement := range values {
+			value = strings.ReplaceAll(value, placeholder, replacement)
+		}
+
+		variables = append(variables, snmp.Variable{OID: oid, Value: strings.TrimSpace(value)})
+	}
+
+	if len(variables) == 0 {
+		return nil, errors.New("save config oid is empty")
+	}
+
+	return variables, nil
+}
+
+// UnifiedDiff Строит unified diff между двумя версиями конфигурации
+func UnifiedDiff(from models.HardwareConfig, fromContent []byte, to models.HardwareConfig, toContent []byte) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(fromContent)),
+		B:        difflib.SplitLines(string(toContent)),
+		FromFile: fmt.Sprintf("version %d", from.Version),
+		FromDate: time.Unix(from.CreatedAt, 0).Format(time.RFC3339),
+		ToFile:   fmt.Sprintf("version %d", to.Version),
+		ToDate:   time.Unix(to.CreatedAt, 0).Format(time.RFC3339),
+		Context:  3,
+	})
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,231 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tftpOpRRQ   = 1
+	tftpOpWRQ   = 2
+	tftpOpDATA  = 3
+	tftpOpACK   = 4
+	tftpOpERROR = 5
+
+	tftpBlockSize = 512
+	tftpRetries   = 5
+)
+
+// TFTPServer Минимальный TFTP сервер (RFC 1350), который только принимает файлы. Загрузка
+// принимается, только если имя файла заранее ожидается через Expect и запрос пришел с адреса
+// опрашиваемого оборудования, остальные запросы отклоняются
+type TFTPServer struct {
+	Address string
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	uploads map[string]tftpUpload
+}
+
+// tftpUpload Ожидаемая загрузка: адрес оборудования, с которого она должна прийти, и канал для содержимого
+type tftpUpload struct {
+	host net.IP
+	ch   chan []byte
+}
+
+func NewTFTPServer(address string) *TFTPServer {
+	return &TFTPServer{
+		Address: address,
+		Timeout: 5 * time.Second,
+		uploads: make(map[string]tftpUpload),
+	}
+}
+
+// Expect Регистрирует ожидаемый файл от оборудования с адресом host. Содержимое придет в канал
+// после окончания загрузки
+func (s *TFTPServer) Expect(fileName string, host string) (<-chan []byte, error) {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, errors.New("invalid hardware ip address (" + host + ")")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan []byte, 1)
+	s.uploads[fileName] = tftpUpload{host: ip, ch: ch}
+
+	return ch, nil
+}
+
+// Cancel Снимает ожидание файла, например после таймаута
+func (s *TFTPServer) Cancel(fileName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.uploads, fileName)
+}
+
+func (s *TFTPServer) ListenAndServe(ctx context.Context) error {
+	conn, err := net.ListenPacket("udp", s.Address)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 1024)
+
+	for {
+		n, remote, e := conn.ReadFrom(buf)
+		if e != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return e
+		}
+
+		if n < 4 {
+			continue
+		}
+
+		opcode := binary.BigEndian.Uint16(buf[:2])
+
+		if opcode != tftpOpWRQ {
+			if opcode == tftpOpRRQ {
+				sendTFTPError(conn, remote, 2, "read is not supported")
+			}
+
+			continue
+		}
+
+		fields := bytes.Split(buf[2:n], []byte{0})
+		if len(fields) < 2 {
+			sendTFTPError(conn, remote, 4, "malformed request")
+			continue
+		}
+
+		fileName := strings.TrimLeft(string(fields[0]), "/")
+		mode := strings.ToLower(string(fields[1]))
+
+		s.mu.Lock()
+		upload, ok := s.uploads[fileName]
+		s.mu.Unlock()
+
+		if !ok {
+			sendTFTPError(conn, remote, 2, "unexpected file")
+			continue
+		}
+
+		// Имя файла предсказуемо, поэтому загрузка принимается только с адреса опрашиваемого оборудования
+		if udpAddr, isUDP := remote.(*net.UDPAddr); !isUDP || !udpAddr.IP.Equal(upload.host) {
+			sendTFTPError(conn, remote, 2, "unexpected sender")
+			continue
+		}
+
+		go s.receive(remote, fileName, mode, upload.ch)
+	}
+}
+
+// receive Принимает файл с отдельного порта, как требует протокол
+func (s *TFTPServer) receive(remote net.Addr, fileName string, mode string, ch chan []byte) {
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var (
+		data  bytes.Buffer
+		block uint16
+	)
+
+	ack := make([]byte, 4)
+	buf := make([]byte, tftpBlockSize+4)
+
+	for {
+		binary.BigEndian.PutUint16(ack[:2], tftpOpACK)
+		binary.BigEndian.PutUint16(ack[2:], block)
+
+		n, retries := 0, 0
+
+		for {
+			if _, err = conn.WriteTo(ack, remote); err != nil {
+				return
+			}
+
+			conn.SetReadDeadline(time.Now().Add(s.Timeout))
+
+			var from net.Addr
+
+			n, from, err = conn.ReadFrom(buf)
+			if err != nil {
+				var netErr net.Error
+
+				if errors.As(err, &netErr) && netErr.Timeout() && retries < tftpRetries {
+					retries++
+					continue
+				}
+
+				return
+			}
+
+			if from.String() != remote.String() || n < 4 {
+				continue
+			}
+
+			if binary.BigEndian.Uint16(buf[:2]) == tftpOpERROR {
+				return
+			}
+
+			// Повтор предыдущего блока означает, что наш ACK потерялся
+			if binary.BigEndian.Uint16(buf[:2]) == tftpOpDATA && binary.BigEndian.Uint16(buf[2:4]) == block+1 {
+				break
+			}
+		}
+
+		block++
+		data.Write(buf[4:n])
+
+		if n-4 < tftpBlockSize {
+			binary.BigEndian.PutUint16(ack[2:], block)
+			conn.WriteTo(ack, remote)
+			break
+		}
+	}
+
+	content := data.Bytes()
+
+	if mode == "netascii" {
+		content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+		content = bytes.ReplaceAll(content, []byte("\r\x00"), []byte("\r"))
+	}
+
+	s.mu.Lock()
+	delete(s.uploads, fileName)
+	s.mu.Unlock()
+
+	ch <- content
+}
+
+func sendTFTPError(conn net.PacketConn, remote net.Addr, code uint16, message string) {
+	packet := make([]byte, 4, 5+len(message))
+
+	binary.BigEndian.PutUint16(packet[:2], tftpOpERROR)
+	binary.BigEndian.PutUint16(packet[2:], code)
+
+	packet = append(packet, message...)
+	packet = append(packet, 0)
+
+	conn.WriteTo(packet, remote)
+}
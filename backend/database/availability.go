@@ -0,0 +1,336 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+)
+
+type AvailabilityRepository interface {
+	GetAvailabilityTargets() ([]models.Hardware, error)
+	SaveAvailabilityCheck(check models.AvailabilityCheck, failureThreshold int) error
+	CloseUnmonitoredOutages(closedAt int64) error
+	GetCurrentOutages() ([]models.Outage, error)
+	GetHardwareOutages(hardwareID int, from int64, to int64) ([]models.Outage, error)
+	GetAvailability(from int64, to int64, nodeID int, houseID int) ([]models.Availability, error)
+}
+
+type DefaultAvailabilityRepository struct {
+	Database Database
+}
+
+// GetAvailabilityTargets Возвращает оборудование с IP адресом. UptimeOID заполнен, только если у
+// оборудования есть модель коммутатора
+func (r *DefaultAvailabilityRepository) GetAvailabilityTargets() ([]models.Hardware, error) {
+	stmt, ok := r.Database.GetQuery("GET_AVAILABILITY_TARGETS")
+	if !ok {
+		return nil, errors.New("query GET_AVAILABILITY_TARGETS is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hardware []models.Hardware
+
+	for rows.Next() {
+		var (
+			hd       models.Hardware
+			switchID sql.NullInt64
+		)
+
+		if err = rows.Scan(
+			&hd.ID,
+			&hd.IpAddress,
+			&switchID,
+			&hd.Switch.CommunityRead,
+			&hd.Switch.UptimeOID,
+		); err != nil {
+			return nil, err
+		}
+
+		hd.Switch.ID = int(switchID.Int64)
+		hardware = append(hardware, hd)
+	}
+
+	return hardware, nil
+}
+
+// SaveAvailabilityCheck Сохраняет результат проверки в одной транзакции. check.IsUp - результат одной проверки,
+// недоступность подтверждается только после failureThreshold неудачных проверок подряд, до этого оборудование
+// остается доступным. При подтверждении открывается простой с времени первой неудачной проверки,
+// при восстановлении все открытые простои закрываются. Если uptime уменьшился с прошлой проверки,
+// записывается перезагрузка, которую проверки не застали
+func (r *DefaultAvailabilityRepository) SaveAvailabilityCheck(check models.AvailabilityCheck, failureThreshold int) error {
+	keys := []string{"GET_AVAILABILITY_STATE", "UPSERT_AVAILABILITY_STATE", "CREATE_OUTAGE", "CLOSE_OUTAGES"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var previous models.AvailabilityCheck
+
+	err = tx.Stmt(stmts["GET_AVAILABILITY_STATE"]).QueryRow(check.HardwareID).Scan(
+		&previous.HardwareID,
+		&previous.IsUp,
+		&previous.Method,
+		&previous.Rtt,
+		&previous.Uptime,
+		&previous.Error,
+		&previous.FirstCheckedAt,
+		&previous.CheckedAt,
+		&previous.ChangedAt,
+		&previous.Failures,
+		&previous.FailedAt,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	isFirst := errors.Is(err, sql.ErrNoRows)
+	wasUp := isFirst || previous.IsUp
+
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	if !check.IsUp {
+		check.Failures = previous.Failures + 1
+		check.FailedAt = previous.FailedAt
+
+		if !check.FailedAt.Valid {
+			check.FailedAt = sql.NullInt64{Int64: check.CheckedAt, Valid: true}
+		}
+
+		check.IsUp = wasUp && check.Failures < failureThreshold
+	}
+
+	check.FirstCheckedAt = previous.FirstCheckedAt
+	check.ChangedAt = previous.ChangedAt
+
+	if isFirst {
+		check.FirstCheckedAt = check.CheckedAt
+		check.ChangedAt = check.CheckedAt
+	}
+
+	if !isFirst && previous.IsUp != check.IsUp {
+		check.ChangedAt = check.CheckedAt
+	}
+
+	if wasUp && !check.IsUp {
+		check.ChangedAt = check.FailedAt.Int64
+	}
+
+	if _, err = tx.Stmt(stmts["UPSERT_AVAILABILITY_STATE"]).Exec(
+		check.HardwareID,
+		check.IsUp,
+		check.Method,
+		check.Rtt,
+		check.Uptime,
+		check.Error,
+		check.FirstCheckedAt,
+		check.CheckedAt,
+		check.ChangedAt,
+		check.Failures,
+		check.FailedAt,
+	); err != nil {
+		return err
+	}
+
+	createStmt := tx.Stmt(stmts["CREATE_OUTAGE"])
+
+	switch {
+	case wasUp && !check.IsUp:
+		if _, err = createStmt.Exec(check.HardwareID, models.OutageUnreachable, check.FailedAt.Int64, nil); err != nil {
+			return err
+		}
+	case check.IsUp && !isFirst && !previous.IsUp:
+		if _, err = tx.Stmt(stmts["CLOSE_OUTAGES"]).Exec(check.HardwareID, check.CheckedAt); err != nil {
+			return err
+		}
+	case check.IsUp && previous.IsUp && check.Uptime.Valid && previous.Uptime.Valid &&
+		check.Uptime.Int64 < previous.Uptime.Int64:
+		bootedAt := check.CheckedAt - check.Uptime.Int64
+
+		if _, err = createStmt.Exec(check.HardwareID, models.OutageReboot, bootedAt, bootedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CloseUnmonitoredOutages Закрывает открытые простои оборудования, которое больше не проверяется: удаленного,
+// в удаленном узле или без IP адреса, и сбрасывает его состояние доступности. Простой удаленного
+// оборудования закрывается временем удаления
+func (r *DefaultAvailabilityRepository) CloseUnmonitoredOutages(closedAt int64) error {
+	keys := []string{"CLOSE_UNMONITORED_OUTAGES", "DELETE_UNMONITORED_AVAILABILITY"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Stmt(stmts["CLOSE_UNMONITORED_OUTAGES"]).Exec(closedAt); err != nil {
+		return err
+	}
+
+	if _, err = tx.Stmt(stmts["DELETE_UNMONITORED_AVAILABILITY"]).Exec(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetCurrentOutages Возвращает незакрытые простои оборудования, начиная с самых долгих
+func (r *DefaultAvailabilityRepository) GetCurrentOutages() ([]models.Outage, error) {
+	stmt, ok := r.Database.GetQuery("GET_CURRENT_OUTAGES")
+	if !ok {
+		return nil, errors.New("query GET_CURRENT_OUTAGES is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outages := make([]models.Outage, 0)
+
+	for rows.Next() {
+		var (
+			outage     models.Outage
+			switchName sql.NullString
+		)
+
+		if err = rows.Scan(
+			&outage.ID,
+			&outage.Cause,
+			&outage.StartedAt,
+			&outage.EndedAt,
+			&outage.Hardware.ID,
+			&outage.Hardware.IpAddress,
+			&outage.Hardware.Type.Value,
+			&switchName,
+			&outage.Hardware.Node.ID,
+			&outage.Hardware.Node.Name,
+			&outage.Hardware.Node.HouseId,
+			&outage.Hardware.Node.Zone,
+		); err != nil {
+			return nil, err
+		}
+
+		outage.Hardware.Switch.Name = switchName.String
+		outages = append(outages, outage)
+	}
+
+	return outages, nil
+}
+
+// GetHardwareOutages Возвращает простои оборудования, пересекающиеся с периодом from - to
+func (r *DefaultAvailabilityRepository) GetHardwareOutages(hardwareID int, from int64, to int64) ([]models.Outage, error) {
+	stmt, ok := r.Database.GetQuery("GET_HARDWARE_OUTAGES")
+	if !ok {
+		return nil, errors.New("query GET_HARDWARE_OUTAGES is not prepare")
+	}
+
+	rows, err := stmt.Query(hardwareID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outages := make([]models.Outage, 0)
+
+	for rows.Next() {
+		var outage models.Outage
+
+		if err = rows.Scan(
+			&outage.ID,
+			&outage.Cause,
+			&outage.StartedAt,
+			&outage.EndedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		outage.Hardware.ID = hardwareID
+		outages = append(outages, outage)
+	}
+
+	return outages, nil
+}
+
+// GetAvailability Возвращает доступность каждого наблюдаемого оборудования за период from - to.
+// При nodeID или houseID, отличных от 0, выбирается только оборудование узла или дома
+func (r *DefaultAvailabilityRepository) GetAvailability(from int64, to int64, nodeID int, houseID int) ([]models.Availability, error) {
+	stmt, ok := r.Database.GetQuery("GET_AVAILABILITY")
+	if !ok {
+		return nil, errors.New("query GET_AVAILABILITY is not prepare")
+	}
+
+	rows, err := stmt.Query(from, to, nodeID, houseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	availability := make([]models.Availability, 0)
+
+	for rows.Next() {
+		var (
+			item       models.Availability
+			hd         models.Hardware
+			switchName sql.NullString
+		)
+
+		if err = rows.Scan(
+			&hd.ID,
+			&hd.IpAddress,
+			&hd.Type.Value,
+			&switchName,
+			&hd.Node.ID,
+			&hd.Node.Name,
+			&hd.Node.HouseId,
+			&hd.Node.Zone,
+			&item.Monitored,
+			&item.Downtime,
+			&item.Outages,
+		); err != nil {
+			return nil, err
+		}
+
+		hd.Switch.Name = switchName.String
+
+		item.Hardware = &hd
+		item.HouseID = hd.Node.HouseId
+		availability = append(availability, item)
+	}
+
+	return availability, nil
+}
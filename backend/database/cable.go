@@ -0,0 +1,367 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+)
+
+type CableRepository interface {
+	GetCables(nodeID int, hardwareID int) ([]models.Cable, error)
+	GetCable(cableID int) (*models.Cable, error)
+	CreateCable(cable *models.Cable) error
+	EditCable(cable *models.Cable) error
+	DeleteCable(cableID int) error
+	PortHasCable(portID int, cableID int) (bool, error)
+	TraceToBackbone(nodeID int) (*models.CableTrace, error)
+	ValidateCable(cable models.Cable) bool
+}
+
+type DefaultCableRepository struct {
+	Database Database
+}
+
+// BackboneNodeType Ключ типа магистрального узла, до которого строится трассировка
+const BackboneNodeType = "BN"
+
+// GetCables Возвращает кабели, хотя бы один конец которых в узле nodeID или на порту оборудования
+// hardwareID. При нулевых значениях фильтры не применяются
+func (r *DefaultCableRepository) GetCables(nodeID int, hardwareID int) ([]models.Cable, error) {
+	return r.getCables(nodeID, hardwareID, 0)
+}
+
+// GetCable Возвращает кабель с разобранными концами или sql.ErrNoRows
+func (r *DefaultCableRepository) GetCable(cableID int) (*models.Cable, error) {
+	cables, err := r.getCables(0, 0, cableID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cables) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &cables[0], nil
+}
+
+func (r *DefaultCableRepository) getCables(nodeID int, hardwareID int, cableID int) ([]models.Cable, error) {
+	stmt, ok := r.Database.GetQuery("GET_CABLES")
+	if !ok {
+		return nil, errors.New("query GET_CABLES is not prepare")
+	}
+
+	rows, err := stmt.Query(nodeID, hardwareID, cableID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cables := make([]models.Cable, 0)
+
+	for rows.Next() {
+		var (
+			cable                              models.Cable
+			aTypeKey, aTypeValue               sql.NullString
+			bTypeKey, bTypeValue               sql.NullString
+			aHardwareID, bHardwareID           sql.NullInt64
+			aHardwareType, bHardwareType       sql.NullString
+			aPortID, bPortID                   sql.NullInt64
+			aPortNumber, bPortNumber           sql.NullInt64
+			aPortDescription, bPortDescription sql.NullString
+		)
+
+		if err = rows.Scan(
+			&cable.ID,
+			&cable.Medium,
+			&cable.FiberCount,
+			&cable.Length,
+			&cable.Label,
+			&cable.Description,
+			&cable.CreatedAt,
+			&cable.UpdatedAt,
+			&cable.A.Node.ID,
+			&cable.A.Node.Name,
+			&cable.A.Node.HouseId,
+			&cable.A.Node.IsPassive,
+			&aTypeKey,
+			&aTypeValue,
+			&aHardwareID,
+			&aHardwareType,
+			&aPortID,
+			&aPortNumber,
+			&aPortDescription,
+			&cable.B.Node.ID,
+			&cable.B.Node.Name,
+			&cable.B.Node.HouseId,
+			&cable.B.Node.IsPassive,
+			&bTypeKey,
+			&bTypeValue,
+			&bHardwareID,
+			&bHardwareType,
+			&bPortID,
+			&bPortNumber,
+			&bPortDescription,
+		); err != nil {
+			return nil, err
+		}
+
+		if aTypeKey.Valid {
+			cable.A.Node.Type = &models.Reference{Key: aTypeKey.String, Value: aTypeValue.String}
+		}
+
+		if bTypeKey.Valid {
+			cable.B.Node.Type = &models.Reference{Key: bTypeKey.String, Value: bTypeValue.String}
+		}
+
+		if aPortID.Valid {
+			cable.A.Hardware = &models.Hardware{ID: int(aHardwareID.Int64), Type: models.Reference{Value: aHardwareType.String}}
+			cable.A.Port = &models.HardwarePort{
+				ID:          int(aPortID.Int64),
+				Hardware:    models.Hardware{ID: int(aHardwareID.Int64)},
+				Number:      int(aPortNumber.Int64),
+				Description: aPortDescription,
+			}
+		}
+
+		if bPortID.Valid {
+			cable.B.Hardware = &models.Hardware{ID: int(bHardwareID.Int64), Type: models.Reference{Value: bHardwareType.String}}
+			cable.B.Port = &models.HardwarePort{
+				ID:          int(bPortID.Int64),
+				Hardware:    models.Hardware{ID: int(bHardwareID.Int64)},
+				Number:      int(bPortNumber.Int64),
+				Description: bPortDescription,
+			}
+		}
+
+		cables = append(cables, cable)
+	}
+
+	return cables, nil
+}
+
+func (r *DefaultCableRepository) CreateCable(cable *models.Cable) error {
+	stmt, ok := r.Database.GetQuery("CREATE_CABLE")
+	if !ok {
+		return errors.New("query CREATE_CABLE is not prepare")
+	}
+
+	aNodeID, aPortID := cableEndpointArgs(cable.A)
+	bNodeID, bPortID := cableEndpointArgs(cable.B)
+
+	return stmt.QueryRow(
+		aNodeID,
+		aPortID,
+		bNodeID,
+		bPortID,
+		cable.Medium,
+		cable.Length,
+		cable.Label,
+		cable.Description,
+		cable.CreatedAt,
+		cable.FiberCount,
+	).Scan(&cable.ID)
+}
+
+func (r *DefaultCableRepository) EditCable(cable *models.Cable) error {
+	stmt, ok := r.Database.GetQuery("EDIT_CABLE")
+	if !ok {
+		return errors.New("query EDIT_CABLE is not prepare")
+	}
+
+	aNodeID, aPortID := cableEndpointArgs(cable.A)
+	bNodeID, bPortID := cableEndpointArgs(cable.B)
+
+	res, err := stmt.Exec(
+		cable.ID,
+		aNodeID,
+		aPortID,
+		bNodeID,
+		bPortID,
+		cable.Medium,
+		cable.Length,
+		cable.Label,
+		cable.Description,
+		cable.UpdatedAt,
+		cable.FiberCount,
+	)
+	if err != nil {
+		return err
+	}
+
+	if affected, e := res.RowsAffected(); e == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *DefaultCableRepository) DeleteCable(cableID int) error {
+	stmt, ok := r.Database.GetQuery("DELETE_CABLE")
+	if !ok {
+		return errors.New("query DELETE_CABLE is not prepare")
+	}
+
+	res, err := stmt.Exec(cableID)
+	if err != nil {
+		return err
+	}
+
+	if affected, e := res.RowsAffected(); e == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// PortHasCable Проверяет, подключен ли к порту другой кабель, кроме cableID
+func (r *DefaultCableRepository) PortHasCable(portID int, cableID int) (bool, error) {
+	stmt, ok := r.Database.GetQuery("CHECK_PORT_CABLE")
+	if !ok {
+		return false, errors.New("query CHECK_PORT_CABLE is not prepare")
+	}
+
+	var exists bool
+
+	if err := stmt.QueryRow(portID, cableID).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// TraceToBackbone Ищет по кабелям кратчайший путь от узла до ближайшего магистрального узла (поиск в ширину).
+// Если узел сам магистральный, путь пустой. Если пути нет, возвращается sql.ErrNoRows
+func (r *DefaultCableRepository) TraceToBackbone(nodeID int) (*models.CableTrace, error) {
+	cables, err := r.GetCables(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return traceToBackbone(cables, nodeID)
+}
+
+func traceToBackbone(cables []models.Cable, nodeID int) (*models.CableTrace, error) {
+	type edge struct {
+		cable   int
+		forward bool
+	}
+
+	nodes := make(map[int]models.Node)
+	edges := make(map[int][]edge)
+
+	for i, cable := range cables {
+		nodes[cable.A.Node.ID] = cable.A.Node
+		nodes[cable.B.Node.ID] = cable.B.Node
+
+		if cable.A.Node.ID == cable.B.Node.ID {
+			continue
+		}
+
+		edges[cable.A.Node.ID] = append(edges[cable.A.Node.ID], edge{i, true})
+		edges[cable.B.Node.ID] = append(edges[cable.B.Node.ID], edge{i, false})
+	}
+
+	isBackbone := func(id int) bool {
+		node, ok := nodes[id]
+		return ok && node.Type != nil && node.Type.Key == BackboneNodeType
+	}
+
+	if isBackbone(nodeID) {
+		return &models.CableTrace{Hops: make([]models.CableHop, 0), Backbone: nodes[nodeID]}, nil
+	}
+
+	previous := map[int]edge{nodeID: {cable: -1}}
+	queue := []int{nodeID}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if isBackbone(current) {
+			trace := &models.CableTrace{Backbone: nodes[current]}
+
+			for id := current; previous[id].cable != -1; {
+				e := previous[id]
+				cable := cables[e.cable]
+				hop := models.CableHop{Cable: cable, From: cable.A, To: cable.B}
+
+				if !e.forward {
+					hop.From, hop.To = cable.B, cable.A
+				}
+
+				trace.Hops = append([]models.CableHop{hop}, trace.Hops...)
+				trace.Length += int(cable.Length.Int32)
+				id = hop.From.Node.ID
+			}
+
+			return trace, nil
+		}
+
+		for _, e := range edges[current] {
+			next := cables[e.cable].B.Node.ID
+			if !e.forward {
+				next = cables[e.cable].A.Node.ID
+			}
+
+			if _, ok := previous[next]; ok {
+				continue
+			}
+
+			previous[next] = e
+			queue = append(queue, next)
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// ValidateCable Проверяет среду, количество волокон, длину и то, что каждый конец - это либо порт,
+// либо узел, и концы не совпадают
+func (r *DefaultCableRepository) ValidateCable(cable models.Cable) bool {
+	if _, ok := models.CableMediums[cable.Medium]; !ok {
+		return false
+	}
+
+	if cable.Medium == models.CableMediumCopper {
+		if cable.FiberCount.Valid {
+			return false
+		}
+	} else if !cable.FiberCount.Valid || cable.FiberCount.Int32 <= 0 || cable.FiberCount.Int32 > models.MaxFiberCount {
+		return false
+	}
+
+	if cable.Length.Valid && cable.Length.Int32 < 0 {
+		return false
+	}
+
+	aNodeID, aPortID := cableEndpointArgs(cable.A)
+	bNodeID, bPortID := cableEndpointArgs(cable.B)
+
+	if (aNodeID == nil && aPortID == nil) || (bNodeID == nil && bPortID == nil) {
+		return false
+	}
+
+	if aPortID != nil && aPortID == bPortID {
+		return false
+	}
+
+	if aNodeID != nil && aNodeID == bNodeID {
+		return false
+	}
+
+	return true
+}
+
+// cableEndpointArgs Возвращает node_id и port_id конца кабеля. Если указан порт, узел не сохраняется,
+// он определяется по оборудованию порта
+func cableEndpointArgs(endpoint models.CableEndpoint) (interface{}, interface{}) {
+	if endpoint.Port != nil && endpoint.Port.ID != 0 {
+		return nil, endpoint.Port.ID
+	}
+
+	if endpoint.Node.ID != 0 {
+		return endpoint.Node.ID, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTraceToBackbone(t *testing.T) {
+	node := func(id int, typeKey string) models.Node {
+		return models.Node{ID: id, Type: &models.Reference{Key: typeKey}}
+	}
+
+	cable := func(id int, a models.Node, b models.Node, length int32) models.Cable {
+		return models.Cable{
+			ID:     id,
+			A:      models.CableEndpoint{Node: a},
+			B:      models.CableEndpoint{Node: b},
+			Length: sql.NullInt32{Int32: length, Valid: length > 0},
+		}
+	}
+
+	access := node(1, "AN")
+	passive := node(2, "PN")
+	backbone := node(3, "BN")
+	far := node(4, "AN")
+	farBackbone := node(5, "BN")
+	isolatedA := node(6, "AN")
+	isolatedB := node(7, "AN")
+
+	cables := []models.Cable{
+		cable(10, access, passive, 100),
+		cable(11, backbone, passive, 50),
+		cable(12, passive, far, 0),
+		cable(13, far, farBackbone, 500),
+		cable(14, isolatedA, isolatedB, 10),
+		cable(15, access, access, 1),
+	}
+
+	tests := []struct {
+		name     string
+		nodeID   int
+		hops     []int // кабель и узел From каждого участка
+		backbone int
+		length   int
+		err      error
+	}{
+		{name: "shortest path", nodeID: 1, hops: []int{10, 1, 11, 2}, backbone: 3, length: 150},
+		{name: "reversed cable", nodeID: 2, hops: []int{11, 2}, backbone: 3, length: 50},
+		{name: "backbone node itself", nodeID: 5, hops: []int{}, backbone: 5},
+		{name: "nearest backbone by hops", nodeID: 4, hops: []int{13, 4}, backbone: 5, length: 500},
+		{name: "no path", nodeID: 6, err: sql.ErrNoRows},
+		{name: "unknown node", nodeID: 99, err: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace, err := traceToBackbone(cables, tt.nodeID)
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("traceToBackbone() error = %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("traceToBackbone() error = %v", err)
+			}
+
+			hops := make([]int, 0)
+
+			for _, hop := range trace.Hops {
+				hops = append(hops, hop.Cable.ID, hop.From.Node.ID)
+			}
+
+			if !reflect.DeepEqual(hops, tt.hops) {
+				t.Errorf("traceToBackbone() hops = %v, want %v", hops, tt.hops)
+			}
+
+			if trace.Backbone.ID != tt.backbone {
+				t.Errorf("traceToBackbone() backbone = %d, want %d", trace.Backbone.ID, tt.backbone)
+			}
+
+			if trace.Length != tt.length {
+				t.Errorf("traceToBackbone() length = %d, want %d", trace.Length, tt.length)
+			}
+		})
+	}
+}
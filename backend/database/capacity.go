@@ -0,0 +1,72 @@
+package database
+
+import (
+	"backend/models"
+	"errors"
+)
+
+type CapacityRepository interface {
+	GetPortCapacity(nodeID int, houseID int, zone string) ([]models.Capacity, error)
+}
+
+type DefaultCapacityRepository struct {
+	Database Database
+}
+
+// GetPortCapacity Возвращает емкость портов установленного оборудования с моделью коммутатора.
+// Учитываются только порты с номерами от 1 до PortAmount, остальные интерфейсы (VLAN, агрегаты) не считаются.
+// У оборудования, последний опрос которого не удался или которое еще не опрашивалось, свободные порты
+// считаются неизвестными
+func (r *DefaultCapacityRepository) GetPortCapacity(nodeID int, houseID int, zone string) ([]models.Capacity, error) {
+	stmt, ok := r.Database.GetQuery("GET_PORT_CAPACITY")
+	if !ok {
+		return nil, errors.New("query GET_PORT_CAPACITY is not prepare")
+	}
+
+	rows, err := stmt.Query(nodeID, houseID, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.Capacity, 0)
+
+	for rows.Next() {
+		var (
+			item models.Capacity
+			hd   models.Hardware
+		)
+
+		if err = rows.Scan(
+			&hd.ID,
+			&hd.IpAddress,
+			&hd.Type.Value,
+			&hd.Switch.Name,
+			&hd.Node.ID,
+			&hd.Node.Name,
+			&hd.Node.HouseId,
+			&hd.Node.Zone,
+			&item.Total,
+			&item.Used,
+			&item.IsPolled,
+		); err != nil {
+			return nil, err
+		}
+
+		if !item.IsPolled {
+			item.Unknown = item.Total - item.Used
+			item.UnpolledCount = 1
+		}
+
+		hd.Switch.PortAmount = item.Total
+
+		item.Hardware = &hd
+		item.HouseID = hd.Node.HouseId
+		item.Zone = hd.Node.Zone
+		item.HardwareCount = 1
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+)
+
+type ConfigRepository interface {
+	GetHardwareConfigs(hardwareID int) ([]models.HardwareConfig, error)
+	GetHardwareConfig(hardwareID int, version int) (*models.HardwareConfig, error)
+	CreateHardwareConfig(config *models.HardwareConfig, event *models.Event) error
+}
+
+type DefaultConfigRepository struct {
+	Database Database
+}
+
+func (r *DefaultConfigRepository) GetHardwareConfigs(hardwareID int) ([]models.HardwareConfig, error) {
+	stmt, ok := r.Database.GetQuery("GET_HARDWARE_CONFIGS")
+	if !ok {
+		return nil, errors.New("query GET_HARDWARE_CONFIGS is not prepare")
+	}
+
+	rows, err := stmt.Query(hardwareID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	configs := make([]models.HardwareConfig, 0)
+
+	for rows.Next() {
+		var config models.HardwareConfig
+
+		if err = rows.Scan(
+			&config.ID,
+			&config.Hardware.ID,
+			&config.Version,
+			&config.Path,
+			&config.Hash,
+			&config.Size,
+			&config.UserId,
+			&config.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// GetHardwareConfig Возвращает версию конфигурации, при version = 0 - последнюю.
+// Если версий нет, возвращается sql.ErrNoRows
+func (r *DefaultConfigRepository) GetHardwareConfig(hardwareID int, version int) (*models.HardwareConfig, error) {
+	stmt, ok := r.Database.GetQuery("GET_HARDWARE_CONFIG")
+	if !ok {
+		return nil, errors.New("query GET_HARDWARE_CONFIG is not prepare")
+	}
+
+	var config models.HardwareConfig
+
+	if err := stmt.QueryRow(hardwareID, version).Scan(
+		&config.ID,
+		&config.Hardware.ID,
+		&config.Version,
+		&config.Path,
+		&config.Hash,
+		&config.Size,
+		&config.UserId,
+		&config.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// CreateHardwareConfig Сохраняет новую версию конфигурации и, если передано, событие в одной транзакции.
+// Строка оборудования блокируется до расчета номера версии, поэтому плановое и ручное снятие
+// конфигурации одного оборудования получают версии по очереди
+func (r *DefaultConfigRepository) CreateHardwareConfig(config *models.HardwareConfig, event *models.Event) error {
+	keys := []string{"LOCK_HARDWARE", "CREATE_HARDWARE_CONFIG", "CREATE_EVENT"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var hardwareID int
+
+	if err = tx.Stmt(stmts["LOCK_HARDWARE"]).QueryRow(config.Hardware.ID).Scan(&hardwareID); err != nil {
+		return err
+	}
+
+	if err = tx.Stmt(stmts["CREATE_HARDWARE_CONFIG"]).QueryRow(
+		config.Hardware.ID,
+		config.Path,
+		config.Hash,
+		config.Size,
+		config.UserId,
+		config.CreatedAt,
+	).Scan(&config.ID, &config.Version); err != nil {
+		return err
+	}
+
+	if event != nil {
+		if err = createEvent(tx.Stmt(stmts["CREATE_EVENT"]), *event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -10,6 +10,7 @@ type Database interface {
 	Connect() error
 	PrepareQuery() []error
 	GetQuery(key string) (*sql.Stmt, bool)
+	Begin() (*sql.Tx, error)
 }
 
 type DefaultDatabase struct {
@@ -39,6 +40,10 @@ func (d *DefaultDatabase) GetQuery(key string) (*sql.Stmt, bool) {
 	return stmt, ok
 }
 
+func (d *DefaultDatabase) Begin() (*sql.Tx, error) {
+	return d.db.Begin()
+}
+
 func (d *DefaultDatabase) PrepareQuery() []error {
 	var err error
 	errorsList := make([]error, 0)
@@ -353,9 +358,23 @@ func (d *DefaultDatabase) PrepareQuery() []error {
 		errorsList = append(errorsList, err)
 	}
 
+	d.query["GET_HARDWARE_BY_NODE_IDS"], err = d.db.Prepare(`
+		SELECT hd.id, hd.node_id, hd.type_id, hd.switch_id, hd.ip_address, n.house_id, hdt.key, hdt.value, sw.name, n.name
+		FROM "Hardware" AS hd
+		JOIN "Node" AS n ON hd.node_id = n.id
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		WHERE hd.node_id = ANY($1) AND hd.is_delete = false
+		ORDER BY hd.node_id, hd.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
 	d.query["CREATE_HARDWARE"], err = d.db.Prepare(`
-		INSERT INTO "Hardware" (node_id, type_id, switch_id, ip_address, mgmt_vlan, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO "Hardware" (node_id, type_id, switch_id, ip_address, mgmt_vlan, description, created_at, updated_at,
+		                        serial_number, inventory_number, purchase_date, warranty_end, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id
     `)
 	if err != nil {
@@ -364,15 +383,68 @@ func (d *DefaultDatabase) PrepareQuery() []error {
 
 	d.query["EDIT_HARDWARE"], err = d.db.Prepare(`
 		UPDATE "Hardware" SET node_id = $2, type_id = $3, switch_id = $4, ip_address = $5, mgmt_vlan = $6, description = $7,
-		                      updated_at = $8
+		                      updated_at = $8, serial_number = $9, inventory_number = $10, purchase_date = $11,
+		                      warranty_end = $12, status = COALESCE(NULLIF($13, ''), status)
 		WHERE id = $1
+		RETURNING status
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_HARDWARE"], err = d.db.Prepare(`
+		UPDATE "Hardware" SET is_delete = true, deleted_at = $2, deleted_by = $3 WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["RESTORE_HARDWARE"], err = d.db.Prepare(`
+		UPDATE "Hardware" SET is_delete = false, deleted_at = NULL, deleted_by = NULL, updated_at = $2 WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_DELETED_HARDWARE"], err = d.db.Prepare(`
+		SELECT hd.id, hd.node_id, hd.type_id, hd.switch_id, hd.ip_address, n.house_id, hdt.key, hdt.value, sw.name, n.name, 
+		       n.is_delete, hd.deleted_at, hd.deleted_by, COUNT(*) OVER()
+		FROM "Hardware" AS hd
+		JOIN "Node" AS n ON hd.node_id = n.id
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		WHERE hd.is_delete = true
+		ORDER BY hd.deleted_at DESC NULLS LAST, hd.id DESC
+		OFFSET $1
+		LIMIT 20
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_INVENTORY_HARDWARE"], err = d.db.Prepare(`
+		SELECT hd.id, hd.node_id, n.name, n.house_id, hdt.value, hd.switch_id, sw.name, hd.ip_address, hd.mgmt_vlan,
+		       hd.description, hd.is_delete, hd.created_at, hd.updated_at, hd.serial_number, hd.inventory_number,
+		       hd.purchase_date, hd.warranty_end, hd.status
+		FROM "Hardware" AS hd
+		JOIN "Node" AS n ON hd.node_id = n.id
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		WHERE ($1 = 0 OR n.house_id = $1)
+			AND ($2 = 0 OR n.owner_id = $2)
+			AND ($3 = '' OR n.zone = $3)
+			AND ($4 = 0 OR n.type_id = $4)
+			AND ($5::boolean IS NULL OR hd.is_delete = $5)
+			AND ($6::boolean IS NULL OR n.is_passive = $6)
+			AND ($7 = 0 OR hd.type_id = $7)
+		ORDER BY n.house_id, hd.node_id, hd.id
     `)
 	if err != nil {
 		errorsList = append(errorsList, err)
 	}
 
 	d.query["GET_HARDWARE_BY_ID"], err = d.db.Prepare(`
-		SELECT hd.*, n.house_id, hdt.key, hdt.value, sw.name, n.name
+		SELECT hd.*, n.house_id, hdt.key, hdt.value, sw.name, n.name, n.is_delete
 		FROM "Hardware" AS hd
 		JOIN "Node" AS n ON hd.node_id = n.id
 		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
@@ -428,9 +500,156 @@ func (d *DefaultDatabase) PrepareQuery() []error {
 		errorsList = append(errorsList, err)
 	}
 
+	d.query["GET_NODE_TREE"], err = d.db.Prepare(`
+		WITH RECURSIVE tree AS (
+		    SELECT n.id, n.parent_id, 0 AS depth, ARRAY[n.id] AS path
+		    FROM "Node" AS n
+		    WHERE n.id = $1
+		    UNION ALL
+		    SELECT c.id, c.parent_id, t.depth + 1, t.path || c.id
+		    FROM "Node" AS c
+		    JOIN tree AS t ON c.parent_id = t.id
+		    WHERE c.is_delete = false AND NOT c.id = ANY(t.path)
+		)
+		SELECT t.id, t.parent_id, t.depth, n.house_id, n.name, n.zone, n.is_passive, nt.id, nt.key, nt.value, COUNT(hd.id)
+		FROM tree AS t
+		JOIN "Node" AS n ON t.id = n.id
+		LEFT JOIN "Node_type" AS nt ON n.type_id = nt.id
+		LEFT JOIN "Hardware" AS hd ON hd.node_id = n.id AND hd.is_delete = false
+		GROUP BY t.id, t.parent_id, t.depth, n.id, nt.id
+		ORDER BY t.depth, t.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_NODE_PATH"], err = d.db.Prepare(`
+		WITH RECURSIVE path AS (
+		    SELECT n.id, n.parent_id, n.type_id, 0 AS depth, ARRAY[n.id] AS visited
+		    FROM "Node" AS n
+		    WHERE n.id = $1
+		    UNION ALL
+		    SELECT p.id, p.parent_id, p.type_id, pa.depth + 1, pa.visited || p.id
+		    FROM "Node" AS p
+		    JOIN path AS pa ON p.id = pa.parent_id
+		    LEFT JOIN "Node_type" AS pat ON pa.type_id = pat.id
+		    WHERE NOT p.id = ANY(pa.visited) AND p.is_delete = false AND (pat.key IS NULL OR pat.key <> 'BN')
+		)
+		SELECT pa.id, pa.parent_id, pa.depth, n.house_id, n.name, n.zone, n.is_passive, nt.id, nt.key, nt.value, COUNT(hd.id)
+		FROM path AS pa
+		JOIN "Node" AS n ON pa.id = n.id
+		LEFT JOIN "Node_type" AS nt ON n.type_id = nt.id
+		LEFT JOIN "Hardware" AS hd ON hd.node_id = n.id AND hd.is_delete = false
+		GROUP BY pa.id, pa.parent_id, pa.depth, n.id, nt.id
+		ORDER BY pa.depth DESC
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CHECK_NODE_CYCLE"], err = d.db.Prepare(`
+		WITH RECURSIVE ancestors AS (
+		    SELECT n.id, n.parent_id, ARRAY[n.id] AS path
+		    FROM "Node" AS n
+		    WHERE n.id = $1
+		    UNION ALL
+		    SELECT n.id, n.parent_id, a.path || n.id
+		    FROM "Node" AS n
+		    JOIN ancestors AS a ON n.id = a.parent_id
+		    WHERE NOT n.id = ANY(a.path)
+		)
+		SELECT path FROM ancestors WHERE id = $2 LIMIT 1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_NODE_TYPE_RELATION"], err = d.db.Prepare(`
+		SELECT pt.key, ct.key,
+		       EXISTS(SELECT 1 FROM "Node_type_rule" AS r WHERE r.parent_type_id = pt.id AND r.child_type_id = ct.id),
+		       ARRAY(
+		           SELECT apt.key 
+		           FROM "Node_type_rule" AS ar
+		           JOIN "Node_type" AS apt ON ar.parent_type_id = apt.id
+		           WHERE ar.child_type_id = ct.id
+		           ORDER BY apt.key
+		       )
+		FROM "Node" AS p
+		LEFT JOIN "Node_type" AS pt ON p.type_id = pt.id
+		JOIN "Node_type" AS ct ON ct.id = $2
+		WHERE p.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_NODE_CHILD_TYPE_VIOLATION"], err = d.db.Prepare(`
+		SELECT c.id, ct.key, pt.key,
+		       ARRAY(
+		           SELECT apt.key
+		           FROM "Node_type_rule" AS ar
+		           JOIN "Node_type" AS apt ON ar.parent_type_id = apt.id
+		           WHERE ar.child_type_id = ct.id
+		           ORDER BY apt.key
+		       )
+		FROM "Node" AS n
+		JOIN "Node_type" AS pt ON pt.id = $2
+		JOIN "Node" AS c ON c.parent_id = n.id AND c.is_delete = false AND c.is_passive = false
+		JOIN "Node_type" AS ct ON c.type_id = ct.id
+		WHERE n.id = $1 AND n.type_id IS DISTINCT FROM $2
+		  AND NOT EXISTS(SELECT 1 FROM "Node_type_rule" AS r WHERE r.parent_type_id = pt.id AND r.child_type_id = ct.id)
+		ORDER BY c.id
+		LIMIT 1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_NODE_TYPE_RULES"], err = d.db.Prepare(`
+		SELECT r.id, r.created_at, pt.id, pt.key, pt.value, ct.id, ct.key, ct.value
+		FROM "Node_type_rule" AS r
+		JOIN "Node_type" AS pt ON r.parent_type_id = pt.id
+		JOIN "Node_type" AS ct ON r.child_type_id = ct.id
+		ORDER BY pt.id, ct.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_NODE_TYPE_RULE"], err = d.db.Prepare(`
+		INSERT INTO "Node_type_rule"(parent_type_id, child_type_id, created_at) VALUES ($1, $2, $3)
+		RETURNING id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_NODE_TYPE_RULE"], err = d.db.Prepare(`
+		DELETE FROM "Node_type_rule" WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["MOVE_NODE"], err = d.db.Prepare(`
+		UPDATE "Node" SET parent_id = $2, house_id = CASE WHEN $3 = 0 THEN house_id ELSE $3 END, updated_at = $4
+		WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["TOUCH_NODES"], err = d.db.Prepare(`
+		UPDATE "Node" SET updated_at = $2 WHERE id = ANY($1)
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
 	d.query["CREATE_NODE"], err = d.db.Prepare(`
-		INSERT INTO "Node"(parent_id, house_id, type_id, owner_id, name, zone, placement, supply, access, description, created_at, updated_at, is_passive) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		INSERT INTO "Node"(parent_id, house_id, type_id, owner_id, name, zone, placement, supply, access, description, created_at, updated_at, is_passive,
+		                   latitude, longitude) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING id
     `)
 	if err != nil {
@@ -439,7 +658,8 @@ func (d *DefaultDatabase) PrepareQuery() []error {
 
 	d.query["EDIT_NODE"], err = d.db.Prepare(`
 		UPDATE "Node" SET parent_id = $2, type_id = $3, owner_id = $4, name = $5, zone = $6, placement = $7, supply = $8,
-		                  access = $9, description = $10, updated_at = $11, house_id = $12, is_passive = $13
+		                  access = $9, description = $10, updated_at = $11, house_id = $12, is_passive = $13,
+		                  latitude = $14, longitude = $15
 		WHERE id = $1	
     `)
 	if err != nil {
@@ -457,7 +677,76 @@ func (d *DefaultDatabase) PrepareQuery() []error {
 	}
 
 	d.query["DELETE_NODE"], err = d.db.Prepare(`
-		UPDATE "Node" SET is_delete = true WHERE id = $1
+		UPDATE "Node" SET is_delete = true, deleted_at = $2, deleted_by = $3 WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["RESTORE_NODE"], err = d.db.Prepare(`
+		UPDATE "Node" SET is_delete = false, deleted_at = NULL, deleted_by = NULL, updated_at = $2 WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_DELETED_NODES"], err = d.db.Prepare(`
+		SELECT n.id, n.house_id, n.owner_id, n.name, n.zone, n.is_passive, no.value, nt.key, n.deleted_at, n.deleted_by, COUNT(*) OVER ()
+		FROM "Node" AS n 
+		JOIN "Node_owner" AS no ON n.owner_id = no.id
+		LEFT JOIN "Node_type" AS nt ON n.type_id = nt.id
+		WHERE n.is_delete = true
+		ORDER BY n.deleted_at DESC NULLS LAST, n.id DESC
+		OFFSET $1
+		LIMIT 20
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_INVENTORY_NODES"], err = d.db.Prepare(`
+		SELECT n.id, n.parent_id, p.name, n.house_id, n.name, nt.value, no.value, n.zone, n.placement, n.supply, n.access,
+		       n.description, n.is_passive, n.is_delete, n.created_at, n.updated_at
+		FROM "Node" AS n
+		JOIN "Node_owner" AS no ON n.owner_id = no.id
+		LEFT JOIN "Node_type" AS nt ON n.type_id = nt.id
+		LEFT JOIN "Node" AS p ON n.parent_id = p.id
+		WHERE ($1 = 0 OR n.house_id = $1)
+			AND ($2 = 0 OR n.owner_id = $2)
+			AND ($3 = '' OR n.zone = $3)
+			AND ($4 = 0 OR n.type_id = $4)
+			AND ($5::boolean IS NULL OR n.is_delete = $5)
+			AND ($6::boolean IS NULL OR n.is_passive = $6)
+		ORDER BY n.house_id, n.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_NODES_IN_BBOX"], err = d.db.Prepare(`
+		SELECT n.id, n.parent_id, n.house_id, n.name, n.zone, n.is_passive, nt.key, nt.value, no.value, n.latitude, n.longitude
+		FROM "Node" AS n
+		JOIN "Node_owner" AS no ON n.owner_id = no.id
+		LEFT JOIN "Node_type" AS nt ON n.type_id = nt.id
+		WHERE n.is_delete = false
+			AND n.longitude BETWEEN $1 AND $3
+			AND n.latitude BETWEEN $2 AND $4
+		ORDER BY n.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_NODE_LINKS_IN_BBOX"], err = d.db.Prepare(`
+		SELECT n.id, n.name, n.latitude, n.longitude, p.id, p.name, p.latitude, p.longitude
+		FROM "Node" AS n
+		JOIN "Node" AS p ON n.parent_id = p.id
+		WHERE n.is_delete = false AND p.is_delete = false
+			AND n.latitude IS NOT NULL AND n.longitude IS NOT NULL
+			AND p.latitude IS NOT NULL AND p.longitude IS NOT NULL
+			AND ((n.longitude BETWEEN $1 AND $3 AND n.latitude BETWEEN $2 AND $4)
+				OR (p.longitude BETWEEN $1 AND $3 AND p.latitude BETWEEN $2 AND $4))
+		ORDER BY n.id
     `)
 	if err != nil {
 		errorsList = append(errorsList, err)
@@ -598,8 +887,9 @@ func (d *DefaultDatabase) PrepareQuery() []error {
 	d.query["CREATE_SWITCH"], err = d.db.Prepare(`
 		INSERT INTO "Switch"(name, operation_mode_id, community_read, community_write, port_amount, firmware_oid, 
 		                     system_name_oid, sn_oid, save_config_oid, port_desc_oid, vlan_oid, port_untagged_oid, 
-		                     speed_oid, battery_status_oid, battery_charge_oid, port_mode_oid, uptime_oid, created_at, mac_oid) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		                     speed_oid, battery_status_oid, battery_charge_oid, port_mode_oid, uptime_oid, created_at, mac_oid,
+		                     target_firmware, lldp_chassis_id_oid, lldp_sys_name_oid, lldp_port_id_oid) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
 		RETURNING id
     `)
 	if err != nil {
@@ -610,7 +900,8 @@ func (d *DefaultDatabase) PrepareQuery() []error {
 		UPDATE "Switch" SET name = $2, operation_mode_id = $3, community_read = $4, community_write = $5, port_amount = $6,
 		                    firmware_oid = $7, system_name_oid = $8, sn_oid = $9, save_config_oid = $10, port_desc_oid = $11,
 		                    vlan_oid = $12, port_untagged_oid = $13, speed_oid = $14, battery_status_oid = $15, battery_charge_oid = $16,
-		                    port_mode_oid = $17, uptime_oid = $18, mac_oid = $19
+		                    port_mode_oid = $17, uptime_oid = $18, mac_oid = $19, target_firmware = $20,
+		                    lldp_chassis_id_oid = $21, lldp_sys_name_oid = $22, lldp_port_id_oid = $23
 		WHERE id = $1
     `)
 	if err != nil {
@@ -627,5 +918,1288 @@ func (d *DefaultDatabase) PrepareQuery() []error {
 		errorsList = append(errorsList, err)
 	}
 
+	d.query["GET_ENTITY_SNAPSHOT"], err = d.db.Prepare(`
+		SELECT CASE $1::varchar
+			WHEN 'NODE' THEN (SELECT to_jsonb(t) FROM "Node" AS t WHERE t.id = $2)
+			WHEN 'HARDWARE' THEN (SELECT to_jsonb(t) FROM "Hardware" AS t WHERE t.id = $2)
+			WHEN 'SWITCH' THEN (SELECT to_jsonb(t) FROM "Switch" AS t WHERE t.id = $2)
+			WHEN 'OWNERS' THEN (SELECT to_jsonb(t) FROM "Node_owner" AS t WHERE t.id = $2)
+			WHEN 'NODE_TYPES' THEN (SELECT to_jsonb(t) FROM "Node_type" AS t WHERE t.id = $2)
+			WHEN 'HARDWARE_TYPES' THEN (SELECT to_jsonb(t) FROM "Hardware_type" AS t WHERE t.id = $2)
+			WHEN 'OPERATION_MODES' THEN (SELECT to_jsonb(t) FROM "Operation_mode" AS t WHERE t.id = $2)
+			WHEN 'ROOF_TYPES' THEN (SELECT to_jsonb(t) FROM "Roof_type" AS t WHERE t.id = $2)
+			WHEN 'WIRING_TYPES' THEN (SELECT to_jsonb(t) FROM "Wiring_type" AS t WHERE t.id = $2)
+			WHEN 'REPORT_DATA' THEN (SELECT to_jsonb(t) FROM "Report_data" AS t WHERE t.id = $2)
+			WHEN 'VLAN' THEN (SELECT to_jsonb(t) FROM "Vlan" AS t WHERE t.id = $2)
+			WHEN 'SUBNET' THEN (SELECT to_jsonb(t) FROM "Subnet" AS t WHERE t.id = $2)
+		END
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["LOCK_ENTITY_HISTORY"], err = d.db.Prepare(`
+		SELECT pg_advisory_xact_lock(hashtext($1::varchar), $2::integer)
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_HISTORY"], err = d.db.Prepare(`
+		INSERT INTO "Entity_history" (entity, entity_id, version, action, before, after, user_id, created_at)
+		SELECT $1::varchar, $2::integer, COALESCE(MAX(h.version), 0) + 1, $3::varchar, $4::jsonb, $5::jsonb, $6::integer,
+		       $7::bigint
+		FROM "Entity_history" AS h
+		WHERE h.entity = $1 AND h.entity_id = $2
+		RETURNING id, version
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_HISTORY"], err = d.db.Prepare(`
+		SELECT id, entity, entity_id, version, action, before, after, user_id, created_at, COUNT(*) OVER()
+		FROM "Entity_history"
+		WHERE entity = $1 AND entity_id = $2
+		ORDER BY version DESC
+		OFFSET $3
+		LIMIT 20
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_HISTORY_VERSION"], err = d.db.Prepare(`
+		SELECT id, entity, entity_id, version, action, before, after, user_id, created_at
+		FROM "Entity_history"
+		WHERE entity = $1 AND entity_id = $2 AND version = $3
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_NODE"], err = d.db.Prepare(`
+		UPDATE "Node" AS t
+		SET parent_id = r.parent_id, house_id = r.house_id, type_id = r.type_id, owner_id = r.owner_id, name = r.name,
+		    zone = r.zone, placement = r.placement, supply = r.supply, access = r.access, description = r.description,
+		    is_delete = r.is_delete, is_passive = r.is_passive, deleted_at = r.deleted_at, deleted_by = r.deleted_by,
+		    latitude = r.latitude, longitude = r.longitude, updated_at = $3
+		FROM jsonb_populate_record(NULL::"Node", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_HARDWARE"], err = d.db.Prepare(`
+		UPDATE "Hardware" AS t
+		SET node_id = r.node_id, type_id = r.type_id, switch_id = r.switch_id, ip_address = r.ip_address,
+		    mgmt_vlan = r.mgmt_vlan, description = r.description, is_delete = r.is_delete, deleted_at = r.deleted_at,
+		    deleted_by = r.deleted_by, serial_number = r.serial_number, inventory_number = r.inventory_number,
+		    purchase_date = r.purchase_date, warranty_end = r.warranty_end, status = COALESCE(r.status, t.status),
+		    updated_at = $3
+		FROM jsonb_populate_record(NULL::"Hardware", $2::jsonb) AS r
+		WHERE t.id = $1
+		RETURNING t.status
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_SWITCH"], err = d.db.Prepare(`
+		UPDATE "Switch" AS t
+		SET name = r.name, operation_mode_id = r.operation_mode_id, community_read = r.community_read,
+		    community_write = r.community_write, port_amount = r.port_amount, firmware_oid = r.firmware_oid,
+		    system_name_oid = r.system_name_oid, sn_oid = r.sn_oid, save_config_oid = r.save_config_oid,
+		    port_desc_oid = r.port_desc_oid, vlan_oid = r.vlan_oid, port_untagged_oid = r.port_untagged_oid,
+		    speed_oid = r.speed_oid, battery_status_oid = r.battery_status_oid,
+		    battery_charge_oid = r.battery_charge_oid, port_mode_oid = r.port_mode_oid, uptime_oid = r.uptime_oid,
+		    mac_oid = r.mac_oid, target_firmware = r.target_firmware, lldp_chassis_id_oid = r.lldp_chassis_id_oid,
+		    lldp_sys_name_oid = r.lldp_sys_name_oid, lldp_port_id_oid = r.lldp_port_id_oid
+		FROM jsonb_populate_record(NULL::"Switch", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_OWNERS"], err = d.db.Prepare(`
+		UPDATE "Node_owner" AS t SET value = r.value
+		FROM jsonb_populate_record(NULL::"Node_owner", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_NODE_TYPES"], err = d.db.Prepare(`
+		UPDATE "Node_type" AS t SET key = r.key, value = r.value
+		FROM jsonb_populate_record(NULL::"Node_type", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_HARDWARE_TYPES"], err = d.db.Prepare(`
+		UPDATE "Hardware_type" AS t SET key = r.key, value = r.value
+		FROM jsonb_populate_record(NULL::"Hardware_type", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_OPERATION_MODES"], err = d.db.Prepare(`
+		UPDATE "Operation_mode" AS t SET key = r.key, value = r.value
+		FROM jsonb_populate_record(NULL::"Operation_mode", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_ROOF_TYPES"], err = d.db.Prepare(`
+		UPDATE "Roof_type" AS t SET value = r.value
+		FROM jsonb_populate_record(NULL::"Roof_type", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_WIRING_TYPES"], err = d.db.Prepare(`
+		UPDATE "Wiring_type" AS t SET value = r.value
+		FROM jsonb_populate_record(NULL::"Wiring_type", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_REPORT_DATA"], err = d.db.Prepare(`
+		UPDATE "Report_data" AS t SET key = r.key, value = r.value, description = r.description
+		FROM jsonb_populate_record(NULL::"Report_data", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_SNMP_TARGETS"], err = d.db.Prepare(`
+		SELECT hd.id, hd.node_id, n.house_id, hd.ip_address, sw.id, sw.name, sw.community_read, sw.firmware_oid,
+		       sw.system_name_oid, sw.sn_oid, sw.uptime_oid, sw.battery_status_oid, sw.battery_charge_oid,
+		       sw.port_desc_oid, sw.vlan_oid, sw.port_untagged_oid, sw.speed_oid, sw.port_mode_oid, sw.mac_oid,
+		       sw.port_amount, sw.save_config_oid, sw.community_write, sw.lldp_chassis_id_oid, sw.lldp_sys_name_oid,
+		       sw.lldp_port_id_oid
+		FROM "Hardware" AS hd
+		JOIN "Node" AS n ON hd.node_id = n.id
+		JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		WHERE hd.is_delete = false
+			AND hd.ip_address IS NOT NULL AND hd.ip_address <> ''
+			AND ($1 = 0 OR hd.id = $1)
+		ORDER BY hd.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["UPSERT_SNMP_POLL"], err = d.db.Prepare(`
+		INSERT INTO "Snmp_poll" (hardware_id, polled_at, success_at, error)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (hardware_id) DO UPDATE
+		SET polled_at = EXCLUDED.polled_at, success_at = COALESCE(EXCLUDED.success_at, "Snmp_poll".success_at),
+		    error = EXCLUDED.error
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_SNMP_READINGS"], err = d.db.Prepare(`
+		DELETE FROM "Snmp_reading" WHERE hardware_id = $1 AND metric = $2
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_SNMP_READING"], err = d.db.Prepare(`
+		INSERT INTO "Snmp_reading" (hardware_id, metric, oid, value, polled_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (hardware_id, metric, oid) DO UPDATE SET value = EXCLUDED.value, polled_at = EXCLUDED.polled_at
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_SNMP_POLL"], err = d.db.Prepare(`
+		SELECT hardware_id, polled_at, success_at, error FROM "Snmp_poll" WHERE hardware_id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_SNMP_READINGS"], err = d.db.Prepare(`
+		SELECT hardware_id, metric, oid, value, polled_at
+		FROM "Snmp_reading"
+		WHERE hardware_id = $1 AND ($2 = '' OR metric = $2)
+		ORDER BY metric, string_to_array(oid, '.')::integer[]
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_HARDWARE_PORTS"], err = d.db.Prepare(`
+		SELECT id, hardware_id, number, description, speed, mode, untagged_vlan, link_state, source, created_at, updated_at
+		FROM "Hardware_port"
+		WHERE hardware_id = $1
+		ORDER BY number
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_PORT"], err = d.db.Prepare(`
+		SELECT id, hardware_id, number, description, speed, mode, untagged_vlan, link_state, source, created_at, updated_at
+		FROM "Hardware_port"
+		WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_PORT"], err = d.db.Prepare(`
+		INSERT INTO "Hardware_port" (hardware_id, number, description, speed, mode, untagged_vlan, link_state, source,
+		                             created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'MANUAL', $8)
+		RETURNING id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["EDIT_PORT"], err = d.db.Prepare(`
+		UPDATE "Hardware_port" SET number = $3, description = $4, speed = $5, mode = $6, untagged_vlan = $7,
+		                           link_state = $8, source = 'MANUAL', updated_at = $9
+		WHERE id = $1 AND hardware_id = $2
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_PORT"], err = d.db.Prepare(`
+		DELETE FROM "Hardware_port" WHERE id = $1 AND hardware_id = $2
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["UPSERT_SNMP_PORT"], err = d.db.Prepare(`
+		INSERT INTO "Hardware_port" (hardware_id, number, description, speed, mode, untagged_vlan, link_state, if_name,
+		                             if_descr, source, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $9, $10, 'SNMP', $8, $8)
+		ON CONFLICT (hardware_id, number) DO UPDATE
+		SET description = CASE
+		        WHEN "Hardware_port".source = 'SNMP' OR COALESCE("Hardware_port".description, '') = ''
+		        THEN COALESCE(EXCLUDED.description, "Hardware_port".description)
+		        ELSE "Hardware_port".description
+		    END,
+		    speed = COALESCE(EXCLUDED.speed, "Hardware_port".speed),
+		    mode = COALESCE(EXCLUDED.mode, "Hardware_port".mode),
+		    untagged_vlan = COALESCE(EXCLUDED.untagged_vlan, "Hardware_port".untagged_vlan),
+		    link_state = COALESCE(EXCLUDED.link_state, "Hardware_port".link_state),
+		    if_name = COALESCE(EXCLUDED.if_name, "Hardware_port".if_name),
+		    if_descr = COALESCE(EXCLUDED.if_descr, "Hardware_port".if_descr),
+		    updated_at = EXCLUDED.updated_at
+		RETURNING id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["SEARCH_PORTS"], err = d.db.Prepare(`
+		SELECT p.id, p.hardware_id, p.number, p.description, p.speed, p.mode, p.untagged_vlan, p.link_state, p.source,
+		       p.created_at, p.updated_at, hdt.value, sw.name, hd.ip_address, n.id, n.name, n.house_id, COUNT(*) OVER()
+		FROM "Hardware_port" AS p
+		JOIN "Hardware" AS hd ON p.hardware_id = hd.id
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		WHERE hd.is_delete = false AND p.description ILIKE '%' || $1 || '%'
+		ORDER BY n.house_id, hd.id, p.number
+		OFFSET $2
+		LIMIT 20
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_VLANS"], err = d.db.Prepare(`
+		SELECT id, vlan_id, name, purpose, zone, created_at, updated_at
+		FROM "Vlan"
+		ORDER BY vlan_id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_VLAN"], err = d.db.Prepare(`
+		INSERT INTO "Vlan" (vlan_id, name, purpose, zone, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["EDIT_VLAN"], err = d.db.Prepare(`
+		UPDATE "Vlan" SET vlan_id = $2, name = $3, purpose = $4, zone = $5, updated_at = $6
+		WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CHECK_VLAN_EXISTS"], err = d.db.Prepare(`
+		SELECT EXISTS (SELECT 1 FROM "Vlan" WHERE vlan_id = $1)
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_VLAN"], err = d.db.Prepare(`
+		UPDATE "Vlan" AS t
+		SET vlan_id = r.vlan_id, name = r.name, purpose = r.purpose, zone = r.zone, updated_at = $3
+		FROM jsonb_populate_record(NULL::"Vlan", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_HARDWARE_PORT_VLANS"], err = d.db.Prepare(`
+		SELECT pv.port_id, pv.vlan_id, pv.tagged, pv.source, pv.updated_at
+		FROM "Port_vlan" AS pv
+		JOIN "Hardware_port" AS p ON pv.port_id = p.id
+		WHERE p.hardware_id = $1
+		ORDER BY pv.port_id, pv.vlan_id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_PORT_VLANS"], err = d.db.Prepare(`
+		DELETE FROM "Port_vlan" WHERE port_id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_SNMP_PORT_VLANS"], err = d.db.Prepare(`
+		DELETE FROM "Port_vlan" WHERE port_id = $1 AND source = 'SNMP'
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_PORT_VLAN"], err = d.db.Prepare(`
+		INSERT INTO "Port_vlan" (port_id, vlan_id, tagged, source, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (port_id, vlan_id) DO NOTHING
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_UNREGISTERED_VLANS"], err = d.db.Prepare(`
+		WITH switch_vlans AS (
+			SELECT p.hardware_id, pv.vlan_id
+			FROM "Port_vlan" AS pv
+			JOIN "Hardware_port" AS p ON pv.port_id = p.id
+			UNION
+			SELECT hardware_id, untagged_vlan FROM "Hardware_port" WHERE untagged_vlan IS NOT NULL
+			UNION
+			SELECT hardware_id, substring(oid FROM '(\d+)$')::integer FROM "Snmp_reading" WHERE metric = 'VLAN'
+			UNION
+			SELECT id, mgmt_vlan::integer FROM "Hardware" WHERE mgmt_vlan ~ '^\d{1,4}$'
+		)
+		SELECT sv.vlan_id, hd.id, hd.ip_address, sw.name, n.id, n.name, n.house_id
+		FROM switch_vlans AS sv
+		JOIN "Hardware" AS hd ON sv.hardware_id = hd.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		WHERE hd.is_delete = false AND NOT EXISTS (SELECT 1 FROM "Vlan" AS v WHERE v.vlan_id = sv.vlan_id)
+		ORDER BY sv.vlan_id, hd.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_UNUSED_VLANS"], err = d.db.Prepare(`
+		WITH switch_vlans AS (
+			SELECT p.hardware_id, pv.vlan_id
+			FROM "Port_vlan" AS pv
+			JOIN "Hardware_port" AS p ON pv.port_id = p.id
+			UNION
+			SELECT hardware_id, untagged_vlan FROM "Hardware_port" WHERE untagged_vlan IS NOT NULL
+			UNION
+			SELECT hardware_id, substring(oid FROM '(\d+)$')::integer FROM "Snmp_reading" WHERE metric = 'VLAN'
+			UNION
+			SELECT id, mgmt_vlan::integer FROM "Hardware" WHERE mgmt_vlan ~ '^\d{1,4}$'
+		)
+		SELECT v.id, v.vlan_id, v.name, v.purpose, v.zone, v.created_at, v.updated_at
+		FROM "Vlan" AS v
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM switch_vlans AS sv
+			JOIN "Hardware" AS hd ON sv.hardware_id = hd.id
+			WHERE hd.is_delete = false AND sv.vlan_id = v.vlan_id
+		)
+		ORDER BY v.vlan_id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_SUBNETS"], err = d.db.Prepare(`
+		SELECT s.id, s.network::text, host(s.gateway), s.zone, s.vlan_id, s.description, s.created_at, s.updated_at
+		FROM "Subnet" AS s
+		ORDER BY s.network
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_SUBNET"], err = d.db.Prepare(`
+		SELECT s.id, s.network::text, host(s.gateway), s.zone, s.vlan_id, s.description, s.created_at, s.updated_at
+		FROM "Subnet" AS s
+		WHERE s.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_SUBNET"], err = d.db.Prepare(`
+		INSERT INTO "Subnet" (network, gateway, zone, vlan_id, description, created_at)
+		VALUES ($1::cidr, $2::inet, $3, $4, $5, $6)
+		RETURNING id, network::text
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["EDIT_SUBNET"], err = d.db.Prepare(`
+		UPDATE "Subnet" SET network = $2::cidr, gateway = $3::inet, zone = $4, vlan_id = $5, description = $6,
+		                    updated_at = $7
+		WHERE id = $1
+		RETURNING network::text
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVERT_SUBNET"], err = d.db.Prepare(`
+		UPDATE "Subnet" AS t
+		SET network = r.network, gateway = r.gateway, zone = r.zone, vlan_id = r.vlan_id,
+		    description = r.description, updated_at = $3
+		FROM jsonb_populate_record(NULL::"Subnet", $2::jsonb) AS r
+		WHERE t.id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_OVERLAPPING_SUBNET"], err = d.db.Prepare(`
+		SELECT s.id, s.network::text, host(s.gateway), s.zone, s.vlan_id, s.description, s.created_at, s.updated_at
+		FROM "Subnet" AS s
+		WHERE s.network && $1::cidr AND s.id <> $2
+		ORDER BY s.network
+		LIMIT 1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["COUNT_SUBNETS"], err = d.db.Prepare(`
+		SELECT COUNT(*) FROM "Subnet"
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_SUBNET_BY_IP"], err = d.db.Prepare(`
+		SELECT s.id, s.network::text, host(s.gateway), s.zone, s.vlan_id, s.description, s.created_at, s.updated_at, n.zone
+		FROM "Subnet" AS s, "Node" AS n
+		WHERE s.network >>= $1::inet AND n.id = $2
+		ORDER BY masklen(s.network) DESC
+		LIMIT 1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_HARDWARE_BY_IP"], err = d.db.Prepare(`
+		SELECT hd.id, hd.ip_address, hdt.value, n.id, n.name, n.house_id
+		FROM "Hardware" AS hd
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		WHERE hd.is_delete = false AND ip_to_inet(hd.ip_address) = $1::inet AND hd.id <> $2
+		ORDER BY hd.id
+		LIMIT 1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_NEXT_FREE_IP"], err = d.db.Prepare(`
+		SELECT host(s.network + g.i)
+		FROM "Subnet" AS s
+		CROSS JOIN LATERAL generate_series(
+			CASE WHEN masklen(s.network) >= 31 THEN 0 ELSE 1 END,
+			LEAST(CASE WHEN masklen(s.network) >= 31 THEN (1::bigint << (32 - masklen(s.network))) - 1
+			           ELSE (1::bigint << (32 - masklen(s.network))) - 2 END, 65535)
+		) AS g(i)
+		WHERE s.id = $1
+		  AND (s.gateway IS NULL OR s.network + g.i <> s.gateway)
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM "Hardware" AS hd
+			WHERE hd.is_delete = false AND ip_to_inet(hd.ip_address) = s.network + g.i
+		  )
+		ORDER BY g.i
+		LIMIT 1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_SUBNET_UTILIZATION"], err = d.db.Prepare(`
+		SELECT s.id, s.network::text, host(s.gateway), s.zone, s.vlan_id, s.description, s.created_at, s.updated_at,
+		       CASE WHEN masklen(s.network) >= 31 THEN 1::bigint << (32 - masklen(s.network))
+		            ELSE (1::bigint << (32 - masklen(s.network))) - 2 END,
+		       COUNT(DISTINCT ip_to_inet(hd.ip_address)),
+		       CASE WHEN s.gateway IS NOT NULL AND NOT bool_or(COALESCE(ip_to_inet(hd.ip_address) = s.gateway, false))
+		            THEN 1 ELSE 0 END
+		FROM "Subnet" AS s
+		LEFT JOIN "Hardware" AS hd ON hd.is_delete = false AND ip_to_inet(hd.ip_address) << s.network
+		GROUP BY s.id
+		ORDER BY s.network
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["UPSERT_MAC_ENTRY"], err = d.db.Prepare(`
+		INSERT INTO "Mac_entry" (mac, hardware_id, port_number, vlan_id, first_seen, last_seen)
+		VALUES ($1::macaddr, $2, $3, $4, $5, $5)
+		ON CONFLICT (mac, hardware_id, port_number, vlan_id) DO UPDATE SET last_seen = EXCLUDED.last_seen
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_MAC_ENTRIES"], err = d.db.Prepare(`
+		SELECT m.id, m.mac::text, m.port_number, m.vlan_id, m.first_seen, m.last_seen, m.last_seen = l.last_seen,
+		       pc.count, hd.id, hd.ip_address, hdt.value, sw.name, n.id, n.name, n.house_id, p.id, p.description,
+		       p.link_state
+		FROM "Mac_entry" AS m
+		JOIN "Hardware" AS hd ON m.hardware_id = hd.id
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		LEFT JOIN "Hardware_port" AS p ON p.hardware_id = m.hardware_id AND p.number = m.port_number
+		CROSS JOIN LATERAL (
+			SELECT MAX(e.last_seen) AS last_seen FROM "Mac_entry" AS e WHERE e.hardware_id = m.hardware_id
+		) AS l
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS count
+			FROM "Mac_entry" AS e
+			WHERE e.hardware_id = m.hardware_id AND e.port_number = m.port_number AND e.last_seen = l.last_seen
+		) AS pc
+		WHERE m.mac = $1::macaddr AND hd.is_delete = false
+		ORDER BY m.last_seen = l.last_seen DESC, pc.count, m.last_seen DESC
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_HARDWARE_CONFIGS"], err = d.db.Prepare(`
+		SELECT id, hardware_id, version, file_path, hash, size, user_id, created_at
+		FROM "Hardware_config"
+		WHERE hardware_id = $1
+		ORDER BY version DESC
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_HARDWARE_CONFIG"], err = d.db.Prepare(`
+		SELECT id, hardware_id, version, file_path, hash, size, user_id, created_at
+		FROM "Hardware_config"
+		WHERE hardware_id = $1 AND ($2 = 0 OR version = $2)
+		ORDER BY version DESC
+		LIMIT 1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["LOCK_HARDWARE"], err = d.db.Prepare(`
+		SELECT id FROM "Hardware" WHERE id = $1 FOR UPDATE
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_HARDWARE_CONFIG"], err = d.db.Prepare(`
+		INSERT INTO "Hardware_config" (hardware_id, version, file_path, hash, size, user_id, created_at)
+		SELECT $1::integer, COALESCE(MAX(c.version), 0) + 1, $2::varchar, $3::char(64), $4::integer, $5::integer,
+		       $6::bigint
+		FROM "Hardware_config" AS c
+		WHERE c.hardware_id = $1
+		RETURNING id, version
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_BATTERY_READING"], err = d.db.Prepare(`
+		INSERT INTO "Battery_reading" (hardware_id, status, charge, polled_at)
+		VALUES ($1, $2, $3, $4)
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_BATTERY_READINGS"], err = d.db.Prepare(`
+		SELECT id, hardware_id, status, charge, polled_at
+		FROM "Battery_reading"
+		WHERE hardware_id = $1 AND polled_at BETWEEN $2 AND $3
+		ORDER BY polled_at
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_POWER_ALERTS"], err = d.db.Prepare(`
+		SELECT hd.id, hd.ip_address, hdt.value, sw.name, n.id, n.name, n.house_id, n.zone, b.status, b.charge,
+		       pb.charge, b.polled_at
+		FROM "Hardware" AS hd
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		CROSS JOIN LATERAL (
+			SELECT r.status, r.charge, r.polled_at
+			FROM "Battery_reading" AS r
+			WHERE r.hardware_id = hd.id
+			ORDER BY r.polled_at DESC
+			LIMIT 1
+		) AS b
+		LEFT JOIN LATERAL (
+			SELECT r.charge
+			FROM "Battery_reading" AS r
+			WHERE r.hardware_id = hd.id AND r.polled_at < b.polled_at
+			ORDER BY r.polled_at DESC
+			LIMIT 1
+		) AS pb ON true
+		WHERE hd.is_delete = false AND n.is_delete = false
+			AND (sw.battery_status_oid IS NOT NULL OR sw.battery_charge_oid IS NOT NULL)
+			AND (b.status IN (3, 4) OR b.charge < $1 OR b.charge < pb.charge)
+		ORDER BY b.charge NULLS FIRST, n.zone, n.name
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["SET_HARDWARE_FIRMWARE"], err = d.db.Prepare(`
+		UPDATE "Hardware" SET firmware = $2, firmware_polled_at = $3 WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_FIRMWARE_COMPLIANCE"], err = d.db.Prepare(`
+		SELECT hd.id, hd.ip_address, hd.firmware, hd.firmware_polled_at, hdt.value, sw.id, sw.name, sw.target_firmware,
+		       n.id, n.name, n.house_id, n.zone, no.id, no.value
+		FROM "Hardware" AS hd
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		JOIN "Node_owner" AS no ON n.owner_id = no.id
+		WHERE hd.is_delete = false AND n.is_delete = false
+			AND sw.target_firmware IS NOT NULL AND sw.target_firmware <> ''
+		ORDER BY sw.name, n.zone NULLS LAST, no.value, n.name, hd.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_AVAILABILITY_TARGETS"], err = d.db.Prepare(`
+		SELECT hd.id, hd.ip_address, sw.id, sw.community_read, sw.uptime_oid
+		FROM "Hardware" AS hd
+		JOIN "Node" AS n ON hd.node_id = n.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		WHERE hd.is_delete = false AND n.is_delete = false
+			AND hd.ip_address IS NOT NULL AND hd.ip_address <> ''
+		ORDER BY hd.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_AVAILABILITY_STATE"], err = d.db.Prepare(`
+		SELECT hardware_id, is_up, method, rtt, uptime, error, first_checked_at, checked_at, changed_at, failures,
+		       failed_at
+		FROM "Hardware_availability"
+		WHERE hardware_id = $1
+		FOR UPDATE
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["UPSERT_AVAILABILITY_STATE"], err = d.db.Prepare(`
+		INSERT INTO "Hardware_availability" (hardware_id, is_up, method, rtt, uptime, error, first_checked_at,
+		                                     checked_at, changed_at, failures, failed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (hardware_id) DO UPDATE
+		SET is_up = EXCLUDED.is_up, method = EXCLUDED.method, rtt = EXCLUDED.rtt, uptime = EXCLUDED.uptime,
+		    error = EXCLUDED.error, checked_at = EXCLUDED.checked_at, changed_at = EXCLUDED.changed_at,
+		    failures = EXCLUDED.failures, failed_at = EXCLUDED.failed_at
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CLOSE_UNMONITORED_OUTAGES"], err = d.db.Prepare(`
+		UPDATE "Hardware_outage" AS o
+		SET ended_at = GREATEST(o.started_at, CASE WHEN hd.is_delete THEN COALESCE(hd.deleted_at, $1) ELSE $1 END)
+		FROM "Hardware" AS hd
+		JOIN "Node" AS n ON hd.node_id = n.id
+		WHERE o.hardware_id = hd.id AND o.ended_at IS NULL
+			AND (hd.is_delete = true OR n.is_delete = true OR hd.ip_address IS NULL OR hd.ip_address = '')
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_UNMONITORED_AVAILABILITY"], err = d.db.Prepare(`
+		DELETE FROM "Hardware_availability" AS a
+		USING "Hardware" AS hd
+		JOIN "Node" AS n ON hd.node_id = n.id
+		WHERE a.hardware_id = hd.id
+			AND (hd.is_delete = true OR n.is_delete = true OR hd.ip_address IS NULL OR hd.ip_address = '')
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_OUTAGE"], err = d.db.Prepare(`
+		INSERT INTO "Hardware_outage" (hardware_id, cause, started_at, ended_at)
+		VALUES ($1, $2, $3, $4)
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CLOSE_OUTAGES"], err = d.db.Prepare(`
+		UPDATE "Hardware_outage" SET ended_at = $2 WHERE hardware_id = $1 AND ended_at IS NULL
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_CURRENT_OUTAGES"], err = d.db.Prepare(`
+		SELECT o.id, o.cause, o.started_at, o.ended_at, hd.id, hd.ip_address, hdt.value, sw.name, n.id, n.name,
+		       n.house_id, n.zone
+		FROM "Hardware_outage" AS o
+		JOIN "Hardware" AS hd ON o.hardware_id = hd.id
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		WHERE o.ended_at IS NULL AND hd.is_delete = false AND n.is_delete = false
+		ORDER BY o.started_at
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_HARDWARE_OUTAGES"], err = d.db.Prepare(`
+		SELECT o.id, o.cause, o.started_at, o.ended_at
+		FROM "Hardware_outage" AS o
+		WHERE o.hardware_id = $1 AND o.started_at <= $3 AND (o.ended_at IS NULL OR o.ended_at >= $2)
+		ORDER BY o.started_at DESC
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_AVAILABILITY"], err = d.db.Prepare(`
+		SELECT hd.id, hd.ip_address, hdt.value, sw.name, n.id, n.name, n.house_id, n.zone,
+		       GREATEST($2 - GREATEST($1, a.first_checked_at), 0), d.downtime, d.outages
+		FROM "Hardware_availability" AS a
+		JOIN "Hardware" AS hd ON a.hardware_id = hd.id
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(SUM(GREATEST(
+			           LEAST(COALESCE(o.ended_at, $2), $2) - GREATEST(o.started_at, $1, a.first_checked_at), 0
+			       )), 0) AS downtime,
+			       COUNT(o.id) AS outages
+			FROM "Hardware_outage" AS o
+			WHERE o.hardware_id = hd.id AND o.started_at <= $2 AND (o.ended_at IS NULL OR o.ended_at >= $1)
+		) AS d
+		WHERE hd.is_delete = false AND n.is_delete = false
+			AND ($3 = 0 OR n.id = $3) AND ($4 = 0 OR n.house_id = $4)
+		ORDER BY n.house_id, n.id, hd.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_HARDWARE_STATUS"], err = d.db.Prepare(`
+		INSERT INTO "Hardware_status_history" (hardware_id, status, user_id, created_at)
+		SELECT $1, $2::varchar, $3, $4
+		WHERE $2::varchar IS DISTINCT FROM (
+			SELECT h.status FROM "Hardware_status_history" AS h
+			WHERE h.hardware_id = $1
+			ORDER BY h.created_at DESC, h.id DESC
+			LIMIT 1
+		)
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_HARDWARE_STATUS_HISTORY"], err = d.db.Prepare(`
+		SELECT id, hardware_id, status, user_id, created_at
+		FROM "Hardware_status_history"
+		WHERE hardware_id = $1
+		ORDER BY created_at DESC, id DESC
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["SET_HARDWARE_SERIAL"], err = d.db.Prepare(`
+		UPDATE "Hardware" SET serial_number = $2, updated_at = $3
+		WHERE id = $1 AND (serial_number IS NULL OR serial_number = '')
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_WARRANTY_EXPIRING"], err = d.db.Prepare(`
+		SELECT hd.id, hd.ip_address, hd.serial_number, hd.inventory_number, hd.purchase_date, hd.warranty_end,
+		       hd.status, hdt.value, sw.name, n.id, n.name, n.house_id, n.zone
+		FROM "Hardware" AS hd
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		WHERE hd.is_delete = false AND hd.status <> 'WRITTEN_OFF'
+			AND hd.warranty_end IS NOT NULL AND hd.warranty_end <= $1
+			AND ($2 = 0 OR hd.warranty_end >= $2)
+		ORDER BY hd.warranty_end, hd.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_CABLES"], err = d.db.Prepare(`
+		SELECT c.id, c.medium, c.fiber_count, c.length, c.label, c.description, c.created_at, c.updated_at,
+		       an.id, an.name, an.house_id, an.is_passive, ant.key, ant.value, ah.id, aht.value, ap.id, ap.number, ap.description,
+		       bn.id, bn.name, bn.house_id, bn.is_passive, bnt.key, bnt.value, bh.id, bht.value, bp.id, bp.number, bp.description
+		FROM "Cable" AS c
+		LEFT JOIN "Hardware_port" AS ap ON c.a_port_id = ap.id
+		LEFT JOIN "Hardware" AS ah ON ap.hardware_id = ah.id
+		LEFT JOIN "Hardware_type" AS aht ON ah.type_id = aht.id
+		JOIN "Node" AS an ON an.id = COALESCE(c.a_node_id, ah.node_id)
+		LEFT JOIN "Node_type" AS ant ON an.type_id = ant.id
+		LEFT JOIN "Hardware_port" AS bp ON c.b_port_id = bp.id
+		LEFT JOIN "Hardware" AS bh ON bp.hardware_id = bh.id
+		LEFT JOIN "Hardware_type" AS bht ON bh.type_id = bht.id
+		JOIN "Node" AS bn ON bn.id = COALESCE(c.b_node_id, bh.node_id)
+		LEFT JOIN "Node_type" AS bnt ON bn.type_id = bnt.id
+		WHERE an.is_delete = false AND bn.is_delete = false
+			AND (ah.id IS NULL OR ah.is_delete = false) AND (bh.id IS NULL OR bh.is_delete = false)
+			AND ($1 = 0 OR an.id = $1 OR bn.id = $1)
+			AND ($2 = 0 OR ah.id = $2 OR bh.id = $2)
+			AND ($3 = 0 OR c.id = $3)
+		ORDER BY c.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_CABLE"], err = d.db.Prepare(`
+		INSERT INTO "Cable" (a_node_id, a_port_id, b_node_id, b_port_id, medium, length, label, description, created_at, fiber_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["EDIT_CABLE"], err = d.db.Prepare(`
+		UPDATE "Cable" SET a_node_id = $2, a_port_id = $3, b_node_id = $4, b_port_id = $5, medium = $6, length = $7,
+		                   label = $8, description = $9, updated_at = $10, fiber_count = $11
+		WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_CABLE"], err = d.db.Prepare(`
+		DELETE FROM "Cable" WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CHECK_PORT_CABLE"], err = d.db.Prepare(`
+		SELECT EXISTS(SELECT 1 FROM "Cable" WHERE (a_port_id = $1 OR b_port_id = $1) AND id <> $2)
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_FIBER_SPLICES"], err = d.db.Prepare(`
+		SELECT s.id, n.id, n.name, n.house_id, n.is_passive,
+		       ac.id, ac.medium, ac.fiber_count, ac.label, s.a_fiber,
+		       bc.id, bc.medium, bc.fiber_count, bc.label, s.b_fiber,
+		       s.loss, s.description, s.created_at, s.updated_at
+		FROM "Fiber_splice" AS s
+		JOIN "Node" AS n ON s.node_id = n.id
+		JOIN "Cable" AS ac ON s.a_cable_id = ac.id
+		JOIN "Cable" AS bc ON s.b_cable_id = bc.id
+		WHERE ($1 = 0 OR s.node_id = $1)
+			AND ($2 = 0 OR s.a_cable_id = $2 OR s.b_cable_id = $2)
+			AND ($3 = 0 OR s.id = $3)
+		ORDER BY s.node_id, s.a_cable_id, s.a_fiber
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_FIBER_SPLICE"], err = d.db.Prepare(`
+		INSERT INTO "Fiber_splice" (node_id, a_cable_id, a_fiber, b_cable_id, b_fiber, loss, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["EDIT_FIBER_SPLICE"], err = d.db.Prepare(`
+		UPDATE "Fiber_splice" SET node_id = $2, a_cable_id = $3, a_fiber = $4, b_cable_id = $5, b_fiber = $6,
+		                          loss = $7, description = $8, updated_at = $9
+		WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_FIBER_SPLICE"], err = d.db.Prepare(`
+		DELETE FROM "Fiber_splice" WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CHECK_FIBER_SPLICE"], err = d.db.Prepare(`
+		SELECT EXISTS (
+			SELECT 1 FROM "Fiber_splice"
+			WHERE node_id = $1 AND id <> $4
+				AND ((a_cable_id = $2 AND a_fiber = $3) OR (b_cable_id = $2 AND b_fiber = $3))
+		)
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_FIBER_ATTENUATION"], err = d.db.Prepare(`
+		SELECT id, medium, wavelength, per_km, per_splice, per_connector, created_at, updated_at
+		FROM "Fiber_attenuation"
+		ORDER BY medium, wavelength
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["EDIT_FIBER_ATTENUATION"], err = d.db.Prepare(`
+		UPDATE "Fiber_attenuation" SET per_km = $2, per_splice = $3, per_connector = $4, updated_at = $5
+		WHERE id = $1
+		RETURNING medium, wavelength, created_at
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_RACKS"], err = d.db.Prepare(`
+		SELECT r.id, n.id, n.name, n.house_id, r.name, r.kind, r.units, r.description, r.created_at, r.updated_at
+		FROM "Rack" AS r
+		JOIN "Node" AS n ON r.node_id = n.id
+		WHERE ($1 = 0 OR r.node_id = $1) AND ($2 = 0 OR r.id = $2)
+		ORDER BY r.node_id, r.name, r.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_RACK_PLACEMENTS"], err = d.db.Prepare(`
+		SELECT p.hardware_id, hd.node_id, hdt.key, hdt.value, sw.name, hd.ip_address, hd.description,
+		       p.rack_id, p.unit, p.height, p.side, p.created_at, p.updated_at
+		FROM "Hardware_placement" AS p
+		JOIN "Rack" AS r ON p.rack_id = r.id
+		JOIN "Hardware" AS hd ON p.hardware_id = hd.id
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		WHERE hd.is_delete = false AND ($1 = 0 OR r.node_id = $1) AND ($2 = 0 OR p.rack_id = $2)
+		ORDER BY p.rack_id, p.unit DESC, p.side
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_RACK"], err = d.db.Prepare(`
+		INSERT INTO "Rack" (node_id, name, kind, units, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["EDIT_RACK"], err = d.db.Prepare(`
+		UPDATE "Rack" SET name = $2, kind = $3, units = $4, description = $5, updated_at = $6
+		WHERE id = $1
+		RETURNING node_id, created_at
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_RACK"], err = d.db.Prepare(`
+		DELETE FROM "Rack" WHERE id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["LOCK_RACK"], err = d.db.Prepare(`
+		SELECT node_id, units FROM "Rack" WHERE id = $1 FOR UPDATE
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["SET_HARDWARE_PLACEMENT"], err = d.db.Prepare(`
+		INSERT INTO "Hardware_placement" (hardware_id, rack_id, unit, height, side, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (hardware_id) DO UPDATE
+		SET rack_id = EXCLUDED.rack_id, unit = EXCLUDED.unit, height = EXCLUDED.height, side = EXCLUDED.side,
+		    updated_at = EXCLUDED.created_at
+		RETURNING created_at, updated_at
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_HARDWARE_PLACEMENT"], err = d.db.Prepare(`
+		DELETE FROM "Hardware_placement" WHERE hardware_id = $1
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_MOVED_HARDWARE_PLACEMENT"], err = d.db.Prepare(`
+		DELETE FROM "Hardware_placement" AS p
+		USING "Rack" AS r
+		WHERE p.rack_id = r.id AND p.hardware_id = $1 AND r.node_id <> $2
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_PORT_CAPACITY"], err = d.db.Prepare(`
+		SELECT hd.id, hd.ip_address, hdt.value, sw.name, n.id, n.name, n.house_id, n.zone, sw.port_amount, COALESCE(u.used, 0),
+		       COALESCE(sp.success_at = sp.polled_at, false)
+		FROM "Hardware" AS hd
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		LEFT JOIN "Snmp_poll" AS sp ON sp.hardware_id = hd.id
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS used
+			FROM "Hardware_port" AS p
+			WHERE p.hardware_id = hd.id AND p.number BETWEEN 1 AND sw.port_amount
+				AND (p.link_state = 'up' OR COALESCE(p.description, '') <> ''
+					OR EXISTS (SELECT 1 FROM "Cable" AS c WHERE c.a_port_id = p.id OR c.b_port_id = p.id))
+		) AS u ON true
+		WHERE hd.is_delete = false AND n.is_delete = false AND hd.status = 'INSTALLED' AND sw.port_amount > 0
+			AND ($1 = 0 OR n.id = $1) AND ($2 = 0 OR n.house_id = $2) AND ($3 = '' OR n.zone = $3)
+		ORDER BY n.zone, n.house_id, n.id, hd.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["UPSERT_LLDP_NEIGHBOR"], err = d.db.Prepare(`
+		INSERT INTO "Lldp_neighbor" (hardware_id, local_port, chassis_id, system_name, port_id, first_seen, last_seen,
+		                             local_if_index)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $7)
+		ON CONFLICT (hardware_id, local_port, chassis_id, system_name) DO UPDATE 
+		SET port_id = EXCLUDED.port_id, local_if_index = EXCLUDED.local_if_index, last_seen = EXCLUDED.last_seen
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_STALE_LLDP_NEIGHBORS"], err = d.db.Prepare(`
+		DELETE FROM "Lldp_neighbor" WHERE hardware_id = $1 AND last_seen < $2
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_LLDP_NEIGHBORS"], err = d.db.Prepare(`
+		SELECT ln.id, ln.local_port, ln.local_if_index, ln.chassis_id, ln.system_name, ln.port_id, ln.first_seen,
+		       ln.last_seen, hd.id, hd.node_id
+		FROM "Lldp_neighbor" AS ln
+		JOIN "Hardware" AS hd ON ln.hardware_id = hd.id
+		WHERE hd.is_delete = false AND ($1 = 0 OR ln.hardware_id = $1)
+		ORDER BY hd.id, ln.local_port, ln.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_HARDWARE_IDENTITIES"], err = d.db.Prepare(`
+		SELECT hd.id, hd.ip_address, hdt.value, sw.name, n.id, n.name, n.house_id, sn.value, lc.value
+		FROM "Hardware" AS hd
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Node" AS n ON hd.node_id = n.id
+		LEFT JOIN "Switch" AS sw ON hd.switch_id = sw.id
+		LEFT JOIN LATERAL (
+			SELECT r.value FROM "Snmp_reading" AS r WHERE r.hardware_id = hd.id AND r.metric = 'SYSTEM_NAME' LIMIT 1
+		) AS sn ON true
+		LEFT JOIN LATERAL (
+			SELECT r.value FROM "Snmp_reading" AS r WHERE r.hardware_id = hd.id AND r.metric = 'LLDP_LOCAL_CHASSIS_ID' LIMIT 1
+		) AS lc ON true
+		WHERE hd.is_delete = false
+		ORDER BY hd.id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_DISCOVERY_NODES"], err = d.db.Prepare(`
+		SELECT n.id, n.parent_id, n.is_passive, nt.key
+		FROM "Node" AS n
+		LEFT JOIN "Node_type" AS nt ON n.type_id = nt.id
+		WHERE n.is_delete = false
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_DISCOVERY_PORTS"], err = d.db.Prepare(`
+		SELECT p.id, p.hardware_id, p.number, p.if_name, p.if_descr,
+		       EXISTS(SELECT 1 FROM "Cable" AS c WHERE c.a_port_id = p.id OR c.b_port_id = p.id)
+		FROM "Hardware_port" AS p
+		JOIN "Hardware" AS hd ON p.hardware_id = hd.id
+		WHERE hd.is_delete = false
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_OPEN_TOPOLOGY_PROPOSALS"], err = d.db.Prepare(`
+		SELECT id, kind, status, node_id, current_parent_id, proposed_parent_id, port_id, remote_port_id
+		FROM "Topology_proposal"
+		WHERE status IN ('PENDING', 'REJECTED')
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["CREATE_TOPOLOGY_PROPOSAL"], err = d.db.Prepare(`
+		INSERT INTO "Topology_proposal" (kind, hardware_id, local_port, remote_hardware_id, remote_port, node_id,
+		                                 current_parent_id, proposed_parent_id, port_id, remote_port_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT DO NOTHING
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["DELETE_TOPOLOGY_PROPOSAL"], err = d.db.Prepare(`
+		DELETE FROM "Topology_proposal" WHERE id = $1 AND status = 'PENDING'
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_TOPOLOGY_PROPOSALS"], err = d.db.Prepare(`
+		SELECT tp.id, tp.kind, tp.status, tp.local_port, tp.remote_port, tp.created_at, tp.reviewed_at, tp.reviewed_by,
+		       hd.id, hd.ip_address, hdt.value, hn.id, hn.name, hn.house_id,
+		       rhd.id, rhd.ip_address, rhdt.value, rn.id, rn.name, rn.house_id,
+		       n.id, n.name, n.house_id, cp.id, cp.name, cp.house_id, pp.id, pp.name, pp.house_id,
+		       p.id, p.number, p.description, rp.id, rp.number, rp.description
+		FROM "Topology_proposal" AS tp
+		JOIN "Hardware" AS hd ON tp.hardware_id = hd.id
+		JOIN "Hardware_type" AS hdt ON hd.type_id = hdt.id
+		JOIN "Node" AS hn ON hd.node_id = hn.id
+		JOIN "Hardware" AS rhd ON tp.remote_hardware_id = rhd.id
+		JOIN "Hardware_type" AS rhdt ON rhd.type_id = rhdt.id
+		JOIN "Node" AS rn ON rhd.node_id = rn.id
+		LEFT JOIN "Node" AS n ON tp.node_id = n.id
+		LEFT JOIN "Node" AS cp ON tp.current_parent_id = cp.id
+		LEFT JOIN "Node" AS pp ON tp.proposed_parent_id = pp.id
+		LEFT JOIN "Hardware_port" AS p ON tp.port_id = p.id
+		LEFT JOIN "Hardware_port" AS rp ON tp.remote_port_id = rp.id
+		WHERE ($1 = '' OR tp.status = $1) AND ($2 = 0 OR tp.id = $2)
+		ORDER BY tp.created_at DESC, tp.id DESC
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["REVIEW_TOPOLOGY_PROPOSAL"], err = d.db.Prepare(`
+		UPDATE "Topology_proposal" SET status = $2, reviewed_at = $3, reviewed_by = $4
+		WHERE id = $1 AND status = 'PENDING'
+		RETURNING id
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["GET_NODE_PARENT"], err = d.db.Prepare(`
+		SELECT COALESCE(parent_id, 0) FROM "Node" WHERE id = $1 AND is_delete = false
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
+	d.query["LOCK_NODE_PATH"], err = d.db.Prepare(`
+		WITH RECURSIVE ancestors AS (
+		    SELECT n.id, n.parent_id, ARRAY[n.id] AS path
+		    FROM "Node" AS n
+		    WHERE n.id = $2
+		    UNION ALL
+		    SELECT n.id, n.parent_id, a.path || n.id
+		    FROM "Node" AS n
+		    JOIN ancestors AS a ON n.id = a.parent_id
+		    WHERE NOT n.id = ANY(a.path)
+		)
+		SELECT n.id FROM "Node" AS n
+		WHERE n.id = $1 OR n.id IN (SELECT id FROM ancestors)
+		ORDER BY n.id
+		FOR UPDATE
+    `)
+	if err != nil {
+		errorsList = append(errorsList, err)
+	}
+
 	return errorsList
 }
@@ -21,6 +21,11 @@ func (r *DefaultEventRepository) CreateEvent(event models.Event) error {
 		return errors.New("query CREATE_EVENT is not prepare")
 	}
 
+	return createEvent(stmt, event)
+}
+
+// createEvent Выполняет вставку события через переданный запрос, что позволяет писать события внутри транзакции
+func createEvent(stmt *sql.Stmt, event models.Event) error {
 	var nodeID interface{}
 	var hardwareID interface{}
 
@@ -0,0 +1,434 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+	"math"
+)
+
+type FiberRepository interface {
+	GetFiberSplices(nodeID int, cableID int) ([]models.FiberSplice, error)
+	GetFiberSplice(spliceID int) (*models.FiberSplice, error)
+	CreateFiberSplice(splice *models.FiberSplice) error
+	EditFiberSplice(splice *models.FiberSplice) error
+	DeleteFiberSplice(spliceID int) error
+	FiberHasSplice(nodeID int, cableID int, fiber int, spliceID int) (bool, error)
+	GetFiberAttenuation() ([]models.FiberAttenuation, error)
+	EditFiberAttenuation(attenuation *models.FiberAttenuation) error
+	TraceFiber(cableID int, fiber int) (*models.FiberTrace, error)
+	ValidateFiberSplice(splice models.FiberSplice) bool
+	ValidateFiberAttenuation(attenuation models.FiberAttenuation) bool
+}
+
+type DefaultFiberRepository struct {
+	Database Database
+}
+
+// GetFiberSplices Возвращает сварки в узле nodeID и/или с волокнами кабеля cableID.
+// При нулевых значениях фильтры не применяются
+func (r *DefaultFiberRepository) GetFiberSplices(nodeID int, cableID int) ([]models.FiberSplice, error) {
+	return r.getFiberSplices(nodeID, cableID, 0)
+}
+
+// GetFiberSplice Возвращает сварку или sql.ErrNoRows
+func (r *DefaultFiberRepository) GetFiberSplice(spliceID int) (*models.FiberSplice, error) {
+	splices, err := r.getFiberSplices(0, 0, spliceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(splices) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &splices[0], nil
+}
+
+func (r *DefaultFiberRepository) getFiberSplices(nodeID int, cableID int, spliceID int) ([]models.FiberSplice, error) {
+	stmt, ok := r.Database.GetQuery("GET_FIBER_SPLICES")
+	if !ok {
+		return nil, errors.New("query GET_FIBER_SPLICES is not prepare")
+	}
+
+	rows, err := stmt.Query(nodeID, cableID, spliceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	splices := make([]models.FiberSplice, 0)
+
+	for rows.Next() {
+		var (
+			splice         models.FiberSplice
+			aFiber, bFiber int
+		)
+
+		if err = rows.Scan(
+			&splice.ID,
+			&splice.Node.ID,
+			&splice.Node.Name,
+			&splice.Node.HouseId,
+			&splice.Node.IsPassive,
+			&splice.A.Cable.ID,
+			&splice.A.Cable.Medium,
+			&splice.A.Cable.FiberCount,
+			&splice.A.Cable.Label,
+			&aFiber,
+			&splice.B.Cable.ID,
+			&splice.B.Cable.Medium,
+			&splice.B.Cable.FiberCount,
+			&splice.B.Cable.Label,
+			&bFiber,
+			&splice.Loss,
+			&splice.Description,
+			&splice.CreatedAt,
+			&splice.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		splice.A.Fiber = models.NewFiber(aFiber)
+		splice.B.Fiber = models.NewFiber(bFiber)
+
+		splices = append(splices, splice)
+	}
+
+	return splices, nil
+}
+
+func (r *DefaultFiberRepository) CreateFiberSplice(splice *models.FiberSplice) error {
+	stmt, ok := r.Database.GetQuery("CREATE_FIBER_SPLICE")
+	if !ok {
+		return errors.New("query CREATE_FIBER_SPLICE is not prepare")
+	}
+
+	return stmt.QueryRow(
+		splice.Node.ID,
+		splice.A.Cable.ID,
+		splice.A.Fiber.Number,
+		splice.B.Cable.ID,
+		splice.B.Fiber.Number,
+		splice.Loss,
+		splice.Description,
+		splice.CreatedAt,
+	).Scan(&splice.ID)
+}
+
+func (r *DefaultFiberRepository) EditFiberSplice(splice *models.FiberSplice) error {
+	stmt, ok := r.Database.GetQuery("EDIT_FIBER_SPLICE")
+	if !ok {
+		return errors.New("query EDIT_FIBER_SPLICE is not prepare")
+	}
+
+	res, err := stmt.Exec(
+		splice.ID,
+		splice.Node.ID,
+		splice.A.Cable.ID,
+		splice.A.Fiber.Number,
+		splice.B.Cable.ID,
+		splice.B.Fiber.Number,
+		splice.Loss,
+		splice.Description,
+		splice.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if affected, e := res.RowsAffected(); e == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *DefaultFiberRepository) DeleteFiberSplice(spliceID int) error {
+	stmt, ok := r.Database.GetQuery("DELETE_FIBER_SPLICE")
+	if !ok {
+		return errors.New("query DELETE_FIBER_SPLICE is not prepare")
+	}
+
+	res, err := stmt.Exec(spliceID)
+	if err != nil {
+		return err
+	}
+
+	if affected, e := res.RowsAffected(); e == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// FiberHasSplice Проверяет, сварено ли волокно кабеля в узле в другой сварке, кроме spliceID
+func (r *DefaultFiberRepository) FiberHasSplice(nodeID int, cableID int, fiber int, spliceID int) (bool, error) {
+	stmt, ok := r.Database.GetQuery("CHECK_FIBER_SPLICE")
+	if !ok {
+		return false, errors.New("query CHECK_FIBER_SPLICE is not prepare")
+	}
+
+	var exists bool
+
+	if err := stmt.QueryRow(nodeID, cableID, fiber, spliceID).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r *DefaultFiberRepository) GetFiberAttenuation() ([]models.FiberAttenuation, error) {
+	stmt, ok := r.Database.GetQuery("GET_FIBER_ATTENUATION")
+	if !ok {
+		return nil, errors.New("query GET_FIBER_ATTENUATION is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.FiberAttenuation, 0)
+
+	for rows.Next() {
+		var item models.FiberAttenuation
+
+		if err = rows.Scan(
+			&item.ID,
+			&item.Medium,
+			&item.Wavelength,
+			&item.PerKm,
+			&item.PerSplice,
+			&item.PerConnector,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// EditFiberAttenuation Изменяет значения затухания. Среда и длина волны записи не меняются
+func (r *DefaultFiberRepository) EditFiberAttenuation(attenuation *models.FiberAttenuation) error {
+	stmt, ok := r.Database.GetQuery("EDIT_FIBER_ATTENUATION")
+	if !ok {
+		return errors.New("query EDIT_FIBER_ATTENUATION is not prepare")
+	}
+
+	return stmt.QueryRow(
+		attenuation.ID,
+		attenuation.PerKm,
+		attenuation.PerSplice,
+		attenuation.PerConnector,
+		attenuation.UpdatedAt,
+	).Scan(&attenuation.Medium, &attenuation.Wavelength, &attenuation.CreatedAt)
+}
+
+// fiberKey Волокно кабеля в узле, по нему ищется сварка на конце кабеля
+type fiberKey struct {
+	node  int
+	cable int
+	fiber int
+}
+
+// TraceFiber Проходит волокно через сварки в обе стороны от кабеля cableID до концов: порта оборудования
+// или несваренного волокна. Если кабеля или волокна нет, возвращается sql.ErrNoRows
+func (r *DefaultFiberRepository) TraceFiber(cableID int, fiber int) (*models.FiberTrace, error) {
+	cableRepo := &DefaultCableRepository{Database: r.Database}
+
+	cables, err := cableRepo.GetCables(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	splices, err := r.GetFiberSplices(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildFiberTrace(cables, splices, cableID, fiber)
+}
+
+func buildFiberTrace(cables []models.Cable, splices []models.FiberSplice, cableID int, fiber int) (*models.FiberTrace, error) {
+	cableMap := make(map[int]models.Cable)
+
+	for _, cable := range cables {
+		cableMap[cable.ID] = cable
+	}
+
+	spliceMap := make(map[fiberKey]*models.FiberSplice)
+
+	for i, splice := range splices {
+		spliceMap[fiberKey{splice.Node.ID, splice.A.Cable.ID, splice.A.Fiber.Number}] = &splices[i]
+		spliceMap[fiberKey{splice.Node.ID, splice.B.Cable.ID, splice.B.Fiber.Number}] = &splices[i]
+	}
+
+	start, ok := cableMap[cableID]
+	if !ok || !start.FiberCount.Valid || fiber <= 0 || fiber > int(start.FiberCount.Int32) {
+		return nil, sql.ErrNoRows
+	}
+
+	// walk Идет по волокну от конца from кабеля, пока на следующем конце есть сварка
+	walk := func(cable models.Cable, fiber int, from, to models.CableEndpoint) ([]models.FiberHop, bool) {
+		hops := make([]models.FiberHop, 0)
+		visited := make(map[fiberKey]struct{})
+
+		for {
+			key := fiberKey{to.Node.ID, cable.ID, fiber}
+
+			if _, ok := visited[key]; ok {
+				return hops, true
+			}
+
+			visited[key] = struct{}{}
+
+			hop := models.FiberHop{Cable: cable, Fiber: models.NewFiber(fiber), From: from, To: to}
+
+			if to.Port == nil {
+				hop.Splice = spliceMap[key]
+			}
+
+			hops = append(hops, hop)
+
+			if hop.Splice == nil {
+				return hops, false
+			}
+
+			next := hop.Splice.B
+			if hop.Splice.B.Cable.ID == cable.ID && hop.Splice.B.Fiber.Number == fiber {
+				next = hop.Splice.A
+			}
+
+			nextCable, ok := cableMap[next.Cable.ID]
+			if !ok {
+				return hops, false
+			}
+
+			cable, fiber = nextCable, next.Fiber.Number
+			from, to = nextCable.A, nextCable.B
+
+			if nextCable.A.Node.ID != hop.Splice.Node.ID || nextCable.A.Port != nil {
+				from, to = nextCable.B, nextCable.A
+			}
+		}
+	}
+
+	forward, closed := walk(start, fiber, start.A, start.B)
+	hops := forward
+
+	if !closed {
+		backward, _ := walk(start, fiber, start.B, start.A)
+
+		// Обратный путь разворачивается: сварка участка переходит на конец, обращенный к началу пути
+		hops = make([]models.FiberHop, 0, len(backward)-1+len(forward))
+
+		for i := len(backward) - 1; i > 0; i-- {
+			hops = append(hops, models.FiberHop{
+				Cable:  backward[i].Cable,
+				Fiber:  backward[i].Fiber,
+				From:   backward[i].To,
+				To:     backward[i].From,
+				Splice: backward[i-1].Splice,
+			})
+		}
+
+		hops = append(hops, forward...)
+	}
+
+	trace := &models.FiberTrace{Hops: hops}
+
+	for _, hop := range hops {
+		trace.Length += int(hop.Cable.Length.Int32)
+
+		if hop.Splice != nil {
+			trace.Splices++
+		}
+	}
+
+	if !closed {
+		if hops[0].From.Port != nil {
+			trace.Connectors++
+		}
+
+		if hops[len(hops)-1].To.Port != nil {
+			trace.Connectors++
+		}
+	}
+
+	return trace, nil
+}
+
+// BuildLossBudgets Считает затухание пути волокна по справочнику для среды кабеля на всех длинах волн
+// или только на wavelength, если она указана. Измеренное затухание сварки заменяет справочное
+func BuildLossBudgets(trace models.FiberTrace, attenuation []models.FiberAttenuation, wavelength int) []models.LossBudget {
+	budgets := make([]models.LossBudget, 0)
+
+	if len(trace.Hops) == 0 {
+		return budgets
+	}
+
+	medium := trace.Hops[0].Cable.Medium
+
+	for _, item := range attenuation {
+		if item.Medium != medium || (wavelength != 0 && item.Wavelength != wavelength) {
+			continue
+		}
+
+		budget := models.LossBudget{
+			Wavelength:    item.Wavelength,
+			CableLoss:     float64(trace.Length) / 1000 * item.PerKm,
+			ConnectorLoss: float64(trace.Connectors) * item.PerConnector,
+		}
+
+		for _, hop := range trace.Hops {
+			if hop.Splice == nil {
+				continue
+			}
+
+			if hop.Splice.Loss.Valid {
+				budget.SpliceLoss += hop.Splice.Loss.Float64
+			} else {
+				budget.SpliceLoss += item.PerSplice
+			}
+		}
+
+		budget.CableLoss = roundLoss(budget.CableLoss)
+		budget.SpliceLoss = roundLoss(budget.SpliceLoss)
+		budget.ConnectorLoss = roundLoss(budget.ConnectorLoss)
+		budget.TotalLoss = roundLoss(budget.CableLoss + budget.SpliceLoss + budget.ConnectorLoss)
+
+		budgets = append(budgets, budget)
+	}
+
+	return budgets
+}
+
+func (r *DefaultFiberRepository) ValidateFiberSplice(splice models.FiberSplice) bool {
+	if splice.Node.ID == 0 || splice.A.Cable.ID == 0 || splice.B.Cable.ID == 0 {
+		return false
+	}
+
+	if splice.A.Fiber.Number <= 0 || splice.B.Fiber.Number <= 0 {
+		return false
+	}
+
+	if splice.A.Cable.ID == splice.B.Cable.ID && splice.A.Fiber.Number == splice.B.Fiber.Number {
+		return false
+	}
+
+	return !splice.Loss.Valid || splice.Loss.Float64 >= 0
+}
+
+func (r *DefaultFiberRepository) ValidateFiberAttenuation(attenuation models.FiberAttenuation) bool {
+	return attenuation.ID != 0 && attenuation.PerKm >= 0 && attenuation.PerSplice >= 0 && attenuation.PerConnector >= 0
+}
+
+func roundLoss(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func fiberTestCables() ([]models.Cable, []models.FiberSplice) {
+	nodeEnd := func(nodeID int) models.CableEndpoint {
+		return models.CableEndpoint{Node: models.Node{ID: nodeID}}
+	}
+
+	portEnd := func(nodeID int, portID int) models.CableEndpoint {
+		return models.CableEndpoint{Node: models.Node{ID: nodeID}, Port: &models.HardwarePort{ID: portID}}
+	}
+
+	cable := func(id int, a models.CableEndpoint, b models.CableEndpoint, length int32) models.Cable {
+		return models.Cable{
+			ID:         id,
+			A:          a,
+			B:          b,
+			Medium:     models.CableMediumSMF,
+			FiberCount: sql.NullInt32{Int32: 4, Valid: true},
+			Length:     sql.NullInt32{Int32: length, Valid: true},
+		}
+	}
+
+	splice := func(id int, nodeID int, aCable int, aFiber int, bCable int, bFiber int) models.FiberSplice {
+		return models.FiberSplice{
+			ID:   id,
+			Node: models.Node{ID: nodeID},
+			A:    models.FiberEnd{Cable: models.Cable{ID: aCable}, Fiber: models.NewFiber(aFiber)},
+			B:    models.FiberEnd{Cable: models.Cable{ID: bCable}, Fiber: models.NewFiber(bFiber)},
+		}
+	}
+
+	// Порт узла 1 - узел 2 - узел 3 - порт узла 4, кабель 3 записан от порта к узлу.
+	// Кабели 4 и 5 между узлами 5 и 6 сварены в кольцо
+	cables := []models.Cable{
+		cable(1, portEnd(1, 100), nodeEnd(2), 1000),
+		cable(2, nodeEnd(2), nodeEnd(3), 2500),
+		cable(3, portEnd(4, 400), nodeEnd(3), 500),
+		cable(4, nodeEnd(5), nodeEnd(6), 100),
+		cable(5, nodeEnd(6), nodeEnd(5), 200),
+		{ID: 6, A: nodeEnd(7), B: nodeEnd(8), Medium: models.CableMediumCopper},
+	}
+
+	measured := splice(1, 2, 1, 1, 2, 2)
+	measured.Loss = sql.NullFloat64{Float64: 0.1, Valid: true}
+
+	splices := []models.FiberSplice{
+		measured,
+		splice(2, 3, 2, 2, 3, 1),
+		splice(3, 6, 4, 1, 5, 1),
+		splice(4, 5, 5, 1, 4, 1),
+	}
+
+	return cables, splices
+}
+
+func TestBuildFiberTrace(t *testing.T) {
+	cables, splices := fiberTestCables()
+
+	tests := []struct {
+		name       string
+		cableID    int
+		fiber      int
+		hops       []int // кабель, волокно и сварка в конце каждого участка
+		length     int
+		splices    int
+		connectors int
+		err        error
+	}{
+		{
+			name:       "path through splices from the middle cable",
+			cableID:    2,
+			fiber:      2,
+			hops:       []int{1, 1, 1, 2, 2, 2, 3, 1, 0},
+			length:     4000,
+			splices:    2,
+			connectors: 2,
+		},
+		{
+			name:       "path from the port end",
+			cableID:    1,
+			fiber:      1,
+			hops:       []int{1, 1, 1, 2, 2, 2, 3, 1, 0},
+			length:     4000,
+			splices:    2,
+			connectors: 2,
+		},
+		{
+			name:       "path starts from the a end of the cable",
+			cableID:    3,
+			fiber:      1,
+			hops:       []int{3, 1, 2, 2, 2, 1, 1, 1, 0},
+			length:     4000,
+			splices:    2,
+			connectors: 2,
+		},
+		{
+			name:    "fiber without splices",
+			cableID: 2,
+			fiber:   3,
+			hops:    []int{2, 3, 0},
+			length:  2500,
+		},
+		{
+			name:    "ring of splices",
+			cableID: 4,
+			fiber:   1,
+			hops:    []int{4, 1, 3, 5, 1, 4},
+			length:  300,
+			splices: 2,
+		},
+		{name: "fiber out of range", cableID: 2, fiber: 5, err: sql.ErrNoRows},
+		{name: "zero fiber", cableID: 2, fiber: 0, err: sql.ErrNoRows},
+		{name: "copper cable", cableID: 6, fiber: 1, err: sql.ErrNoRows},
+		{name: "unknown cable", cableID: 99, fiber: 1, err: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace, err := buildFiberTrace(cables, splices, tt.cableID, tt.fiber)
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("buildFiberTrace() error = %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("buildFiberTrace() error = %v", err)
+			}
+
+			hops := make([]int, 0)
+
+			for _, hop := range trace.Hops {
+				spliceID := 0
+				if hop.Splice != nil {
+					spliceID = hop.Splice.ID
+				}
+
+				hops = append(hops, hop.Cable.ID, hop.Fiber.Number, spliceID)
+			}
+
+			if !reflect.DeepEqual(hops, tt.hops) {
+				t.Errorf("buildFiberTrace() hops = %v, want %v", hops, tt.hops)
+			}
+
+			if trace.Length != tt.length || trace.Splices != tt.splices || trace.Connectors != tt.connectors {
+				t.Errorf("buildFiberTrace() length, splices, connectors = %d, %d, %d, want %d, %d, %d",
+					trace.Length, trace.Splices, trace.Connectors, tt.length, tt.splices, tt.connectors)
+			}
+		})
+	}
+}
+
+func TestBuildLossBudgets(t *testing.T) {
+	cables, splices := fiberTestCables()
+
+	trace, err := buildFiberTrace(cables, splices, 2, 2)
+	if err != nil {
+		t.Fatalf("buildFiberTrace() error = %v", err)
+	}
+
+	attenuation := []models.FiberAttenuation{
+		{ID: 1, Medium: models.CableMediumSMF, Wavelength: 1310, PerKm: 0.35, PerSplice: 0.05, PerConnector: 0.5},
+		{ID: 2, Medium: models.CableMediumSMF, Wavelength: 1550, PerKm: 0.25, PerSplice: 0.03, PerConnector: 0.3},
+		{ID: 3, Medium: models.CableMediumMMF, Wavelength: 850, PerKm: 3, PerSplice: 0.1, PerConnector: 0.5},
+	}
+
+	budget1310 := models.LossBudget{Wavelength: 1310, CableLoss: 1.4, SpliceLoss: 0.15, ConnectorLoss: 1, TotalLoss: 2.55}
+	budget1550 := models.LossBudget{Wavelength: 1550, CableLoss: 1, SpliceLoss: 0.13, ConnectorLoss: 0.6, TotalLoss: 1.73}
+
+	tests := []struct {
+		name       string
+		trace      models.FiberTrace
+		wavelength int
+		want       []models.LossBudget
+	}{
+		{name: "all wavelengths of the medium", trace: *trace, want: []models.LossBudget{budget1310, budget1550}},
+		{name: "one wavelength", trace: *trace, wavelength: 1550, want: []models.LossBudget{budget1550}},
+		{name: "unknown wavelength", trace: *trace, wavelength: 1625, want: []models.LossBudget{}},
+		{name: "empty trace", trace: models.FiberTrace{}, want: []models.LossBudget{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildLossBudgets(tt.trace, attenuation, tt.wavelength); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildLossBudgets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
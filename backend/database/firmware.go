@@ -0,0 +1,121 @@
+package database
+
+import (
+	"backend/models"
+	"errors"
+	"strings"
+)
+
+type FirmwareRepository interface {
+	GetFirmwareCompliance() (*models.FirmwareComplianceReport, error)
+}
+
+type DefaultFirmwareRepository struct {
+	Database Database
+}
+
+// GetFirmwareCompliance Сравнивает прошивку оборудования с целевой версией его модели. В отчет попадают
+// только модели с заданной целевой прошивкой, несоответствующее оборудование группируется по модели,
+// зоне и владельцу узла. Версии сравниваются без учета регистра и пробелов по краям
+func (r *DefaultFirmwareRepository) GetFirmwareCompliance() (*models.FirmwareComplianceReport, error) {
+	stmt, ok := r.Database.GetQuery("GET_FIRMWARE_COMPLIANCE")
+	if !ok {
+		return nil, errors.New("query GET_FIRMWARE_COMPLIANCE is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &models.FirmwareComplianceReport{
+		Summary: make([]models.FirmwareModelSummary, 0),
+		Groups:  make([]models.FirmwareComplianceGroup, 0),
+	}
+
+	for rows.Next() {
+		var hd models.Hardware
+
+		if err = rows.Scan(
+			&hd.ID,
+			&hd.IpAddress,
+			&hd.Firmware,
+			&hd.FirmwarePolledAt,
+			&hd.Type.Value,
+			&hd.Switch.ID,
+			&hd.Switch.Name,
+			&hd.Switch.TargetFirmware,
+			&hd.Node.ID,
+			&hd.Node.Name,
+			&hd.Node.HouseId,
+			&hd.Node.Zone,
+			&hd.Node.Owner.ID,
+			&hd.Node.Owner.Value,
+		); err != nil {
+			return nil, err
+		}
+
+		// Строки отсортированы по модели, поэтому сводка по модели всегда последняя
+		if len(report.Summary) == 0 || report.Summary[len(report.Summary)-1].Switch.ID != hd.Switch.ID {
+			report.Summary = append(report.Summary, models.FirmwareModelSummary{
+				Switch: models.Switch{ID: hd.Switch.ID, Name: hd.Switch.Name, TargetFirmware: hd.Switch.TargetFirmware},
+			})
+		}
+
+		summary := &report.Summary[len(report.Summary)-1]
+		summary.Total++
+
+		status := firmwareStatus(hd.Firmware.String, hd.Switch.TargetFirmware.String)
+
+		switch status {
+		case models.FirmwareCompliant:
+			summary.Compliant++
+			continue
+		case models.FirmwareOutdated:
+			summary.Outdated++
+		case models.FirmwareUnknown:
+			summary.Unknown++
+		}
+
+		last := len(report.Groups) - 1
+
+		if last < 0 || report.Groups[last].Switch.ID != hd.Switch.ID ||
+			report.Groups[last].Zone != hd.Node.Zone || report.Groups[last].Owner.ID != hd.Node.Owner.ID {
+			report.Groups = append(report.Groups, models.FirmwareComplianceGroup{
+				Switch: summary.Switch,
+				Zone:   hd.Node.Zone,
+				Owner:  hd.Node.Owner,
+			})
+			last++
+		}
+
+		report.Groups[last].Hardware = append(report.Groups[last].Hardware, models.FirmwareComplianceItem{
+			Hardware: hd,
+			Status:   status,
+		})
+		report.Count++
+	}
+
+	for i := range report.Summary {
+		if report.Summary[i].Total > 0 {
+			report.Summary[i].Percent = float64(report.Summary[i].Compliant) * 100 / float64(report.Summary[i].Total)
+		}
+	}
+
+	return report, nil
+}
+
+func firmwareStatus(firmware string, target string) string {
+	firmware = strings.TrimSpace(firmware)
+
+	if firmware == "" {
+		return models.FirmwareUnknown
+	}
+
+	if strings.EqualFold(firmware, strings.TrimSpace(target)) {
+		return models.FirmwareCompliant
+	}
+
+	return models.FirmwareOutdated
+}
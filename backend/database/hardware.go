@@ -5,17 +5,25 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/lib/pq"
+	"math"
+	"time"
 )
 
 type HardwareRepository interface {
 	GetHardwareByID(hardware *models.Hardware) error
-	EditHardware(hardware *models.Hardware) error
-	CreateHardware(hardware *models.Hardware) error
+	EditHardware(hardware *models.Hardware, userID int32) error
+	CreateHardware(hardware *models.Hardware, userID int32) error
 	GetHardware(offset int, houseID int, nodeID int) ([]models.Hardware, int, error)
 	ValidateHardware(hardware models.Hardware) bool
-	DeleteHardware(hardwareID int) error
+	DeleteHardware(hardwareID int, deletedBy int32, deletedAt int64) error
+	RestoreHardware(hardwareID int, userID int32, updatedAt int64) error
+	GetDeletedHardware(offset int) ([]models.Hardware, int, error)
 	GetHardwareForIndex() ([]models.Hardware, error)
 	GetHardwareByIDs(hardwareIDs []int32) ([]models.Hardware, error)
+	GetHardwareByNodeIDs(nodeIDs []int32) ([]models.Hardware, error)
+	GetInventoryHardware(filter models.InventoryFilter) ([]models.Hardware, error)
+	GetHardwareStatusHistory(hardwareID int) ([]models.HardwareStatusChange, error)
+	GetWarrantyExpiring(until int64, from int64) ([]models.WarrantyItem, error)
 }
 
 type DefaultHardwareRepository struct {
@@ -73,6 +81,50 @@ func (r *DefaultHardwareRepository) GetHardwareByIDs(hardwareIDs []int32) ([]mod
 	return orderedHardware, nil
 }
 
+func (r *DefaultHardwareRepository) GetHardwareByNodeIDs(nodeIDs []int32) ([]models.Hardware, error) {
+	stmt, ok := r.Database.GetQuery("GET_HARDWARE_BY_NODE_IDS")
+	if !ok {
+		return nil, errors.New("query GET_HARDWARE_BY_NODE_IDS is not prepare")
+	}
+
+	rows, err := stmt.Query(pq.Array(nodeIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hardware []models.Hardware
+
+	for rows.Next() {
+		var hd models.Hardware
+		var switchID sql.NullInt32
+		var switchName sql.NullString
+
+		if err = rows.Scan(
+			&hd.ID,
+			&hd.Node.ID,
+			&hd.Type.ID,
+			&switchID,
+			&hd.IpAddress,
+			&hd.Node.HouseId,
+			&hd.Type.Key,
+			&hd.Type.Value,
+			&switchName,
+			&hd.Node.Name,
+		); err != nil {
+			return nil, err
+		}
+
+		if switchID.Valid {
+			hd.Switch = models.Switch{ID: int(switchID.Int32), Name: switchName.String}
+		}
+
+		hardware = append(hardware, hd)
+	}
+
+	return hardware, nil
+}
+
 func (r *DefaultHardwareRepository) GetHardwareForIndex() ([]models.Hardware, error) {
 	stmt, ok := r.Database.GetQuery("GET_HARDWARE_FOR_INDEX")
 	if !ok {
@@ -113,18 +165,83 @@ func (r *DefaultHardwareRepository) GetHardwareForIndex() ([]models.Hardware, er
 	return hardware, nil
 }
 
-func (r *DefaultHardwareRepository) DeleteHardware(hardwareID int) error {
-	stmt, ok := r.Database.GetQuery("DELETE_HARDWARE")
+// DeleteHardware Помечает оборудование удаленным и пишет версию истории в одной транзакции.
+// Установка в стойку удаляется, чтобы юниты удаленного оборудования освободились
+func (r *DefaultHardwareRepository) DeleteHardware(hardwareID int, deletedBy int32, deletedAt int64) error {
+	history := &models.History{Entity: "HARDWARE", EntityID: hardwareID, Action: "DELETE", UserId: deletedBy, CreatedAt: deletedAt}
+	keys := []string{"DELETE_HARDWARE", "DELETE_HARDWARE_PLACEMENT"}
+
+	return changeWithHistory(r.Database, history, keys, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		if _, err := tx.Stmt(stmts["DELETE_HARDWARE"]).Exec(hardwareID, deletedAt, deletedBy); err != nil {
+			return err
+		}
+
+		_, err := tx.Stmt(stmts["DELETE_HARDWARE_PLACEMENT"]).Exec(hardwareID)
+		return err
+	})
+}
+
+// RestoreHardware Снимает пометку удаления и пишет версию истории в одной транзакции
+func (r *DefaultHardwareRepository) RestoreHardware(hardwareID int, userID int32, updatedAt int64) error {
+	history := &models.History{Entity: "HARDWARE", EntityID: hardwareID, Action: "RESTORE", UserId: userID, CreatedAt: updatedAt}
+
+	return changeWithHistory(r.Database, history, []string{"RESTORE_HARDWARE"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		_, err := tx.Stmt(stmts["RESTORE_HARDWARE"]).Exec(hardwareID, updatedAt)
+		return err
+	})
+}
+
+func (r *DefaultHardwareRepository) GetDeletedHardware(offset int) ([]models.Hardware, int, error) {
+	stmt, ok := r.Database.GetQuery("GET_DELETED_HARDWARE")
 	if !ok {
-		return errors.New("query DELETE_HARDWARE is not prepare")
+		return nil, 0, errors.New("query GET_DELETED_HARDWARE is not prepare")
 	}
 
-	_, err := stmt.Exec(hardwareID)
+	rows, err := stmt.Query(offset)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
+	defer rows.Close()
 
-	return nil
+	var hardware []models.Hardware
+	var count int
+
+	for rows.Next() {
+		var (
+			hd         models.Hardware
+			switchID   sql.NullInt64
+			switchName sql.NullString
+		)
+
+		if err = rows.Scan(
+			&hd.ID,
+			&hd.Node.ID,
+			&hd.Type.ID,
+			&switchID,
+			&hd.IpAddress,
+			&hd.Node.HouseId,
+			&hd.Type.Key,
+			&hd.Type.Value,
+			&switchName,
+			&hd.Node.Name,
+			&hd.Node.IsDelete,
+			&hd.DeletedAt,
+			&hd.DeletedBy,
+			&count,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		if switchID.Valid {
+			hd.Switch = models.Switch{ID: int(switchID.Int64), Name: switchName.String}
+		}
+
+		hd.IsDelete = true
+
+		hardware = append(hardware, hd)
+	}
+
+	return hardware, count, nil
 }
 
 func (r *DefaultHardwareRepository) GetHardwareByID(hardware *models.Hardware) error {
@@ -149,11 +266,21 @@ func (r *DefaultHardwareRepository) GetHardwareByID(hardware *models.Hardware) e
 		&hardware.CreatedAt,
 		&hardware.UpdatedAt,
 		&hardware.IsDelete,
+		&hardware.DeletedAt,
+		&hardware.DeletedBy,
+		&hardware.Firmware,
+		&hardware.FirmwarePolledAt,
+		&hardware.SerialNumber,
+		&hardware.InventoryNumber,
+		&hardware.PurchaseDate,
+		&hardware.WarrantyEnd,
+		&hardware.Status,
 		&hardware.Node.HouseId,
 		&hardware.Type.Key,
 		&hardware.Type.Value,
 		&switchName,
 		&hardware.Node.Name,
+		&hardware.Node.IsDelete,
 	); err != nil {
 		return err
 	}
@@ -165,11 +292,12 @@ func (r *DefaultHardwareRepository) GetHardwareByID(hardware *models.Hardware) e
 	return nil
 }
 
-func (r *DefaultHardwareRepository) EditHardware(hardware *models.Hardware) error {
-	stmt, ok := r.Database.GetQuery("EDIT_HARDWARE")
-	if !ok {
-		return errors.New("query EDIT_HARDWARE is not prepare")
-	}
+// EditHardware Изменяет оборудование, записывает статус в историю статусов и версию в историю
+// изменений в одной транзакции. Если оборудование перенесено в другой узел, установка в стойку
+// старого узла удаляется
+func (r *DefaultHardwareRepository) EditHardware(hardware *models.Hardware, userID int32) error {
+	history := &models.History{Entity: "HARDWARE", EntityID: hardware.ID, Action: "EDIT", UserId: userID, CreatedAt: hardware.UpdatedAt.Int64}
+	keys := []string{"EDIT_HARDWARE", "CREATE_HARDWARE_STATUS", "DELETE_MOVED_HARDWARE_PLACEMENT"}
 
 	var switchID interface{}
 
@@ -177,28 +305,38 @@ func (r *DefaultHardwareRepository) EditHardware(hardware *models.Hardware) erro
 		switchID = hardware.Switch.ID
 	}
 
-	_, err := stmt.Exec(
-		hardware.ID,
-		hardware.Node.ID,
-		hardware.Type.ID,
-		switchID,
-		hardware.IpAddress,
-		hardware.MgmtVlan,
-		hardware.Description,
-		hardware.UpdatedAt,
-	)
-	if err != nil {
-		return err
-	}
+	return changeWithHistory(r.Database, history, keys, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		if err := tx.Stmt(stmts["EDIT_HARDWARE"]).QueryRow(
+			hardware.ID,
+			hardware.Node.ID,
+			hardware.Type.ID,
+			switchID,
+			hardware.IpAddress,
+			hardware.MgmtVlan,
+			hardware.Description,
+			hardware.UpdatedAt,
+			hardware.SerialNumber,
+			hardware.InventoryNumber,
+			hardware.PurchaseDate,
+			hardware.WarrantyEnd,
+			hardware.Status,
+		).Scan(&hardware.Status); err != nil {
+			return err
+		}
 
-	return nil
+		if _, err := tx.Stmt(stmts["DELETE_MOVED_HARDWARE_PLACEMENT"]).Exec(hardware.ID, hardware.Node.ID); err != nil {
+			return err
+		}
+
+		_, err := tx.Stmt(stmts["CREATE_HARDWARE_STATUS"]).Exec(hardware.ID, hardware.Status, userID, hardware.UpdatedAt.Int64)
+		return err
+	})
 }
 
-func (r *DefaultHardwareRepository) CreateHardware(hardware *models.Hardware) error {
-	stmt, ok := r.Database.GetQuery("CREATE_HARDWARE")
-	if !ok {
-		return errors.New("query CREATE_HARDWARE is not prepare")
-	}
+// CreateHardware Создает оборудование, записывает начальный статус и версию истории в одной транзакции
+func (r *DefaultHardwareRepository) CreateHardware(hardware *models.Hardware, userID int32) error {
+	history := &models.History{Entity: "HARDWARE", Action: "CREATE", UserId: userID, CreatedAt: hardware.CreatedAt}
+	keys := []string{"CREATE_HARDWARE", "CREATE_HARDWARE_STATUS"}
 
 	var switchID interface{}
 
@@ -206,20 +344,30 @@ func (r *DefaultHardwareRepository) CreateHardware(hardware *models.Hardware) er
 		switchID = hardware.Switch.ID
 	}
 
-	if err := stmt.QueryRow(
-		hardware.Node.ID,
-		hardware.Type.ID,
-		switchID,
-		hardware.IpAddress,
-		hardware.MgmtVlan,
-		hardware.Description,
-		hardware.CreatedAt,
-		nil,
-	).Scan(&hardware.ID); err != nil {
-		return err
-	}
+	return changeWithHistory(r.Database, history, keys, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		if err := tx.Stmt(stmts["CREATE_HARDWARE"]).QueryRow(
+			hardware.Node.ID,
+			hardware.Type.ID,
+			switchID,
+			hardware.IpAddress,
+			hardware.MgmtVlan,
+			hardware.Description,
+			hardware.CreatedAt,
+			nil,
+			hardware.SerialNumber,
+			hardware.InventoryNumber,
+			hardware.PurchaseDate,
+			hardware.WarrantyEnd,
+			hardware.Status,
+		).Scan(&hardware.ID); err != nil {
+			return err
+		}
 
-	return nil
+		history.EntityID = hardware.ID
+
+		_, err := tx.Stmt(stmts["CREATE_HARDWARE_STATUS"]).Exec(hardware.ID, hardware.Status, userID, hardware.CreatedAt)
+		return err
+	})
 }
 
 func (r *DefaultHardwareRepository) GetHardware(offset int, houseID int, nodeID int) ([]models.Hardware, int, error) {
@@ -279,5 +427,159 @@ func (r *DefaultHardwareRepository) ValidateHardware(hardware models.Hardware) b
 		return false
 	}
 
+	if _, ok := models.HardwareStatuses[hardware.Status]; hardware.Status != "" && !ok {
+		return false
+	}
+
+	if hardware.PurchaseDate.Valid && hardware.WarrantyEnd.Valid && hardware.WarrantyEnd.Int64 < hardware.PurchaseDate.Int64 {
+		return false
+	}
+
 	return true
 }
+
+func (r *DefaultHardwareRepository) GetInventoryHardware(filter models.InventoryFilter) ([]models.Hardware, error) {
+	stmt, ok := r.Database.GetQuery("GET_INVENTORY_HARDWARE")
+	if !ok {
+		return nil, errors.New("query GET_INVENTORY_HARDWARE is not prepare")
+	}
+
+	rows, err := stmt.Query(
+		filter.HouseID,
+		filter.OwnerID,
+		filter.Zone,
+		filter.NodeTypeID,
+		filter.IsDelete,
+		filter.IsPassive,
+		filter.HardwareTypeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hardware []models.Hardware
+
+	for rows.Next() {
+		var hd models.Hardware
+		var switchID sql.NullInt32
+		var switchName sql.NullString
+
+		if err = rows.Scan(
+			&hd.ID,
+			&hd.Node.ID,
+			&hd.Node.Name,
+			&hd.Node.HouseId,
+			&hd.Type.Value,
+			&switchID,
+			&switchName,
+			&hd.IpAddress,
+			&hd.MgmtVlan,
+			&hd.Description,
+			&hd.IsDelete,
+			&hd.CreatedAt,
+			&hd.UpdatedAt,
+			&hd.SerialNumber,
+			&hd.InventoryNumber,
+			&hd.PurchaseDate,
+			&hd.WarrantyEnd,
+			&hd.Status,
+		); err != nil {
+			return nil, err
+		}
+
+		if switchID.Valid {
+			hd.Switch = models.Switch{ID: int(switchID.Int32), Name: switchName.String}
+		}
+
+		hardware = append(hardware, hd)
+	}
+
+	return hardware, nil
+}
+
+func (r *DefaultHardwareRepository) GetHardwareStatusHistory(hardwareID int) ([]models.HardwareStatusChange, error) {
+	stmt, ok := r.Database.GetQuery("GET_HARDWARE_STATUS_HISTORY")
+	if !ok {
+		return nil, errors.New("query GET_HARDWARE_STATUS_HISTORY is not prepare")
+	}
+
+	rows, err := stmt.Query(hardwareID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]models.HardwareStatusChange, 0)
+
+	for rows.Next() {
+		var change models.HardwareStatusChange
+
+		if err = rows.Scan(
+			&change.ID,
+			&change.HardwareID,
+			&change.Status,
+			&change.UserId,
+			&change.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// GetWarrantyExpiring Возвращает неснятое оборудование, гарантия которого заканчивается до until.
+// При from = 0 в отчет попадает и оборудование с уже закончившейся гарантией
+func (r *DefaultHardwareRepository) GetWarrantyExpiring(until int64, from int64) ([]models.WarrantyItem, error) {
+	stmt, ok := r.Database.GetQuery("GET_WARRANTY_EXPIRING")
+	if !ok {
+		return nil, errors.New("query GET_WARRANTY_EXPIRING is not prepare")
+	}
+
+	rows, err := stmt.Query(until, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.WarrantyItem, 0)
+	now := time.Now()
+
+	for rows.Next() {
+		var (
+			item       models.WarrantyItem
+			switchName sql.NullString
+		)
+
+		if err = rows.Scan(
+			&item.Hardware.ID,
+			&item.Hardware.IpAddress,
+			&item.Hardware.SerialNumber,
+			&item.Hardware.InventoryNumber,
+			&item.Hardware.PurchaseDate,
+			&item.Hardware.WarrantyEnd,
+			&item.Hardware.Status,
+			&item.Hardware.Type.Value,
+			&switchName,
+			&item.Hardware.Node.ID,
+			&item.Hardware.Node.Name,
+			&item.Hardware.Node.HouseId,
+			&item.Hardware.Node.Zone,
+		); err != nil {
+			return nil, err
+		}
+
+		item.Hardware.Switch.Name = switchName.String
+
+		left := time.Unix(item.Hardware.WarrantyEnd.Int64, 0).Sub(now)
+		item.DaysLeft = int(math.Ceil(left.Hours() / 24))
+		item.IsExpired = left < 0
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,302 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type HistoryRepository interface {
+	GetHistory(entity string, entityID int, offset int) ([]models.History, int, error)
+	GetHistoryVersion(entity string, entityID int, version int) (*models.History, error)
+	RevertEntity(entity string, entityID int, snapshot []byte, userID int32) (*models.History, error)
+}
+
+type DefaultHistoryRepository struct {
+	Database Database
+}
+
+// HistoryEntities Сущности, для которых ведется история изменений
+var HistoryEntities = map[string]struct{}{
+	"NODE":            {},
+	"HARDWARE":        {},
+	"SWITCH":          {},
+	"OWNERS":          {},
+	"NODE_TYPES":      {},
+	"HARDWARE_TYPES":  {},
+	"OPERATION_MODES": {},
+	"ROOF_TYPES":      {},
+	"WIRING_TYPES":    {},
+	"REPORT_DATA":     {},
+	"VLAN":            {},
+	"SUBNET":          {},
+}
+
+func (r *DefaultHistoryRepository) GetHistory(entity string, entityID int, offset int) ([]models.History, int, error) {
+	stmt, ok := r.Database.GetQuery("GET_HISTORY")
+	if !ok {
+		return nil, 0, errors.New("query GET_HISTORY is not prepare")
+	}
+
+	rows, err := stmt.Query(entity, entityID, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var history []models.History
+	var count int
+
+	for rows.Next() {
+		var record models.History
+		var before, after []byte
+
+		if err = rows.Scan(
+			&record.ID,
+			&record.Entity,
+			&record.EntityID,
+			&record.Version,
+			&record.Action,
+			&before,
+			&after,
+			&record.UserId,
+			&record.CreatedAt,
+			&count,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		record.Before, record.After = before, after
+
+		history = append(history, record)
+	}
+
+	return history, count, nil
+}
+
+func (r *DefaultHistoryRepository) GetHistoryVersion(entity string, entityID int, version int) (*models.History, error) {
+	stmt, ok := r.Database.GetQuery("GET_HISTORY_VERSION")
+	if !ok {
+		return nil, errors.New("query GET_HISTORY_VERSION is not prepare")
+	}
+
+	var record models.History
+	var before, after []byte
+
+	if err := stmt.QueryRow(entity, entityID, version).Scan(
+		&record.ID,
+		&record.Entity,
+		&record.EntityID,
+		&record.Version,
+		&record.Action,
+		&before,
+		&after,
+		&record.UserId,
+		&record.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	record.Before, record.After = before, after
+
+	return &record, nil
+}
+
+// RevertEntity Возвращает сущность к состоянию из снимка и записывает откат новой версией в одной транзакции
+func (r *DefaultHistoryRepository) RevertEntity(entity string, entityID int, snapshot []byte, userID int32) (*models.History, error) {
+	if _, ok := HistoryEntities[entity]; !ok {
+		return nil, fmt.Errorf("entity is unsupported (%s)", entity)
+	}
+
+	keys := []string{"LOCK_ENTITY_HISTORY", "GET_ENTITY_SNAPSHOT", "CREATE_HISTORY", "REVERT_" + entity}
+
+	if entity == "HARDWARE" {
+		keys = append(keys, "CREATE_HARDWARE_STATUS")
+	}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return nil, errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = lockEntityHistory(tx.Stmt(stmts["LOCK_ENTITY_HISTORY"]), entity, entityID); err != nil {
+		return nil, err
+	}
+
+	snapshotStmt := tx.Stmt(stmts["GET_ENTITY_SNAPSHOT"])
+
+	before, err := getEntitySnapshot(snapshotStmt, entity, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if before == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	updatedAt := time.Now().Unix()
+	params := []interface{}{entityID, string(snapshot)}
+
+	if entity == "NODE" || entity == "HARDWARE" || entity == "VLAN" || entity == "SUBNET" {
+		params = append(params, updatedAt)
+	}
+
+	revertStmt := tx.Stmt(stmts["REVERT_"+entity])
+
+	// Откат может изменить статус оборудования, поэтому история статусов пишется в той же транзакции
+	if entity == "HARDWARE" {
+		var status string
+
+		if err = revertStmt.QueryRow(params...).Scan(&status); err != nil {
+			return nil, err
+		}
+
+		if _, err = tx.Stmt(stmts["CREATE_HARDWARE_STATUS"]).Exec(entityID, status, userID, updatedAt); err != nil {
+			return nil, err
+		}
+	} else if _, err = revertStmt.Exec(params...); err != nil {
+		return nil, err
+	}
+
+	history := &models.History{
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    "REVERT",
+		Before:    before,
+		UserId:    userID,
+		CreatedAt: updatedAt,
+	}
+
+	if err = createHistory(snapshotStmt, tx.Stmt(stmts["CREATE_HISTORY"]), history); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func getEntitySnapshot(stmt *sql.Stmt, entity string, entityID int) ([]byte, error) {
+	var snapshot []byte
+
+	if err := stmt.QueryRow(entity, entityID).Scan(&snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// createHistory Пишет версию сущности, может использоваться внутри транзакции через tx.Stmt
+func createHistory(snapshotStmt *sql.Stmt, createStmt *sql.Stmt, history *models.History) error {
+	after, err := getEntitySnapshot(snapshotStmt, history.Entity, history.EntityID)
+	if err != nil {
+		return err
+	}
+
+	history.After = after
+
+	var before, afterParam interface{}
+
+	if history.Before != nil {
+		before = string(history.Before)
+	}
+
+	if after != nil {
+		afterParam = string(after)
+	}
+
+	return createStmt.QueryRow(
+		history.Entity,
+		history.EntityID,
+		history.Action,
+		before,
+		afterParam,
+		history.UserId,
+		history.CreatedAt,
+	).Scan(&history.ID, &history.Version)
+}
+
+// lockEntityHistory Блокирует ключ истории сущности до конца транзакции. Берется до снимка "до",
+// поэтому параллельные изменения одной сущности получают снимки и номера версий по очереди
+func lockEntityHistory(stmt *sql.Stmt, entity string, entityID int) error {
+	rows, err := stmt.Query(entity, entityID)
+	if err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+// changeWithHistory Выполняет изменение сущности и записывает его версией истории в одной транзакции:
+// блокирует ключ истории, снимает снимок "до", вызывает change и пишет версию со снимком "после".
+// При создании сущности history.EntityID равен 0 и заполняется в change. Для несуществующей
+// сущности возвращает sql.ErrNoRows, ничего не изменяя
+func changeWithHistory(db Database, history *models.History, keys []string, change func(tx *sql.Tx, stmts map[string]*sql.Stmt) error) error {
+	keys = append([]string{"LOCK_ENTITY_HISTORY", "GET_ENTITY_SNAPSHOT", "CREATE_HISTORY"}, keys...)
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := db.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	lockStmt := tx.Stmt(stmts["LOCK_ENTITY_HISTORY"])
+	snapshotStmt := tx.Stmt(stmts["GET_ENTITY_SNAPSHOT"])
+
+	isCreate := history.EntityID == 0
+
+	if !isCreate {
+		if err = lockEntityHistory(lockStmt, history.Entity, history.EntityID); err != nil {
+			return err
+		}
+
+		if history.Before, err = getEntitySnapshot(snapshotStmt, history.Entity, history.EntityID); err != nil {
+			return err
+		}
+
+		if history.Before == nil {
+			return sql.ErrNoRows
+		}
+	}
+
+	if err = change(tx, stmts); err != nil {
+		return err
+	}
+
+	if isCreate {
+		if err = lockEntityHistory(lockStmt, history.Entity, history.EntityID); err != nil {
+			return err
+		}
+	}
+
+	if err = createHistory(snapshotStmt, tx.Stmt(stmts["CREATE_HISTORY"]), history); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,376 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+type IpamRepository interface {
+	GetSubnets() ([]models.Subnet, error)
+	GetSubnet(subnet *models.Subnet) error
+	CreateSubnet(subnet *models.Subnet, userID int32) error
+	EditSubnet(subnet *models.Subnet, userID int32) error
+	GetOverlappingSubnet(subnet models.Subnet) (*models.Subnet, error)
+	ValidateHardwareIP(hardware models.Hardware) (*models.IPViolation, error)
+	GetNextFreeIP(subnetID int) (string, error)
+	GetSubnetUtilization() ([]models.SubnetUtilization, error)
+	ValidateSubnet(subnet models.Subnet) bool
+}
+
+type DefaultIpamRepository struct {
+	Database Database
+}
+
+func (r *DefaultIpamRepository) GetSubnets() ([]models.Subnet, error) {
+	stmt, ok := r.Database.GetQuery("GET_SUBNETS")
+	if !ok {
+		return nil, errors.New("query GET_SUBNETS is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subnets := make([]models.Subnet, 0)
+
+	for rows.Next() {
+		var subnet models.Subnet
+
+		if err = rows.Scan(
+			&subnet.ID,
+			&subnet.Network,
+			&subnet.Gateway,
+			&subnet.Zone,
+			&subnet.VlanID,
+			&subnet.Description,
+			&subnet.CreatedAt,
+			&subnet.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		subnets = append(subnets, subnet)
+	}
+
+	return subnets, nil
+}
+
+func (r *DefaultIpamRepository) GetSubnet(subnet *models.Subnet) error {
+	stmt, ok := r.Database.GetQuery("GET_SUBNET")
+	if !ok {
+		return errors.New("query GET_SUBNET is not prepare")
+	}
+
+	return stmt.QueryRow(subnet.ID).Scan(
+		&subnet.ID,
+		&subnet.Network,
+		&subnet.Gateway,
+		&subnet.Zone,
+		&subnet.VlanID,
+		&subnet.Description,
+		&subnet.CreatedAt,
+		&subnet.UpdatedAt,
+	)
+}
+
+// CreateSubnet Создает подсеть и пишет версию истории в одной транзакции
+func (r *DefaultIpamRepository) CreateSubnet(subnet *models.Subnet, userID int32) error {
+	history := &models.History{Entity: "SUBNET", Action: "CREATE", UserId: userID, CreatedAt: subnet.CreatedAt}
+
+	return changeWithHistory(r.Database, history, []string{"CREATE_SUBNET"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		if err := tx.Stmt(stmts["CREATE_SUBNET"]).QueryRow(
+			subnet.Network,
+			subnet.Gateway,
+			subnet.Zone,
+			subnet.VlanID,
+			subnet.Description,
+			subnet.CreatedAt,
+		).Scan(&subnet.ID, &subnet.Network); err != nil {
+			return err
+		}
+
+		history.EntityID = subnet.ID
+
+		return nil
+	})
+}
+
+// EditSubnet Изменяет подсеть и пишет версию истории в одной транзакции. Возвращает sql.ErrNoRows,
+// если подсеть не найдена
+func (r *DefaultIpamRepository) EditSubnet(subnet *models.Subnet, userID int32) error {
+	history := &models.History{Entity: "SUBNET", EntityID: subnet.ID, Action: "EDIT", UserId: userID, CreatedAt: subnet.UpdatedAt.Int64}
+
+	return changeWithHistory(r.Database, history, []string{"EDIT_SUBNET"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		return tx.Stmt(stmts["EDIT_SUBNET"]).QueryRow(
+			subnet.ID,
+			subnet.Network,
+			subnet.Gateway,
+			subnet.Zone,
+			subnet.VlanID,
+			subnet.Description,
+			subnet.UpdatedAt,
+		).Scan(&subnet.Network)
+	})
+}
+
+// GetOverlappingSubnet Возвращает другую подсеть, пересекающуюся с данной, или nil
+func (r *DefaultIpamRepository) GetOverlappingSubnet(subnet models.Subnet) (*models.Subnet, error) {
+	stmt, ok := r.Database.GetQuery("GET_OVERLAPPING_SUBNET")
+	if !ok {
+		return nil, errors.New("query GET_OVERLAPPING_SUBNET is not prepare")
+	}
+
+	var overlapping models.Subnet
+
+	if err := stmt.QueryRow(subnet.Network, subnet.ID).Scan(
+		&overlapping.ID,
+		&overlapping.Network,
+		&overlapping.Gateway,
+		&overlapping.Zone,
+		&overlapping.VlanID,
+		&overlapping.Description,
+		&overlapping.CreatedAt,
+		&overlapping.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &overlapping, nil
+}
+
+// ValidateHardwareIP Проверяет адрес управления оборудования: формат IPv4, уникальность среди неудаленного
+// оборудования и принадлежность подсети IPAM с той же зоной и VLAN управления. Пока в IPAM нет ни одной
+// подсети, проверяются только формат и уникальность
+func (r *DefaultIpamRepository) ValidateHardwareIP(hardware models.Hardware) (*models.IPViolation, error) {
+	ipAddress := strings.TrimSpace(hardware.IpAddress.String)
+
+	if !hardware.IpAddress.Valid || ipAddress == "" {
+		return nil, nil
+	}
+
+	if ip := net.ParseIP(ipAddress); ip == nil || ip.To4() == nil {
+		return &models.IPViolation{
+			Code:      "INVALID_IP",
+			Message:   fmt.Sprintf("ip address %s is not a valid IPv4 address", ipAddress),
+			IpAddress: ipAddress,
+		}, nil
+	}
+
+	keys := []string{"GET_HARDWARE_BY_IP", "COUNT_SUBNETS", "GET_SUBNET_BY_IP"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return nil, errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	var duplicate models.Hardware
+
+	err := stmts["GET_HARDWARE_BY_IP"].QueryRow(ipAddress, hardware.ID).Scan(
+		&duplicate.ID,
+		&duplicate.IpAddress,
+		&duplicate.Type.Value,
+		&duplicate.Node.ID,
+		&duplicate.Node.Name,
+		&duplicate.Node.HouseId,
+	)
+	if err == nil {
+		return &models.IPViolation{
+			Code:      "DUPLICATE_IP",
+			Message:   fmt.Sprintf("ip address %s is already used by hardware %d (%s)", ipAddress, duplicate.ID, duplicate.Node.Name),
+			IpAddress: ipAddress,
+			Hardware:  &duplicate,
+		}, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var count int
+
+	if err = stmts["COUNT_SUBNETS"].QueryRow().Scan(&count); err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		return nil, nil
+	}
+
+	var (
+		subnet   models.Subnet
+		nodeZone sql.NullString
+	)
+
+	if err = stmts["GET_SUBNET_BY_IP"].QueryRow(ipAddress, hardware.Node.ID).Scan(
+		&subnet.ID,
+		&subnet.Network,
+		&subnet.Gateway,
+		&subnet.Zone,
+		&subnet.VlanID,
+		&subnet.Description,
+		&subnet.CreatedAt,
+		&subnet.UpdatedAt,
+		&nodeZone,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.IPViolation{
+				Code:      "OUT_OF_SUBNET",
+				Message:   fmt.Sprintf("ip address %s is out of ipam subnets", ipAddress),
+				IpAddress: ipAddress,
+			}, nil
+		}
+
+		return nil, err
+	}
+
+	_, network, err := net.ParseCIDR(subnet.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	if ones, bits := network.Mask.Size(); bits-ones >= 2 {
+		ip := net.ParseIP(ipAddress).To4()
+		broadcast := make(net.IP, len(network.IP))
+
+		for i := range network.IP {
+			broadcast[i] = network.IP[i] | ^network.Mask[i]
+		}
+
+		if ip.Equal(network.IP) || ip.Equal(broadcast) {
+			return &models.IPViolation{
+				Code:      "RESERVED_IP",
+				Message:   fmt.Sprintf("ip address %s is network or broadcast address of subnet %s", ipAddress, subnet.Network),
+				IpAddress: ipAddress,
+				Subnet:    &subnet,
+			}, nil
+		}
+	}
+
+	mgmtVlan := strings.TrimSpace(hardware.MgmtVlan.String)
+
+	if subnet.VlanID.Valid && mgmtVlan != "" && mgmtVlan != strconv.Itoa(int(subnet.VlanID.Int32)) {
+		return &models.IPViolation{
+			Code:      "VLAN_MISMATCH",
+			Message:   fmt.Sprintf("subnet %s belongs to vlan %d, but management vlan is %s", subnet.Network, subnet.VlanID.Int32, mgmtVlan),
+			IpAddress: ipAddress,
+			Subnet:    &subnet,
+		}, nil
+	}
+
+	if subnet.Zone.Valid && subnet.Zone.String != "" && nodeZone.Valid && nodeZone.String != "" &&
+		!strings.EqualFold(strings.TrimSpace(subnet.Zone.String), strings.TrimSpace(nodeZone.String)) {
+		return &models.IPViolation{
+			Code:      "ZONE_MISMATCH",
+			Message:   fmt.Sprintf("subnet %s belongs to zone %s, but node zone is %s", subnet.Network, subnet.Zone.String, nodeZone.String),
+			IpAddress: ipAddress,
+			Subnet:    &subnet,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// GetNextFreeIP Возвращает первый адрес подсети, не занятый оборудованием и шлюзом. В подсетях больше /16
+// просматриваются только первые 65535 адресов. Если свободных адресов нет, возвращается sql.ErrNoRows
+func (r *DefaultIpamRepository) GetNextFreeIP(subnetID int) (string, error) {
+	stmt, ok := r.Database.GetQuery("GET_NEXT_FREE_IP")
+	if !ok {
+		return "", errors.New("query GET_NEXT_FREE_IP is not prepare")
+	}
+
+	var ipAddress string
+
+	if err := stmt.QueryRow(subnetID).Scan(&ipAddress); err != nil {
+		return "", err
+	}
+
+	return ipAddress, nil
+}
+
+func (r *DefaultIpamRepository) GetSubnetUtilization() ([]models.SubnetUtilization, error) {
+	stmt, ok := r.Database.GetQuery("GET_SUBNET_UTILIZATION")
+	if !ok {
+		return nil, errors.New("query GET_SUBNET_UTILIZATION is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	utilization := make([]models.SubnetUtilization, 0)
+
+	for rows.Next() {
+		var item models.SubnetUtilization
+
+		if err = rows.Scan(
+			&item.Subnet.ID,
+			&item.Subnet.Network,
+			&item.Subnet.Gateway,
+			&item.Subnet.Zone,
+			&item.Subnet.VlanID,
+			&item.Subnet.Description,
+			&item.Subnet.CreatedAt,
+			&item.Subnet.UpdatedAt,
+			&item.Size,
+			&item.Used,
+			&item.Reserved,
+		); err != nil {
+			return nil, err
+		}
+
+		item.Free = item.Size - item.Used - item.Reserved
+
+		if item.Free < 0 {
+			item.Free = 0
+		}
+
+		if item.Size > 0 {
+			item.Percent = float64(item.Used+item.Reserved) * 100 / float64(item.Size)
+		}
+
+		utilization = append(utilization, item)
+	}
+
+	return utilization, nil
+}
+
+// ValidateSubnet Подсеть должна быть IPv4 сетью без установленных битов хоста,
+// шлюз - адресом внутри подсети, VLAN - в диапазоне 1-4094
+func (r *DefaultIpamRepository) ValidateSubnet(subnet models.Subnet) bool {
+	ip, network, err := net.ParseCIDR(strings.TrimSpace(subnet.Network))
+	if err != nil || ip.To4() == nil || !ip.Equal(network.IP) {
+		return false
+	}
+
+	if subnet.Gateway.Valid && subnet.Gateway.String != "" {
+		gateway := net.ParseIP(strings.TrimSpace(subnet.Gateway.String))
+
+		if gateway == nil || !network.Contains(gateway) {
+			return false
+		}
+	}
+
+	if subnet.VlanID.Valid && (subnet.VlanID.Int32 < 1 || subnet.VlanID.Int32 > 4094) {
+		return false
+	}
+
+	return true
+}
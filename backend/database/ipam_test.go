@@ -0,0 +1,43 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"testing"
+)
+
+// TestValidateHardwareIPWithoutDatabase Проверяет только случаи, которые отсекаются до запросов к базе
+func TestValidateHardwareIPWithoutDatabase(t *testing.T) {
+	repo := &DefaultIpamRepository{}
+
+	tests := []struct {
+		name      string
+		ipAddress sql.NullString
+		code      string
+	}{
+		{name: "no ip address", ipAddress: sql.NullString{}},
+		{name: "blank ip address", ipAddress: sql.NullString{String: "  ", Valid: true}},
+		{name: "not an ip address", ipAddress: sql.NullString{String: "10.0.0", Valid: true}, code: "INVALID_IP"},
+		{name: "octet out of range", ipAddress: sql.NullString{String: "10.0.0.256", Valid: true}, code: "INVALID_IP"},
+		{name: "ipv6 address", ipAddress: sql.NullString{String: "2001:db8::1", Valid: true}, code: "INVALID_IP"},
+		{name: "subnet instead of address", ipAddress: sql.NullString{String: "10.0.0.0/24", Valid: true}, code: "INVALID_IP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violation, err := repo.ValidateHardwareIP(models.Hardware{IpAddress: tt.ipAddress})
+			if err != nil {
+				t.Fatalf("ValidateHardwareIP() error = %v", err)
+			}
+
+			code := ""
+			if violation != nil {
+				code = violation.Code
+			}
+
+			if code != tt.code {
+				t.Errorf("ValidateHardwareIP(%q) code = %q, want %q", tt.ipAddress.String, code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+)
+
+type MacRepository interface {
+	SaveMacEntries(hardwareID int, entries []models.MacEntry, seenAt int64) error
+	GetMacEntries(mac string) ([]models.MacEntry, error)
+}
+
+type DefaultMacRepository struct {
+	Database Database
+}
+
+// SaveMacEntries Записывает таблицу коммутации из опроса в одной транзакции. Для уже известной связки
+// MAC, порта и VLAN обновляется только время последнего появления
+func (r *DefaultMacRepository) SaveMacEntries(hardwareID int, entries []models.MacEntry, seenAt int64) error {
+	stmt, ok := r.Database.GetQuery("UPSERT_MAC_ENTRY")
+	if !ok {
+		return errors.New("query UPSERT_MAC_ENTRY is not prepare")
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsertStmt := tx.Stmt(stmt)
+
+	for _, entry := range entries {
+		if _, err = upsertStmt.Exec(entry.Mac, hardwareID, entry.PortNumber, entry.VlanID, seenAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMacEntries Возвращает все места, где был замечен MAC адрес: сначала актуальные записи
+// на портах с наименьшим числом MAC адресов (порты абонентов), затем история
+func (r *DefaultMacRepository) GetMacEntries(mac string) ([]models.MacEntry, error) {
+	stmt, ok := r.Database.GetQuery("GET_MAC_ENTRIES")
+	if !ok {
+		return nil, errors.New("query GET_MAC_ENTRIES is not prepare")
+	}
+
+	rows, err := stmt.Query(mac)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.MacEntry, 0)
+
+	for rows.Next() {
+		var (
+			entry           models.MacEntry
+			switchName      sql.NullString
+			portID          sql.NullInt64
+			portDescription sql.NullString
+			portLinkState   sql.NullString
+		)
+
+		if err = rows.Scan(
+			&entry.ID,
+			&entry.Mac,
+			&entry.PortNumber,
+			&entry.VlanID,
+			&entry.FirstSeen,
+			&entry.LastSeen,
+			&entry.IsCurrent,
+			&entry.PortMacCount,
+			&entry.Hardware.ID,
+			&entry.Hardware.IpAddress,
+			&entry.Hardware.Type.Value,
+			&switchName,
+			&entry.Hardware.Node.ID,
+			&entry.Hardware.Node.Name,
+			&entry.Hardware.Node.HouseId,
+			&portID,
+			&portDescription,
+			&portLinkState,
+		); err != nil {
+			return nil, err
+		}
+
+		entry.Hardware.Switch.Name = switchName.String
+
+		if portID.Valid {
+			entry.Port = &models.HardwarePort{
+				ID:          int(portID.Int64),
+				Hardware:    models.Hardware{ID: entry.Hardware.ID},
+				Number:      entry.PortNumber,
+				Description: portDescription,
+				LinkState:   portLinkState,
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -4,24 +4,495 @@ import (
 	"backend/models"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/lib/pq"
+	"time"
 )
 
 type NodeRepository interface {
 	GetNodesByIDs(nodeIDs []int32) ([]models.Node, error)
-	EditNode(node *models.Node) error
-	CreateNode(node *models.Node) error
+	EditNode(node *models.Node, userID int32) error
+	CreateNode(node *models.Node, userID int32) error
 	GetNode(node *models.Node) error
 	GetNodes(offset int, onlyActive bool, houseID int) ([]models.Node, int, error)
 	ValidateNode(node models.Node) bool
-	DeleteNode(nodeID int) error
+	DeleteNode(nodeID int, deletedBy int32, deletedAt int64) error
+	RestoreNode(nodeID int, userID int32, updatedAt int64) error
+	GetDeletedNodes(offset int) ([]models.Node, int, error)
 	GetNodesForIndex() ([]models.Node, error)
+	GetNodeTree(nodeID int) ([]models.NodeTreeItem, error)
+	GetNodePath(nodeID int) ([]models.NodeTreeItem, error)
+	ValidateNodeTopology(node models.Node) (*models.TopologyViolation, error)
+	GetNodeTypeRules() ([]models.NodeTypeRule, error)
+	CreateNodeTypeRule(rule *models.NodeTypeRule) error
+	DeleteNodeTypeRule(ruleID int) error
+	MoveNode(nodeID int, move models.NodeMove, userID int32) ([]models.NodeTreeItem, error)
+	ExecuteNodeDeletePlan(plan *models.NodeDeletePlan, userID int32, deletedAt int64) error
+	ImportNodes(rows []models.NodeImportRow, userID int32, createdAt int64) error
+	GetInventoryNodes(filter models.InventoryFilter) ([]models.Node, error)
+	GetNodesInBBox(bbox models.BBox) ([]models.Node, error)
+	GetNodeLinksInBBox(bbox models.BBox) ([]models.NodeLink, error)
 }
 
 type DefaultNodeRepository struct {
 	Database Database
 }
 
+// ErrNodeCycle Новый родитель оказался потомком узла. Проверка повторяется в транзакции переноса,
+// т.к. параллельный перенос мог изменить дерево после ValidateNodeTopology
+var ErrNodeCycle = errors.New("parent node is a descendant of the node")
+
+func (r *DefaultNodeRepository) GetNodeTree(nodeID int) ([]models.NodeTreeItem, error) {
+	return r.getNodeTreeItems("GET_NODE_TREE", nodeID)
+}
+
+// GetNodePath Возвращает цепочку предков узла до узла опорной сети. Путь обрывается на удаленном предке,
+// удаленные узлы в путь не входят
+func (r *DefaultNodeRepository) GetNodePath(nodeID int) ([]models.NodeTreeItem, error) {
+	return r.getNodeTreeItems("GET_NODE_PATH", nodeID)
+}
+
+func (r *DefaultNodeRepository) getNodeTreeItems(key string, nodeID int) ([]models.NodeTreeItem, error) {
+	stmt, ok := r.Database.GetQuery(key)
+	if !ok {
+		return nil, errors.New("query " + key + " is not prepare")
+	}
+
+	rows, err := stmt.Query(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNodeTreeItems(rows)
+}
+
+func scanNodeTreeItems(rows *sql.Rows) ([]models.NodeTreeItem, error) {
+	var items []models.NodeTreeItem
+
+	for rows.Next() {
+		var (
+			item      models.NodeTreeItem
+			parentID  sql.NullInt64
+			typeID    sql.NullInt32
+			typeKey   sql.NullString
+			typeValue sql.NullString
+		)
+
+		if err := rows.Scan(
+			&item.Node.ID,
+			&parentID,
+			&item.Depth,
+			&item.Node.HouseId,
+			&item.Node.Name,
+			&item.Node.Zone,
+			&item.Node.IsPassive,
+			&typeID,
+			&typeKey,
+			&typeValue,
+			&item.HardwareAmount,
+		); err != nil {
+			return nil, err
+		}
+
+		if parentID.Valid {
+			item.Node.Parent = &models.Node{ID: int(parentID.Int64)}
+		}
+
+		if typeID.Valid {
+			item.Node.Type = &models.Reference{ID: int(typeID.Int32), Key: typeKey.String, Value: typeValue.String}
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// MoveNode Переносит узел вместе со всей его веткой под нового родителя в одной транзакции
+// и пишет по событию на каждый затронутый узел. Узел и цепочка предков нового родителя блокируются,
+// после чего цикл проверяется повторно (ErrNodeCycle). Возвращает перенесенную ветку
+func (r *DefaultNodeRepository) MoveNode(nodeID int, move models.NodeMove, userID int32) ([]models.NodeTreeItem, error) {
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range moveNodeKeys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return nil, errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	items, err := moveNode(tx, stmts, nodeID, move, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// moveNodeKeys Запросы, которые нужны moveNode
+var moveNodeKeys = []string{"LOCK_NODE_PATH", "CHECK_NODE_CYCLE", "GET_NODE_TREE", "MOVE_NODE", "TOUCH_NODES", "CREATE_EVENT", "LOCK_ENTITY_HISTORY", "GET_ENTITY_SNAPSHOT", "CREATE_HISTORY"}
+
+// moveNode Переносит узел с веткой в переданной транзакции: блокирует путь, повторно проверяет цикл,
+// пишет историю и события. Используется и при принятии предложения топологии, чтобы перенос и
+// отметка о рассмотрении были атомарны
+func moveNode(tx *sql.Tx, stmts map[string]*sql.Stmt, nodeID int, move models.NodeMove, userID int32) ([]models.NodeTreeItem, error) {
+	if err := lockNodePath(tx.Stmt(stmts["LOCK_NODE_PATH"]), nodeID, move.ParentID); err != nil {
+		return nil, err
+	}
+
+	if move.ParentID != 0 {
+		var path pq.Int64Array
+
+		err := tx.Stmt(stmts["CHECK_NODE_CYCLE"]).QueryRow(move.ParentID, nodeID).Scan(&path)
+		if err == nil {
+			return nil, ErrNodeCycle
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Stmt(stmts["GET_NODE_TREE"]).Query(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := scanNodeTreeItems(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	var parentID interface{}
+
+	if move.ParentID != 0 {
+		parentID = move.ParentID
+	}
+
+	updatedAt := time.Now().Unix()
+	snapshotStmt := tx.Stmt(stmts["GET_ENTITY_SNAPSHOT"])
+
+	if err = lockEntityHistory(tx.Stmt(stmts["LOCK_ENTITY_HISTORY"]), "NODE", nodeID); err != nil {
+		return nil, err
+	}
+
+	before, err := getEntitySnapshot(snapshotStmt, "NODE", nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.Stmt(stmts["MOVE_NODE"]).Exec(nodeID, parentID, move.HouseID, updatedAt); err != nil {
+		return nil, err
+	}
+
+	if err = createHistory(snapshotStmt, tx.Stmt(stmts["CREATE_HISTORY"]), &models.History{
+		Entity:    "NODE",
+		EntityID:  nodeID,
+		Action:    "MOVE",
+		Before:    before,
+		UserId:    userID,
+		CreatedAt: updatedAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	if move.HouseID != 0 {
+		items[0].Node.HouseId = move.HouseID
+	}
+
+	if move.ParentID != 0 {
+		items[0].Node.Parent = &models.Node{ID: move.ParentID}
+	} else {
+		items[0].Node.Parent = nil
+	}
+
+	nodeIDs := make([]int32, 0, len(items))
+
+	for _, item := range items[1:] {
+		nodeIDs = append(nodeIDs, int32(item.Node.ID))
+	}
+
+	if len(nodeIDs) > 0 {
+		if _, err = tx.Stmt(stmts["TOUCH_NODES"]).Exec(pq.Array(nodeIDs), updatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	eventStmt := tx.Stmt(stmts["CREATE_EVENT"])
+
+	for i, item := range items {
+		description := fmt.Sprintf("Перемещение узла: %s", item.Node.Name)
+
+		if i > 0 {
+			description = fmt.Sprintf("Перемещение узла в составе ветки узла %s: %s", items[0].Node.Name, item.Node.Name)
+		}
+
+		event := models.Event{
+			HouseId:     item.Node.HouseId,
+			Node:        &models.Node{ID: item.Node.ID},
+			UserId:      userID,
+			Description: description,
+			CreatedAt:   updatedAt,
+		}
+
+		if err = createEvent(eventStmt, event); err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+// lockNodePath Блокирует узел и всю цепочку предков нового родителя в порядке ID, чтобы параллельные
+// переносы встречных веток выполнялись по очереди и не могли вместе создать цикл
+func lockNodePath(stmt *sql.Stmt, nodeID int, parentID int) error {
+	rows, err := stmt.Query(nodeID, parentID)
+	if err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+// ExecuteNodeDeletePlan Применяет план удаления узла в одной транзакции: помечает удаленными узлы и оборудование,
+// переносит дочерние узлы к новому родителю и пишет по событию на каждую затронутую сущность
+func (r *DefaultNodeRepository) ExecuteNodeDeletePlan(plan *models.NodeDeletePlan, userID int32, deletedAt int64) error {
+	keys := []string{"DELETE_NODE", "DELETE_HARDWARE", "DELETE_HARDWARE_PLACEMENT", "MOVE_NODE", "CREATE_EVENT", "LOCK_ENTITY_HISTORY", "GET_ENTITY_SNAPSHOT", "CREATE_HISTORY"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	eventStmt := tx.Stmt(stmts["CREATE_EVENT"])
+	lockStmt := tx.Stmt(stmts["LOCK_ENTITY_HISTORY"])
+	snapshotStmt := tx.Stmt(stmts["GET_ENTITY_SNAPSHOT"])
+	historyStmt := tx.Stmt(stmts["CREATE_HISTORY"])
+
+	// execWithHistory Выполняет изменение сущности и пишет ее версию со снимками до и после
+	execWithHistory := func(stmt *sql.Stmt, entity string, entityID int, action string, params ...interface{}) error {
+		if e := lockEntityHistory(lockStmt, entity, entityID); e != nil {
+			return e
+		}
+
+		before, e := getEntitySnapshot(snapshotStmt, entity, entityID)
+		if e != nil {
+			return e
+		}
+
+		if _, e = tx.Stmt(stmt).Exec(params...); e != nil {
+			return e
+		}
+
+		return createHistory(snapshotStmt, historyStmt, &models.History{
+			Entity:    entity,
+			EntityID:  entityID,
+			Action:    action,
+			Before:    before,
+			UserId:    userID,
+			CreatedAt: deletedAt,
+		})
+	}
+
+	for _, hd := range plan.DeletedHardware {
+		if err = execWithHistory(stmts["DELETE_HARDWARE"], "HARDWARE", hd.ID, "DELETE", hd.ID, deletedAt, userID); err != nil {
+			return err
+		}
+
+		// Юниты стойки удаленного оборудования освобождаются
+		if _, err = tx.Stmt(stmts["DELETE_HARDWARE_PLACEMENT"]).Exec(hd.ID); err != nil {
+			return err
+		}
+
+		if err = createEvent(eventStmt, models.Event{
+			HouseId:     hd.Node.HouseId,
+			Node:        &models.Node{ID: hd.Node.ID},
+			Hardware:    &models.Hardware{ID: hd.ID},
+			UserId:      userID,
+			Description: fmt.Sprintf("Удаление оборудования: %s", hd.Type.Value),
+			CreatedAt:   deletedAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	var newParentID interface{}
+
+	if plan.NewParent != nil {
+		newParentID = plan.NewParent.ID
+	}
+
+	for _, node := range plan.ReparentedNodes {
+		if err = execWithHistory(stmts["MOVE_NODE"], "NODE", node.ID, "MOVE", node.ID, newParentID, 0, deletedAt); err != nil {
+			return err
+		}
+
+		if err = createEvent(eventStmt, models.Event{
+			HouseId:     node.HouseId,
+			Node:        &models.Node{ID: node.ID},
+			UserId:      userID,
+			Description: fmt.Sprintf("Перенос узла к вышестоящему узлу при удалении узла %s: %s", plan.Node.Name, node.Name),
+			CreatedAt:   deletedAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, node := range plan.DeletedNodes {
+		if err = execWithHistory(stmts["DELETE_NODE"], "NODE", node.ID, "DELETE", node.ID, deletedAt, userID); err != nil {
+			return err
+		}
+
+		if err = createEvent(eventStmt, models.Event{
+			HouseId:     node.HouseId,
+			Node:        &models.Node{ID: node.ID},
+			UserId:      userID,
+			Description: fmt.Sprintf("Удаление узла: %s", node.Name),
+			CreatedAt:   deletedAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ImportNodes Создает все узлы импорта в одной транзакции. Строки должны быть упорядочены так,
+// чтобы родитель из того же файла шел раньше дочернего узла
+func (r *DefaultNodeRepository) ImportNodes(rows []models.NodeImportRow, userID int32, createdAt int64) error {
+	createStmt, ok := r.Database.GetQuery("CREATE_NODE")
+	if !ok {
+		return errors.New("query CREATE_NODE is not prepare")
+	}
+
+	eventStmt, ok := r.Database.GetQuery("CREATE_EVENT")
+	if !ok {
+		return errors.New("query CREATE_EVENT is not prepare")
+	}
+
+	snapshotStmt, ok := r.Database.GetQuery("GET_ENTITY_SNAPSHOT")
+	if !ok {
+		return errors.New("query GET_ENTITY_SNAPSHOT is not prepare")
+	}
+
+	historyStmt, ok := r.Database.GetQuery("CREATE_HISTORY")
+	if !ok {
+		return errors.New("query CREATE_HISTORY is not prepare")
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	createStmt = tx.Stmt(createStmt)
+	eventStmt = tx.Stmt(eventStmt)
+	snapshotStmt = tx.Stmt(snapshotStmt)
+	historyStmt = tx.Stmt(historyStmt)
+
+	rowMap := make(map[int]*models.NodeImportRow)
+
+	for i := range rows {
+		node := &rows[i].Node
+
+		if rows[i].ParentRow != 0 {
+			parentRow, exist := rowMap[rows[i].ParentRow]
+			if !exist {
+				return fmt.Errorf("parent row %d is not imported before row %d", rows[i].ParentRow, rows[i].Row)
+			}
+
+			node.Parent = &models.Node{ID: parentRow.Node.ID, Name: parentRow.Node.Name}
+		}
+
+		var parentID interface{}
+		var typeID interface{}
+
+		if node.Parent != nil && !node.IsPassive {
+			parentID = node.Parent.ID
+		}
+
+		if node.Type != nil && !node.IsPassive {
+			typeID = node.Type.ID
+		}
+
+		node.CreatedAt = createdAt
+
+		if err = createStmt.QueryRow(
+			parentID,
+			node.HouseId,
+			typeID,
+			node.Owner.ID,
+			node.Name,
+			node.Zone,
+			node.Placement,
+			node.Supply,
+			node.Access,
+			node.Description,
+			node.CreatedAt,
+			node.UpdatedAt,
+			node.IsPassive,
+			node.Latitude,
+			node.Longitude,
+		).Scan(&node.ID); err != nil {
+			return fmt.Errorf("row %d: %w", rows[i].Row, err)
+		}
+
+		if err = createEvent(eventStmt, models.Event{
+			HouseId:     node.HouseId,
+			Node:        &models.Node{ID: node.ID},
+			UserId:      userID,
+			Description: fmt.Sprintf("Создание нового узла (импорт): %s", node.Name),
+			CreatedAt:   createdAt,
+		}); err != nil {
+			return err
+		}
+
+		if err = createHistory(snapshotStmt, historyStmt, &models.History{
+			Entity:    "NODE",
+			EntityID:  node.ID,
+			Action:    "CREATE",
+			UserId:    userID,
+			CreatedAt: createdAt,
+		}); err != nil {
+			return err
+		}
+
+		rowMap[rows[i].Row] = &rows[i]
+	}
+
+	return tx.Commit()
+}
+
 func (r *DefaultNodeRepository) GetNodesForIndex() ([]models.Node, error) {
 	stmt, ok := r.Database.GetQuery("GET_NODES_FOR_INDEX")
 	if !ok {
@@ -57,13 +528,13 @@ func (r *DefaultNodeRepository) GetNodesForIndex() ([]models.Node, error) {
 	return nodes, nil
 }
 
-func (r *DefaultNodeRepository) DeleteNode(nodeID int) error {
+func (r *DefaultNodeRepository) DeleteNode(nodeID int, deletedBy int32, deletedAt int64) error {
 	stmt, ok := r.Database.GetQuery("DELETE_NODE")
 	if !ok {
 		return errors.New("query DELETE_NODE is not prepare")
 	}
 
-	_, err := stmt.Exec(nodeID)
+	_, err := stmt.Exec(nodeID, deletedAt, deletedBy)
 	if err != nil {
 		return err
 	}
@@ -71,6 +542,63 @@ func (r *DefaultNodeRepository) DeleteNode(nodeID int) error {
 	return nil
 }
 
+// RestoreNode Снимает пометку удаления и пишет версию истории в одной транзакции
+func (r *DefaultNodeRepository) RestoreNode(nodeID int, userID int32, updatedAt int64) error {
+	history := &models.History{Entity: "NODE", EntityID: nodeID, Action: "RESTORE", UserId: userID, CreatedAt: updatedAt}
+
+	return changeWithHistory(r.Database, history, []string{"RESTORE_NODE"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		_, err := tx.Stmt(stmts["RESTORE_NODE"]).Exec(nodeID, updatedAt)
+		return err
+	})
+}
+
+func (r *DefaultNodeRepository) GetDeletedNodes(offset int) ([]models.Node, int, error) {
+	stmt, ok := r.Database.GetQuery("GET_DELETED_NODES")
+	if !ok {
+		return nil, 0, errors.New("query GET_DELETED_NODES is not prepare")
+	}
+
+	rows, err := stmt.Query(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var nodes []models.Node
+	var count int
+
+	for rows.Next() {
+		var node models.Node
+		var nodeTypeKey sql.NullString
+
+		if err = rows.Scan(
+			&node.ID,
+			&node.HouseId,
+			&node.Owner.ID,
+			&node.Name,
+			&node.Zone,
+			&node.IsPassive,
+			&node.Owner.Value,
+			&nodeTypeKey,
+			&node.DeletedAt,
+			&node.DeletedBy,
+			&count,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		if nodeTypeKey.Valid {
+			node.Type = &models.Reference{Key: nodeTypeKey.String}
+		}
+
+		node.IsDelete = true
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes, count, nil
+}
+
 func (r *DefaultNodeRepository) GetNodesByIDs(nodeIDs []int32) ([]models.Node, error) {
 	stmt, ok := r.Database.GetQuery("GET_NODES_BY_IDS")
 	if !ok {
@@ -113,11 +641,9 @@ func (r *DefaultNodeRepository) GetNodesByIDs(nodeIDs []int32) ([]models.Node, e
 	return orderedNodes, nil
 }
 
-func (r *DefaultNodeRepository) EditNode(node *models.Node) error {
-	stmt, ok := r.Database.GetQuery("EDIT_NODE")
-	if !ok {
-		return errors.New("query EDIT_NODE is not prepare")
-	}
+// EditNode Изменяет узел и пишет версию истории в одной транзакции
+func (r *DefaultNodeRepository) EditNode(node *models.Node, userID int32) error {
+	history := &models.History{Entity: "NODE", EntityID: node.ID, Action: "EDIT", UserId: userID, CreatedAt: node.UpdatedAt.Int64}
 
 	var parentID interface{}
 	var typeID interface{}
@@ -130,33 +656,32 @@ func (r *DefaultNodeRepository) EditNode(node *models.Node) error {
 		typeID = node.Type.ID
 	}
 
-	_, err := stmt.Exec(
-		node.ID,
-		parentID,
-		typeID,
-		node.Owner.ID,
-		node.Name,
-		node.Zone,
-		node.Placement,
-		node.Supply,
-		node.Access,
-		node.Description,
-		node.UpdatedAt,
-		node.Address.House.Id,
-		node.IsPassive,
-	)
-	if err != nil {
-		return err
-	}
+	return changeWithHistory(r.Database, history, []string{"EDIT_NODE"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		_, err := tx.Stmt(stmts["EDIT_NODE"]).Exec(
+			node.ID,
+			parentID,
+			typeID,
+			node.Owner.ID,
+			node.Name,
+			node.Zone,
+			node.Placement,
+			node.Supply,
+			node.Access,
+			node.Description,
+			node.UpdatedAt,
+			node.Address.House.Id,
+			node.IsPassive,
+			node.Latitude,
+			node.Longitude,
+		)
 
-	return nil
+		return err
+	})
 }
 
-func (r *DefaultNodeRepository) CreateNode(node *models.Node) error {
-	stmt, ok := r.Database.GetQuery("CREATE_NODE")
-	if !ok {
-		return errors.New("query CREATE_NODE is not prepare")
-	}
+// CreateNode Создает узел и пишет версию истории в одной транзакции
+func (r *DefaultNodeRepository) CreateNode(node *models.Node, userID int32) error {
+	history := &models.History{Entity: "NODE", Action: "CREATE", UserId: userID, CreatedAt: node.CreatedAt}
 
 	var parentID interface{}
 	var typeID interface{}
@@ -169,25 +694,31 @@ func (r *DefaultNodeRepository) CreateNode(node *models.Node) error {
 		typeID = node.Type.ID
 	}
 
-	if err := stmt.QueryRow(
-		parentID,
-		node.HouseId,
-		typeID,
-		node.Owner.ID,
-		node.Name,
-		node.Zone,
-		node.Placement,
-		node.Supply,
-		node.Access,
-		node.Description,
-		node.CreatedAt,
-		node.UpdatedAt,
-		node.IsPassive,
-	).Scan(&node.ID); err != nil {
-		return err
-	}
+	return changeWithHistory(r.Database, history, []string{"CREATE_NODE"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		if err := tx.Stmt(stmts["CREATE_NODE"]).QueryRow(
+			parentID,
+			node.HouseId,
+			typeID,
+			node.Owner.ID,
+			node.Name,
+			node.Zone,
+			node.Placement,
+			node.Supply,
+			node.Access,
+			node.Description,
+			node.CreatedAt,
+			node.UpdatedAt,
+			node.IsPassive,
+			node.Latitude,
+			node.Longitude,
+		).Scan(&node.ID); err != nil {
+			return err
+		}
 
-	return nil
+		history.EntityID = node.ID
+
+		return nil
+	})
 }
 
 func (r *DefaultNodeRepository) GetNode(node *models.Node) error {
@@ -221,6 +752,10 @@ func (r *DefaultNodeRepository) GetNode(node *models.Node) error {
 		&node.UpdatedAt,
 		&node.IsDelete,
 		&node.IsPassive,
+		&node.DeletedAt,
+		&node.DeletedBy,
+		&node.Latitude,
+		&node.Longitude,
 		&typeValue,
 		&node.Owner.Value,
 		&parentName,
@@ -290,5 +825,359 @@ func (r *DefaultNodeRepository) ValidateNode(node models.Node) bool {
 		return false
 	}
 
+	if node.Latitude.Valid != node.Longitude.Valid {
+		return false
+	}
+
+	if node.Latitude.Valid && (node.Latitude.Float64 < -90 || node.Latitude.Float64 > 90 ||
+		node.Longitude.Float64 < -180 || node.Longitude.Float64 > 180) {
+		return false
+	}
+
 	return true
 }
+
+// ValidateNodeTopology Проверяет, что новый родитель не образует цикл и допустим для типа узла,
+// а при смене типа - что новый тип допустим для прямых потомков узла. Возвращает nil, если нарушений нет
+func (r *DefaultNodeRepository) ValidateNodeTopology(node models.Node) (*models.TopologyViolation, error) {
+	if node.IsPassive {
+		return nil, nil
+	}
+
+	violation, err := r.validateNodeChildren(node)
+	if err != nil || violation != nil {
+		return violation, err
+	}
+
+	if node.Parent == nil {
+		return nil, nil
+	}
+
+	violation = &models.TopologyViolation{
+		NodeID:   node.ID,
+		ParentID: node.Parent.ID,
+	}
+
+	if node.ID != 0 && node.Parent.ID == node.ID {
+		violation.Code = "SELF_PARENT"
+		violation.Message = "node cannot be its own parent"
+		violation.Cycle = []int{node.ID, node.ID}
+		return violation, nil
+	}
+
+	if node.ID != 0 {
+		stmt, ok := r.Database.GetQuery("CHECK_NODE_CYCLE")
+		if !ok {
+			return nil, errors.New("query CHECK_NODE_CYCLE is not prepare")
+		}
+
+		var path pq.Int64Array
+
+		err := stmt.QueryRow(node.Parent.ID, node.ID).Scan(&path)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		if err == nil {
+			violation.Code = "CYCLE"
+			violation.Message = "parent node is a descendant of the node"
+			violation.Cycle = []int{node.ID}
+
+			for _, id := range path {
+				violation.Cycle = append(violation.Cycle, int(id))
+			}
+
+			return violation, nil
+		}
+	}
+
+	if node.Type == nil || node.Type.ID == 0 {
+		return nil, nil
+	}
+
+	stmt, ok := r.Database.GetQuery("GET_NODE_TYPE_RELATION")
+	if !ok {
+		return nil, errors.New("query GET_NODE_TYPE_RELATION is not prepare")
+	}
+
+	var (
+		parentTypeKey  sql.NullString
+		isAllowed      bool
+		allowedParents pq.StringArray
+	)
+
+	if err := stmt.QueryRow(node.Parent.ID, node.Type.ID).Scan(
+		&parentTypeKey,
+		&violation.ChildTypeKey,
+		&isAllowed,
+		&allowedParents,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			violation.Code = "PARENT_NOT_FOUND"
+			violation.Message = "parent node not found"
+			return violation, nil
+		}
+
+		return nil, err
+	}
+
+	// Пассивный родитель (муфта, кросс) не имеет типа, поэтому правила типов к нему не применяются
+	if !parentTypeKey.Valid || isAllowed {
+		return nil, nil
+	}
+
+	violation.Code = "TYPE_HIERARCHY"
+	violation.ParentTypeKey = parentTypeKey.String
+	violation.AllowedParents = allowedParents
+	violation.Message = fmt.Sprintf("node of type %s cannot be placed under node of type %s", violation.ChildTypeKey, violation.ParentTypeKey)
+
+	return violation, nil
+}
+
+// validateNodeChildren Проверяет, что новый тип узла допустим для типов его прямых потомков.
+// Проверка выполняется только для существующего узла, тип которого меняется
+func (r *DefaultNodeRepository) validateNodeChildren(node models.Node) (*models.TopologyViolation, error) {
+	if node.ID == 0 || node.Type == nil || node.Type.ID == 0 {
+		return nil, nil
+	}
+
+	stmt, ok := r.Database.GetQuery("GET_NODE_CHILD_TYPE_VIOLATION")
+	if !ok {
+		return nil, errors.New("query GET_NODE_CHILD_TYPE_VIOLATION is not prepare")
+	}
+
+	violation := &models.TopologyViolation{
+		Code:     "CHILD_TYPE_HIERARCHY",
+		ParentID: node.ID,
+	}
+
+	var allowedParents pq.StringArray
+
+	if err := stmt.QueryRow(node.ID, node.Type.ID).Scan(
+		&violation.NodeID,
+		&violation.ChildTypeKey,
+		&violation.ParentTypeKey,
+		&allowedParents,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	violation.AllowedParents = allowedParents
+	violation.Message = fmt.Sprintf("child node of type %s cannot be placed under node of type %s", violation.ChildTypeKey, violation.ParentTypeKey)
+
+	return violation, nil
+}
+
+func (r *DefaultNodeRepository) GetNodeTypeRules() ([]models.NodeTypeRule, error) {
+	stmt, ok := r.Database.GetQuery("GET_NODE_TYPE_RULES")
+	if !ok {
+		return nil, errors.New("query GET_NODE_TYPE_RULES is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.NodeTypeRule
+
+	for rows.Next() {
+		var rule models.NodeTypeRule
+
+		if err = rows.Scan(
+			&rule.ID,
+			&rule.CreatedAt,
+			&rule.ParentType.ID,
+			&rule.ParentType.Key,
+			&rule.ParentType.Value,
+			&rule.ChildType.ID,
+			&rule.ChildType.Key,
+			&rule.ChildType.Value,
+		); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (r *DefaultNodeRepository) CreateNodeTypeRule(rule *models.NodeTypeRule) error {
+	stmt, ok := r.Database.GetQuery("CREATE_NODE_TYPE_RULE")
+	if !ok {
+		return errors.New("query CREATE_NODE_TYPE_RULE is not prepare")
+	}
+
+	if err := stmt.QueryRow(rule.ParentType.ID, rule.ChildType.ID, rule.CreatedAt).Scan(&rule.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *DefaultNodeRepository) DeleteNodeTypeRule(ruleID int) error {
+	stmt, ok := r.Database.GetQuery("DELETE_NODE_TYPE_RULE")
+	if !ok {
+		return errors.New("query DELETE_NODE_TYPE_RULE is not prepare")
+	}
+
+	_, err := stmt.Exec(ruleID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *DefaultNodeRepository) GetInventoryNodes(filter models.InventoryFilter) ([]models.Node, error) {
+	stmt, ok := r.Database.GetQuery("GET_INVENTORY_NODES")
+	if !ok {
+		return nil, errors.New("query GET_INVENTORY_NODES is not prepare")
+	}
+
+	rows, err := stmt.Query(
+		filter.HouseID,
+		filter.OwnerID,
+		filter.Zone,
+		filter.NodeTypeID,
+		filter.IsDelete,
+		filter.IsPassive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []models.Node
+
+	for rows.Next() {
+		var node models.Node
+		var parentID sql.NullInt32
+		var parentName, typeValue sql.NullString
+
+		if err = rows.Scan(
+			&node.ID,
+			&parentID,
+			&parentName,
+			&node.HouseId,
+			&node.Name,
+			&typeValue,
+			&node.Owner.Value,
+			&node.Zone,
+			&node.Placement,
+			&node.Supply,
+			&node.Access,
+			&node.Description,
+			&node.IsPassive,
+			&node.IsDelete,
+			&node.CreatedAt,
+			&node.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if parentID.Valid {
+			node.Parent = &models.Node{ID: int(parentID.Int32), Name: parentName.String}
+		}
+
+		if typeValue.Valid {
+			node.Type = &models.Reference{Value: typeValue.String}
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+func (r *DefaultNodeRepository) GetNodesInBBox(bbox models.BBox) ([]models.Node, error) {
+	stmt, ok := r.Database.GetQuery("GET_NODES_IN_BBOX")
+	if !ok {
+		return nil, errors.New("query GET_NODES_IN_BBOX is not prepare")
+	}
+
+	rows, err := stmt.Query(bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []models.Node
+
+	for rows.Next() {
+		var node models.Node
+		var parentID sql.NullInt32
+		var typeKey, typeValue sql.NullString
+
+		if err = rows.Scan(
+			&node.ID,
+			&parentID,
+			&node.HouseId,
+			&node.Name,
+			&node.Zone,
+			&node.IsPassive,
+			&typeKey,
+			&typeValue,
+			&node.Owner.Value,
+			&node.Latitude,
+			&node.Longitude,
+		); err != nil {
+			return nil, err
+		}
+
+		if parentID.Valid {
+			node.Parent = &models.Node{ID: int(parentID.Int32)}
+		}
+
+		if typeKey.Valid {
+			node.Type = &models.Reference{Key: typeKey.String, Value: typeValue.String}
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// GetNodeLinksInBBox Возвращает связи родитель-потомок, у которых хотя бы один конец попадает в область
+func (r *DefaultNodeRepository) GetNodeLinksInBBox(bbox models.BBox) ([]models.NodeLink, error) {
+	stmt, ok := r.Database.GetQuery("GET_NODE_LINKS_IN_BBOX")
+	if !ok {
+		return nil, errors.New("query GET_NODE_LINKS_IN_BBOX is not prepare")
+	}
+
+	rows, err := stmt.Query(bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []models.NodeLink
+
+	for rows.Next() {
+		var link models.NodeLink
+
+		if err = rows.Scan(
+			&link.Child.ID,
+			&link.Child.Name,
+			&link.Child.Latitude,
+			&link.Child.Longitude,
+			&link.Parent.ID,
+			&link.Parent.Name,
+			&link.Parent.Latitude,
+			&link.Parent.Longitude,
+		); err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
@@ -0,0 +1,395 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+)
+
+type PortRepository interface {
+	GetHardwarePorts(hardwareID int) ([]models.HardwarePort, error)
+	GetPort(port *models.HardwarePort) error
+	CreatePort(port *models.HardwarePort) error
+	EditPort(port *models.HardwarePort) error
+	DeletePort(port models.HardwarePort) error
+	SetPortVlans(portID int, vlans []models.PortVlan, updatedAt int64) error
+	SyncSnmpPorts(hardwareID int, ports []models.HardwarePort, updatedAt int64) error
+	SearchPorts(search string, offset int) ([]models.HardwarePort, int, error)
+	ValidatePort(port models.HardwarePort) bool
+	ValidatePortVlans(vlans []models.PortVlan) bool
+}
+
+type DefaultPortRepository struct {
+	Database Database
+}
+
+// PortLinkStates Допустимые состояния линка порта, совпадают с ifOperStatus из IF-MIB
+var PortLinkStates = map[string]struct{}{
+	"up":             {},
+	"down":           {},
+	"testing":        {},
+	"unknown":        {},
+	"dormant":        {},
+	"notPresent":     {},
+	"lowerLayerDown": {},
+}
+
+func (r *DefaultPortRepository) GetHardwarePorts(hardwareID int) ([]models.HardwarePort, error) {
+	stmt, ok := r.Database.GetQuery("GET_HARDWARE_PORTS")
+	if !ok {
+		return nil, errors.New("query GET_HARDWARE_PORTS is not prepare")
+	}
+
+	rows, err := stmt.Query(hardwareID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ports := make([]models.HardwarePort, 0)
+
+	for rows.Next() {
+		var port models.HardwarePort
+
+		if err = rows.Scan(
+			&port.ID,
+			&port.Hardware.ID,
+			&port.Number,
+			&port.Description,
+			&port.Speed,
+			&port.Mode,
+			&port.UntaggedVlan,
+			&port.LinkState,
+			&port.Source,
+			&port.CreatedAt,
+			&port.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		ports = append(ports, port)
+	}
+
+	vlanStmt, ok := r.Database.GetQuery("GET_HARDWARE_PORT_VLANS")
+	if !ok {
+		return nil, errors.New("query GET_HARDWARE_PORT_VLANS is not prepare")
+	}
+
+	vlanRows, err := vlanStmt.Query(hardwareID)
+	if err != nil {
+		return nil, err
+	}
+	defer vlanRows.Close()
+
+	vlansMap := make(map[int][]models.PortVlan)
+
+	for vlanRows.Next() {
+		var (
+			portID int
+			vlan   models.PortVlan
+		)
+
+		if err = vlanRows.Scan(&portID, &vlan.VlanID, &vlan.Tagged, &vlan.Source, &vlan.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		vlansMap[portID] = append(vlansMap[portID], vlan)
+	}
+
+	for i := range ports {
+		ports[i].Vlans = vlansMap[ports[i].ID]
+
+		if ports[i].Vlans == nil {
+			ports[i].Vlans = make([]models.PortVlan, 0)
+		}
+	}
+
+	return ports, nil
+}
+
+func (r *DefaultPortRepository) GetPort(port *models.HardwarePort) error {
+	stmt, ok := r.Database.GetQuery("GET_PORT")
+	if !ok {
+		return errors.New("query GET_PORT is not prepare")
+	}
+
+	return stmt.QueryRow(port.ID).Scan(
+		&port.ID,
+		&port.Hardware.ID,
+		&port.Number,
+		&port.Description,
+		&port.Speed,
+		&port.Mode,
+		&port.UntaggedVlan,
+		&port.LinkState,
+		&port.Source,
+		&port.CreatedAt,
+		&port.UpdatedAt,
+	)
+}
+
+func (r *DefaultPortRepository) CreatePort(port *models.HardwarePort) error {
+	stmt, ok := r.Database.GetQuery("CREATE_PORT")
+	if !ok {
+		return errors.New("query CREATE_PORT is not prepare")
+	}
+
+	port.Source = "MANUAL"
+
+	return stmt.QueryRow(
+		port.Hardware.ID,
+		port.Number,
+		port.Description,
+		port.Speed,
+		port.Mode,
+		port.UntaggedVlan,
+		port.LinkState,
+		port.CreatedAt,
+	).Scan(&port.ID)
+}
+
+func (r *DefaultPortRepository) EditPort(port *models.HardwarePort) error {
+	stmt, ok := r.Database.GetQuery("EDIT_PORT")
+	if !ok {
+		return errors.New("query EDIT_PORT is not prepare")
+	}
+
+	res, err := stmt.Exec(
+		port.ID,
+		port.Hardware.ID,
+		port.Number,
+		port.Description,
+		port.Speed,
+		port.Mode,
+		port.UntaggedVlan,
+		port.LinkState,
+		port.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if count, e := res.RowsAffected(); e == nil && count == 0 {
+		return sql.ErrNoRows
+	}
+
+	port.Source = "MANUAL"
+
+	return nil
+}
+
+func (r *DefaultPortRepository) DeletePort(port models.HardwarePort) error {
+	stmt, ok := r.Database.GetQuery("DELETE_PORT")
+	if !ok {
+		return errors.New("query DELETE_PORT is not prepare")
+	}
+
+	res, err := stmt.Exec(port.ID, port.Hardware.ID)
+	if err != nil {
+		return err
+	}
+
+	if count, e := res.RowsAffected(); e == nil && count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SetPortVlans Заменяет членство порта во VLAN введенным вручную
+func (r *DefaultPortRepository) SetPortVlans(portID int, vlans []models.PortVlan, updatedAt int64) error {
+	keys := []string{"DELETE_PORT_VLANS", "CREATE_PORT_VLAN"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Stmt(stmts["DELETE_PORT_VLANS"]).Exec(portID); err != nil {
+		return err
+	}
+
+	createStmt := tx.Stmt(stmts["CREATE_PORT_VLAN"])
+
+	for _, vlan := range vlans {
+		if _, err = createStmt.Exec(portID, vlan.VlanID, vlan.Tagged, "MANUAL", updatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SyncSnmpPorts Записывает порты, собранные по SNMP, в одной транзакции. Пустые значения из опроса
+// не затирают заполненные вручную поля, описание порта, созданного вручную, заполняется из опроса
+// только если оно пустое, источник порта не меняется. Порты, которых нет в опросе, остаются без изменений.
+// Членство во VLAN из опроса заменяет прошлое SNMP членство, если Vlans порта не nil,
+// введенное вручную членство имеет приоритет
+func (r *DefaultPortRepository) SyncSnmpPorts(hardwareID int, ports []models.HardwarePort, updatedAt int64) error {
+	keys := []string{"UPSERT_SNMP_PORT", "DELETE_SNMP_PORT_VLANS", "CREATE_PORT_VLAN"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsertStmt := tx.Stmt(stmts["UPSERT_SNMP_PORT"])
+	deleteVlansStmt := tx.Stmt(stmts["DELETE_SNMP_PORT_VLANS"])
+	createVlanStmt := tx.Stmt(stmts["CREATE_PORT_VLAN"])
+
+	for _, port := range ports {
+		var portID int
+
+		if err = upsertStmt.QueryRow(
+			hardwareID,
+			port.Number,
+			port.Description,
+			port.Speed,
+			port.Mode,
+			port.UntaggedVlan,
+			port.LinkState,
+			updatedAt,
+			port.IfName,
+			port.IfDescr,
+		).Scan(&portID); err != nil {
+			return err
+		}
+
+		if port.Vlans == nil {
+			continue
+		}
+
+		if _, err = deleteVlansStmt.Exec(portID); err != nil {
+			return err
+		}
+
+		for _, vlan := range port.Vlans {
+			if _, err = createVlanStmt.Exec(portID, vlan.VlanID, vlan.Tagged, "SNMP", updatedAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SearchPorts Ищет порты по описанию, например по номеру квартиры, вместе с оборудованием и узлом
+func (r *DefaultPortRepository) SearchPorts(search string, offset int) ([]models.HardwarePort, int, error) {
+	stmt, ok := r.Database.GetQuery("SEARCH_PORTS")
+	if !ok {
+		return nil, 0, errors.New("query SEARCH_PORTS is not prepare")
+	}
+
+	rows, err := stmt.Query(search, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	ports := make([]models.HardwarePort, 0)
+	var count int
+
+	for rows.Next() {
+		var (
+			port       models.HardwarePort
+			switchName sql.NullString
+		)
+
+		if err = rows.Scan(
+			&port.ID,
+			&port.Hardware.ID,
+			&port.Number,
+			&port.Description,
+			&port.Speed,
+			&port.Mode,
+			&port.UntaggedVlan,
+			&port.LinkState,
+			&port.Source,
+			&port.CreatedAt,
+			&port.UpdatedAt,
+			&port.Hardware.Type.Value,
+			&switchName,
+			&port.Hardware.IpAddress,
+			&port.Hardware.Node.ID,
+			&port.Hardware.Node.Name,
+			&port.Hardware.Node.HouseId,
+			&count,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		port.Hardware.Switch.Name = switchName.String
+
+		ports = append(ports, port)
+	}
+
+	return ports, count, nil
+}
+
+func (r *DefaultPortRepository) ValidatePort(port models.HardwarePort) bool {
+	if port.Hardware.ID == 0 || port.Number <= 0 {
+		return false
+	}
+
+	if port.Speed.Valid && port.Speed.Int64 < 0 {
+		return false
+	}
+
+	if port.UntaggedVlan.Valid && (port.UntaggedVlan.Int32 < 1 || port.UntaggedVlan.Int32 > 4094) {
+		return false
+	}
+
+	if port.LinkState.Valid {
+		if _, ok := PortLinkStates[port.LinkState.String]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidatePortVlans Проверяет номера VLAN порта: без повторов и не более одного нетегированного
+func (r *DefaultPortRepository) ValidatePortVlans(vlans []models.PortVlan) bool {
+	vlanSet := make(map[int]struct{})
+	untagged := 0
+
+	for _, vlan := range vlans {
+		if vlan.VlanID < 1 || vlan.VlanID > 4094 {
+			return false
+		}
+
+		if _, ok := vlanSet[vlan.VlanID]; ok {
+			return false
+		}
+
+		vlanSet[vlan.VlanID] = struct{}{}
+
+		if !vlan.Tagged {
+			untagged++
+		}
+	}
+
+	return untagged <= 1
+}
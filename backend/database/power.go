@@ -0,0 +1,126 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+)
+
+type PowerRepository interface {
+	CreateBatteryReading(reading models.BatteryReading) error
+	GetBatteryReadings(hardwareID int, from int64, to int64) ([]models.BatteryReading, error)
+	GetPowerAlerts(threshold int) ([]models.PowerAlert, error)
+}
+
+type DefaultPowerRepository struct {
+	Database Database
+}
+
+func (r *DefaultPowerRepository) CreateBatteryReading(reading models.BatteryReading) error {
+	stmt, ok := r.Database.GetQuery("CREATE_BATTERY_READING")
+	if !ok {
+		return errors.New("query CREATE_BATTERY_READING is not prepare")
+	}
+
+	_, err := stmt.Exec(reading.HardwareID, reading.Status, reading.Charge, reading.PolledAt)
+
+	return err
+}
+
+// GetBatteryReadings Возвращает показания батареи ИБП за период в порядке времени опроса
+func (r *DefaultPowerRepository) GetBatteryReadings(hardwareID int, from int64, to int64) ([]models.BatteryReading, error) {
+	stmt, ok := r.Database.GetQuery("GET_BATTERY_READINGS")
+	if !ok {
+		return nil, errors.New("query GET_BATTERY_READINGS is not prepare")
+	}
+
+	rows, err := stmt.Query(hardwareID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	readings := make([]models.BatteryReading, 0)
+
+	for rows.Next() {
+		var reading models.BatteryReading
+
+		if err = rows.Scan(
+			&reading.ID,
+			&reading.HardwareID,
+			&reading.Status,
+			&reading.Charge,
+			&reading.PolledAt,
+		); err != nil {
+			return nil, err
+		}
+
+		readings = append(readings, reading)
+	}
+
+	return readings, nil
+}
+
+// GetPowerAlerts Возвращает узлы, у ИБП которых по последнему опросу батарея разряжена, заряд ниже
+// порога threshold или упал с прошлого опроса, то есть узел работает от батареи
+func (r *DefaultPowerRepository) GetPowerAlerts(threshold int) ([]models.PowerAlert, error) {
+	stmt, ok := r.Database.GetQuery("GET_POWER_ALERTS")
+	if !ok {
+		return nil, errors.New("query GET_POWER_ALERTS is not prepare")
+	}
+
+	rows, err := stmt.Query(threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]models.PowerAlert, 0)
+
+	for rows.Next() {
+		var (
+			alert      models.PowerAlert
+			switchName sql.NullString
+		)
+
+		if err = rows.Scan(
+			&alert.Hardware.ID,
+			&alert.Hardware.IpAddress,
+			&alert.Hardware.Type.Value,
+			&switchName,
+			&alert.Node.ID,
+			&alert.Node.Name,
+			&alert.Node.HouseId,
+			&alert.Node.Zone,
+			&alert.Status,
+			&alert.Charge,
+			&alert.PreviousCharge,
+			&alert.PolledAt,
+		); err != nil {
+			return nil, err
+		}
+
+		alert.Hardware.Switch.Name = switchName.String
+		alert.StatusName = models.BatteryStatuses[alert.Status.Int32]
+		alert.Reasons = make([]string, 0)
+
+		switch alert.Status.Int32 {
+		case models.BatteryStatusLow:
+			alert.Reasons = append(alert.Reasons, "BATTERY_LOW")
+		case models.BatteryStatusDepleted:
+			alert.Reasons = append(alert.Reasons, "BATTERY_DEPLETED")
+		}
+
+		if alert.Charge.Valid && alert.Charge.Int32 < int32(threshold) {
+			alert.Reasons = append(alert.Reasons, "LOW_CHARGE")
+		}
+
+		if alert.Charge.Valid && alert.PreviousCharge.Valid && alert.Charge.Int32 < alert.PreviousCharge.Int32 {
+			alert.Reasons = append(alert.Reasons, "DISCHARGING")
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,353 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+)
+
+type RackRepository interface {
+	GetRacks(nodeID int) ([]models.Rack, error)
+	GetRack(rackID int) (*models.Rack, error)
+	CreateRack(rack *models.Rack) error
+	EditRack(rack *models.Rack) (*models.RackPlacement, error)
+	DeleteRack(rackID int) error
+	SetHardwarePlacement(placement *models.RackPlacement, nodeID int) (*models.RackPlacement, error)
+	DeleteHardwarePlacement(hardwareID int) error
+	ValidateRack(rack models.Rack) bool
+	ValidateRackPlacement(placement models.RackPlacement) bool
+}
+
+type DefaultRackRepository struct {
+	Database Database
+}
+
+var (
+	// ErrRackNotInNode Стойка находится в другом узле, чем оборудование
+	ErrRackNotInNode = errors.New("rack is in another node")
+	// ErrRackUnitsExceeded Оборудование выходит за высоту стойки
+	ErrRackUnitsExceeded = errors.New("placement exceeds rack units")
+)
+
+// GetRacks Возвращает стойки узла (при nodeID = 0 - все) с установленным в них оборудованием
+func (r *DefaultRackRepository) GetRacks(nodeID int) ([]models.Rack, error) {
+	return r.getRacks(nodeID, 0)
+}
+
+// GetRack Возвращает стойку с установленным оборудованием или sql.ErrNoRows
+func (r *DefaultRackRepository) GetRack(rackID int) (*models.Rack, error) {
+	racks, err := r.getRacks(0, rackID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(racks) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &racks[0], nil
+}
+
+func (r *DefaultRackRepository) getRacks(nodeID int, rackID int) ([]models.Rack, error) {
+	stmt, ok := r.Database.GetQuery("GET_RACKS")
+	if !ok {
+		return nil, errors.New("query GET_RACKS is not prepare")
+	}
+
+	rows, err := stmt.Query(nodeID, rackID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	racks := make([]models.Rack, 0)
+	rackIndex := make(map[int]int)
+
+	for rows.Next() {
+		var rack models.Rack
+
+		if err = rows.Scan(
+			&rack.ID,
+			&rack.Node.ID,
+			&rack.Node.Name,
+			&rack.Node.HouseId,
+			&rack.Name,
+			&rack.Kind,
+			&rack.Units,
+			&rack.Description,
+			&rack.CreatedAt,
+			&rack.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		rack.Placements = make([]models.RackPlacement, 0)
+		rackIndex[rack.ID] = len(racks)
+		racks = append(racks, rack)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stmt, ok = r.Database.GetQuery("GET_RACK_PLACEMENTS")
+	if !ok {
+		return nil, errors.New("query GET_RACK_PLACEMENTS is not prepare")
+	}
+
+	placements, err := getRackPlacements(stmt, nodeID, rackID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, placement := range placements {
+		if i, ok := rackIndex[placement.RackID]; ok {
+			racks[i].Placements = append(racks[i].Placements, placement)
+		}
+	}
+
+	return racks, nil
+}
+
+func getRackPlacements(stmt *sql.Stmt, nodeID int, rackID int) ([]models.RackPlacement, error) {
+	rows, err := stmt.Query(nodeID, rackID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	placements := make([]models.RackPlacement, 0)
+
+	for rows.Next() {
+		var (
+			placement  models.RackPlacement
+			switchName sql.NullString
+		)
+
+		if err = rows.Scan(
+			&placement.Hardware.ID,
+			&placement.Hardware.Node.ID,
+			&placement.Hardware.Type.Key,
+			&placement.Hardware.Type.Value,
+			&switchName,
+			&placement.Hardware.IpAddress,
+			&placement.Hardware.Description,
+			&placement.RackID,
+			&placement.Unit,
+			&placement.Height,
+			&placement.Side,
+			&placement.CreatedAt,
+			&placement.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		placement.Hardware.Switch.Name = switchName.String
+
+		placements = append(placements, placement)
+	}
+
+	return placements, nil
+}
+
+func (r *DefaultRackRepository) CreateRack(rack *models.Rack) error {
+	stmt, ok := r.Database.GetQuery("CREATE_RACK")
+	if !ok {
+		return errors.New("query CREATE_RACK is not prepare")
+	}
+
+	return stmt.QueryRow(
+		rack.Node.ID,
+		rack.Name,
+		rack.Kind,
+		rack.Units,
+		rack.Description,
+		rack.CreatedAt,
+	).Scan(&rack.ID)
+}
+
+// EditRack Изменяет стойку. Узел стойки не меняется, он возвращается в rack.Node.ID, установленное
+// оборудование возвращается в rack.Placements. Стойка блокируется на время проверки, как при установке
+// оборудования, поэтому параллельная установка не займет юниты, которые убираются. Если выше новой высоты
+// есть оборудование, возвращается самая высокая установка и ничего не сохраняется.
+// Если стойки нет, возвращается sql.ErrNoRows
+func (r *DefaultRackRepository) EditRack(rack *models.Rack) (*models.RackPlacement, error) {
+	keys := []string{"LOCK_RACK", "GET_RACK_PLACEMENTS", "EDIT_RACK"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return nil, errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var nodeID, units int
+
+	if err = tx.Stmt(stmts["LOCK_RACK"]).QueryRow(rack.ID).Scan(&nodeID, &units); err != nil {
+		return nil, err
+	}
+
+	placements, err := getRackPlacements(tx.Stmt(stmts["GET_RACK_PLACEMENTS"]), 0, rack.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflict *models.RackPlacement
+
+	for i := range placements {
+		if top := placementTop(placements[i]); top > rack.Units && (conflict == nil || top > placementTop(*conflict)) {
+			conflict = &placements[i]
+		}
+	}
+
+	if conflict != nil {
+		return conflict, nil
+	}
+
+	if err = tx.Stmt(stmts["EDIT_RACK"]).QueryRow(
+		rack.ID,
+		rack.Name,
+		rack.Kind,
+		rack.Units,
+		rack.Description,
+		rack.UpdatedAt,
+	).Scan(&rack.Node.ID, &rack.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	rack.Placements = placements
+
+	return nil, tx.Commit()
+}
+
+func (r *DefaultRackRepository) DeleteRack(rackID int) error {
+	stmt, ok := r.Database.GetQuery("DELETE_RACK")
+	if !ok {
+		return errors.New("query DELETE_RACK is not prepare")
+	}
+
+	res, err := stmt.Exec(rackID)
+	if err != nil {
+		return err
+	}
+
+	if affected, e := res.RowsAffected(); e == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SetHardwarePlacement Устанавливает оборудование узла nodeID в стойку или переносит его. Стойка блокируется
+// на время проверки, чтобы параллельные установки не заняли одни и те же юниты. Если юниты с этой стороны
+// уже заняты, возвращается мешающая установка и ничего не сохраняется
+func (r *DefaultRackRepository) SetHardwarePlacement(placement *models.RackPlacement, nodeID int) (*models.RackPlacement, error) {
+	keys := []string{"LOCK_RACK", "GET_RACK_PLACEMENTS", "SET_HARDWARE_PLACEMENT"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return nil, errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var rackNodeID, units int
+
+	if err = tx.Stmt(stmts["LOCK_RACK"]).QueryRow(placement.RackID).Scan(&rackNodeID, &units); err != nil {
+		return nil, err
+	}
+
+	if rackNodeID != nodeID {
+		return nil, ErrRackNotInNode
+	}
+
+	if placementTop(*placement) > units {
+		return nil, ErrRackUnitsExceeded
+	}
+
+	placements, err := getRackPlacements(tx.Stmt(stmts["GET_RACK_PLACEMENTS"]), 0, placement.RackID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range placements {
+		if placements[i].Hardware.ID != placement.Hardware.ID && placementsOverlap(*placement, placements[i]) {
+			return &placements[i], nil
+		}
+	}
+
+	if err = tx.Stmt(stmts["SET_HARDWARE_PLACEMENT"]).QueryRow(
+		placement.Hardware.ID,
+		placement.RackID,
+		placement.Unit,
+		placement.Height,
+		placement.Side,
+		placement.CreatedAt,
+	).Scan(&placement.CreatedAt, &placement.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return nil, tx.Commit()
+}
+
+func (r *DefaultRackRepository) DeleteHardwarePlacement(hardwareID int) error {
+	stmt, ok := r.Database.GetQuery("DELETE_HARDWARE_PLACEMENT")
+	if !ok {
+		return errors.New("query DELETE_HARDWARE_PLACEMENT is not prepare")
+	}
+
+	res, err := stmt.Exec(hardwareID)
+	if err != nil {
+		return err
+	}
+
+	if affected, e := res.RowsAffected(); e == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *DefaultRackRepository) ValidateRack(rack models.Rack) bool {
+	if _, ok := models.RackKinds[rack.Kind]; !ok {
+		return false
+	}
+
+	return rack.Node.ID != 0 && rack.Name != "" && len(rack.Name) <= 64 && rack.Units > 0 && rack.Units <= models.MaxRackUnits
+}
+
+func (r *DefaultRackRepository) ValidateRackPlacement(placement models.RackPlacement) bool {
+	if _, ok := models.RackSides[placement.Side]; !ok {
+		return false
+	}
+
+	return placement.RackID != 0 && placement.Unit > 0 && placement.Height > 0 && placementTop(placement) <= models.MaxRackUnits
+}
+
+// placementTop Верхний юнит, занятый оборудованием
+func placementTop(placement models.RackPlacement) int {
+	return placement.Unit + placement.Height - 1
+}
+
+// placementsOverlap Проверяет, заняты ли общие юниты с одной стороны стойки
+func placementsOverlap(a models.RackPlacement, b models.RackPlacement) bool {
+	return a.Side == b.Side && a.Unit <= placementTop(b) && b.Unit <= placementTop(a)
+}
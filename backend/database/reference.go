@@ -2,13 +2,15 @@ package database
 
 import (
 	"backend/models"
+	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 )
 
 type ReferenceRepository interface {
-	EditReferenceRecord(referenceRecord *models.Reference, reference string) error
-	CreateReferenceRecord(referenceRecord *models.Reference, reference string) error
+	EditReferenceRecord(referenceRecord *models.Reference, reference string, userID int32) error
+	CreateReferenceRecord(referenceRecord *models.Reference, reference string, userID int32) error
 	GetReferenceRecords(reference string) ([]models.Reference, error)
 }
 
@@ -16,12 +18,8 @@ type DefaultReferenceRepository struct {
 	Database Database
 }
 
-func (r *DefaultReferenceRepository) EditReferenceRecord(referenceRecord *models.Reference, reference string) error {
-	stmt, ok := r.Database.GetQuery("EDIT_" + reference)
-	if !ok {
-		return errors.New("query EDIT_" + reference + " is not prepare")
-	}
-
+// EditReferenceRecord Изменяет запись справочника и пишет версию истории в одной транзакции
+func (r *DefaultReferenceRepository) EditReferenceRecord(referenceRecord *models.Reference, reference string, userID int32) error {
 	var params []interface{}
 
 	switch reference {
@@ -33,20 +31,16 @@ func (r *DefaultReferenceRepository) EditReferenceRecord(referenceRecord *models
 		return fmt.Errorf("reference is unsupported (%s)", reference)
 	}
 
-	_, err := stmt.Exec(params...)
-	if err != nil {
-		return err
-	}
+	history := &models.History{Entity: reference, EntityID: referenceRecord.ID, Action: "EDIT", UserId: userID, CreatedAt: time.Now().Unix()}
 
-	return nil
+	return changeWithHistory(r.Database, history, []string{"EDIT_" + reference}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		_, err := tx.Stmt(stmts["EDIT_"+reference]).Exec(params...)
+		return err
+	})
 }
 
-func (r *DefaultReferenceRepository) CreateReferenceRecord(referenceRecord *models.Reference, reference string) error {
-	stmt, ok := r.Database.GetQuery("CREATE_" + reference)
-	if !ok {
-		return errors.New("query CREATE_" + reference + " is not prepare")
-	}
-
+// CreateReferenceRecord Создает запись справочника и пишет версию истории в одной транзакции
+func (r *DefaultReferenceRepository) CreateReferenceRecord(referenceRecord *models.Reference, reference string, userID int32) error {
 	var params []interface{}
 
 	switch reference {
@@ -58,11 +52,17 @@ func (r *DefaultReferenceRepository) CreateReferenceRecord(referenceRecord *mode
 		return fmt.Errorf("reference is unsupported (%s)", reference)
 	}
 
-	if err := stmt.QueryRow(params...).Scan(&referenceRecord.ID); err != nil {
-		return err
-	}
+	history := &models.History{Entity: reference, Action: "CREATE", UserId: userID, CreatedAt: referenceRecord.CreatedAt}
+
+	return changeWithHistory(r.Database, history, []string{"CREATE_" + reference}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		if err := tx.Stmt(stmts["CREATE_"+reference]).QueryRow(params...).Scan(&referenceRecord.ID); err != nil {
+			return err
+		}
+
+		history.EntityID = referenceRecord.ID
 
-	return nil
+		return nil
+	})
 }
 
 func (r *DefaultReferenceRepository) GetReferenceRecords(reference string) ([]models.Reference, error) {
@@ -2,30 +2,29 @@ package database
 
 import (
 	"backend/models"
+	"database/sql"
 	"errors"
+	"time"
 )
 
 type ReportRepository interface {
 	GetReportData() ([]models.Report, error)
-	EditReportData(reportData *models.Report) error
+	EditReportData(reportData *models.Report, userID int32) error
 }
 
 type DefaultReportRepository struct {
 	Database Database
 }
 
-func (r *DefaultReportRepository) EditReportData(reportData *models.Report) error {
-	stmt, ok := r.Database.GetQuery("EDIT_REPORT_DATA")
-	if !ok {
-		return errors.New("query EDIT_REPORT_DATA is not prepare")
-	}
+// EditReportData Изменяет данные отчета по ключу и пишет версию истории в одной транзакции.
+// ID записи для истории должен быть заполнен вызывающим
+func (r *DefaultReportRepository) EditReportData(reportData *models.Report, userID int32) error {
+	history := &models.History{Entity: "REPORT_DATA", EntityID: reportData.ID, Action: "EDIT", UserId: userID, CreatedAt: time.Now().Unix()}
 
-	_, err := stmt.Exec(reportData.Key, reportData.Value, reportData.Description)
-	if err != nil {
+	return changeWithHistory(r.Database, history, []string{"EDIT_REPORT_DATA"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		_, err := tx.Stmt(stmts["EDIT_REPORT_DATA"]).Exec(reportData.Key, reportData.Value, reportData.Description)
 		return err
-	}
-
-	return nil
+	})
 }
 
 func (r *DefaultReportRepository) GetReportData() ([]models.Report, error) {
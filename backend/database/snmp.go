@@ -0,0 +1,255 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type SnmpRepository interface {
+	GetSnmpTargets(hardwareID int) ([]models.Hardware, error)
+	SaveSnmpPoll(poll *models.SnmpPoll) error
+	GetSnmpPoll(hardwareID int) (*models.SnmpPoll, error)
+	GetSnmpReadings(hardwareID int, metric string) ([]models.SnmpReading, error)
+	SetHardwareFirmware(hardwareID int, firmware string, polledAt int64) error
+	SetHardwareSerial(hd models.Hardware, serial string, polledAt int64) error
+}
+
+type DefaultSnmpRepository struct {
+	Database Database
+}
+
+// GetSnmpTargets Возвращает оборудование с IP адресом и моделью коммутатора вместе с OID шаблона модели.
+// При hardwareID = 0 возвращается все такое оборудование
+func (r *DefaultSnmpRepository) GetSnmpTargets(hardwareID int) ([]models.Hardware, error) {
+	stmt, ok := r.Database.GetQuery("GET_SNMP_TARGETS")
+	if !ok {
+		return nil, errors.New("query GET_SNMP_TARGETS is not prepare")
+	}
+
+	rows, err := stmt.Query(hardwareID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hardware []models.Hardware
+
+	for rows.Next() {
+		var hd models.Hardware
+
+		if err = rows.Scan(
+			&hd.ID,
+			&hd.Node.ID,
+			&hd.Node.HouseId,
+			&hd.IpAddress,
+			&hd.Switch.ID,
+			&hd.Switch.Name,
+			&hd.Switch.CommunityRead,
+			&hd.Switch.FirmwareOID,
+			&hd.Switch.SystemNameOID,
+			&hd.Switch.SerialNumberOID,
+			&hd.Switch.UptimeOID,
+			&hd.Switch.BatteryStatusOID,
+			&hd.Switch.BatteryChargeOID,
+			&hd.Switch.PortDescOID,
+			&hd.Switch.VlanOID,
+			&hd.Switch.PortUntaggedOID,
+			&hd.Switch.SpeedOID,
+			&hd.Switch.PortModeOID,
+			&hd.Switch.MacOID,
+			&hd.Switch.PortAmount,
+			&hd.Switch.SaveConfigOID,
+			&hd.Switch.CommunityWrite,
+			&hd.Switch.LldpChassisIdOID,
+			&hd.Switch.LldpSysNameOID,
+			&hd.Switch.LldpPortIdOID,
+		); err != nil {
+			return nil, err
+		}
+
+		hardware = append(hardware, hd)
+	}
+
+	return hardware, nil
+}
+
+// SaveSnmpPoll Сохраняет результат опроса в одной транзакции. Показания каждой успешно опрошенной метрики
+// полностью заменяются новыми, показания неопрошенных метрик остаются от прошлого опроса
+func (r *DefaultSnmpRepository) SaveSnmpPoll(poll *models.SnmpPoll) error {
+	keys := []string{"UPSERT_SNMP_POLL", "DELETE_SNMP_READINGS", "CREATE_SNMP_READING"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Stmt(stmts["UPSERT_SNMP_POLL"]).Exec(poll.HardwareID, poll.PolledAt, poll.SuccessAt, poll.Error); err != nil {
+		return err
+	}
+
+	deleteStmt := tx.Stmt(stmts["DELETE_SNMP_READINGS"])
+	createStmt := tx.Stmt(stmts["CREATE_SNMP_READING"])
+
+	for _, metric := range poll.Metrics {
+		if _, err = deleteStmt.Exec(poll.HardwareID, metric); err != nil {
+			return err
+		}
+	}
+
+	for _, reading := range poll.Readings {
+		if _, err = createStmt.Exec(reading.HardwareID, reading.Metric, reading.OID, reading.Value, reading.PolledAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *DefaultSnmpRepository) GetSnmpPoll(hardwareID int) (*models.SnmpPoll, error) {
+	stmt, ok := r.Database.GetQuery("GET_SNMP_POLL")
+	if !ok {
+		return nil, errors.New("query GET_SNMP_POLL is not prepare")
+	}
+
+	var poll models.SnmpPoll
+
+	if err := stmt.QueryRow(hardwareID).Scan(
+		&poll.HardwareID,
+		&poll.PolledAt,
+		&poll.SuccessAt,
+		&poll.Error,
+	); err != nil {
+		return nil, err
+	}
+
+	return &poll, nil
+}
+
+func (r *DefaultSnmpRepository) GetSnmpReadings(hardwareID int, metric string) ([]models.SnmpReading, error) {
+	stmt, ok := r.Database.GetQuery("GET_SNMP_READINGS")
+	if !ok {
+		return nil, errors.New("query GET_SNMP_READINGS is not prepare")
+	}
+
+	rows, err := stmt.Query(hardwareID, metric)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var readings []models.SnmpReading
+
+	for rows.Next() {
+		var reading models.SnmpReading
+
+		if err = rows.Scan(
+			&reading.HardwareID,
+			&reading.Metric,
+			&reading.OID,
+			&reading.Value,
+			&reading.PolledAt,
+		); err != nil {
+			return nil, err
+		}
+
+		readings = append(readings, reading)
+	}
+
+	return readings, nil
+}
+
+// SetHardwareFirmware Сохраняет версию прошивки, считанную с оборудования по FirmwareOID
+func (r *DefaultSnmpRepository) SetHardwareFirmware(hardwareID int, firmware string, polledAt int64) error {
+	stmt, ok := r.Database.GetQuery("SET_HARDWARE_FIRMWARE")
+	if !ok {
+		return errors.New("query SET_HARDWARE_FIRMWARE is not prepare")
+	}
+
+	_, err := stmt.Exec(hardwareID, firmware, polledAt)
+
+	return err
+}
+
+// SetHardwareSerial Заполняет серийный номер оборудования из SNMP, если он еще не указан вручную.
+// Изменение пишется версией истории и событием в одной транзакции, пользователь SNMP опроса - 0
+func (r *DefaultSnmpRepository) SetHardwareSerial(hd models.Hardware, serial string, polledAt int64) error {
+	keys := []string{"SET_HARDWARE_SERIAL", "LOCK_ENTITY_HISTORY", "GET_ENTITY_SNAPSHOT", "CREATE_HISTORY", "CREATE_EVENT"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = lockEntityHistory(tx.Stmt(stmts["LOCK_ENTITY_HISTORY"]), "HARDWARE", hd.ID); err != nil {
+		return err
+	}
+
+	snapshotStmt := tx.Stmt(stmts["GET_ENTITY_SNAPSHOT"])
+
+	before, err := getEntitySnapshot(snapshotStmt, "HARDWARE", hd.ID)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Stmt(stmts["SET_HARDWARE_SERIAL"]).Exec(hd.ID, serial, polledAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return nil
+	}
+
+	if err = createHistory(snapshotStmt, tx.Stmt(stmts["CREATE_HISTORY"]), &models.History{
+		Entity:    "HARDWARE",
+		EntityID:  hd.ID,
+		Action:    "EDIT",
+		Before:    before,
+		UserId:    0,
+		CreatedAt: polledAt,
+	}); err != nil {
+		return err
+	}
+
+	if err = createEvent(tx.Stmt(stmts["CREATE_EVENT"]), models.Event{
+		HouseId:     hd.Node.HouseId,
+		Node:        &models.Node{ID: hd.Node.ID},
+		Hardware:    &models.Hardware{ID: hd.ID},
+		UserId:      0,
+		Description: fmt.Sprintf("Заполнение серийного номера по SNMP: %s", serial),
+		CreatedAt:   polledAt,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -4,12 +4,13 @@ import (
 	"backend/models"
 	"database/sql"
 	"errors"
+	"time"
 )
 
 type SwitchRepository interface {
 	GetSwitches() ([]models.Switch, error)
-	EditSwitch(_switch *models.Switch) error
-	CreateSwitch(_switch *models.Switch) error
+	EditSwitch(_switch *models.Switch, userID int32) error
+	CreateSwitch(_switch *models.Switch, userID int32) error
 }
 
 type DefaultSwitchRepository struct {
@@ -58,6 +59,10 @@ func (r *DefaultSwitchRepository) GetSwitches() ([]models.Switch, error) {
 			&_switch.UptimeOID,
 			&_switch.CreatedAt,
 			&_switch.MacOID,
+			&_switch.TargetFirmware,
+			&_switch.LldpChassisIdOID,
+			&_switch.LldpSysNameOID,
+			&_switch.LldpPortIdOID,
 			&operationModeKey,
 			&operationModeValue,
 		); err != nil {
@@ -78,11 +83,9 @@ func (r *DefaultSwitchRepository) GetSwitches() ([]models.Switch, error) {
 	return switches, nil
 }
 
-func (r *DefaultSwitchRepository) EditSwitch(_switch *models.Switch) error {
-	stmt, ok := r.Database.GetQuery("EDIT_SWITCH")
-	if !ok {
-		return errors.New("query EDIT_SWITCH is not prepare")
-	}
+// EditSwitch Изменяет модель коммутатора и пишет версию истории в одной транзакции
+func (r *DefaultSwitchRepository) EditSwitch(_switch *models.Switch, userID int32) error {
+	history := &models.History{Entity: "SWITCH", EntityID: _switch.ID, Action: "EDIT", UserId: userID, CreatedAt: time.Now().Unix()}
 
 	var operationModeID interface{}
 
@@ -90,39 +93,40 @@ func (r *DefaultSwitchRepository) EditSwitch(_switch *models.Switch) error {
 		operationModeID = _switch.OperationMode.ID
 	}
 
-	_, err := stmt.Exec(
-		_switch.ID,
-		_switch.Name,
-		operationModeID,
-		_switch.CommunityRead,
-		_switch.CommunityWrite,
-		_switch.PortAmount,
-		_switch.FirmwareOID,
-		_switch.SystemNameOID,
-		_switch.SerialNumberOID,
-		_switch.SaveConfigOID,
-		_switch.PortDescOID,
-		_switch.VlanOID,
-		_switch.PortUntaggedOID,
-		_switch.SpeedOID,
-		_switch.BatteryStatusOID,
-		_switch.BatteryChargeOID,
-		_switch.PortModeOID,
-		_switch.UptimeOID,
-		_switch.MacOID,
-	)
-	if err != nil {
-		return err
-	}
+	return changeWithHistory(r.Database, history, []string{"EDIT_SWITCH"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		_, err := tx.Stmt(stmts["EDIT_SWITCH"]).Exec(
+			_switch.ID,
+			_switch.Name,
+			operationModeID,
+			_switch.CommunityRead,
+			_switch.CommunityWrite,
+			_switch.PortAmount,
+			_switch.FirmwareOID,
+			_switch.SystemNameOID,
+			_switch.SerialNumberOID,
+			_switch.SaveConfigOID,
+			_switch.PortDescOID,
+			_switch.VlanOID,
+			_switch.PortUntaggedOID,
+			_switch.SpeedOID,
+			_switch.BatteryStatusOID,
+			_switch.BatteryChargeOID,
+			_switch.PortModeOID,
+			_switch.UptimeOID,
+			_switch.MacOID,
+			_switch.TargetFirmware,
+			_switch.LldpChassisIdOID,
+			_switch.LldpSysNameOID,
+			_switch.LldpPortIdOID,
+		)
 
-	return nil
+		return err
+	})
 }
 
-func (r *DefaultSwitchRepository) CreateSwitch(_switch *models.Switch) error {
-	stmt, ok := r.Database.GetQuery("CREATE_SWITCH")
-	if !ok {
-		return errors.New("query CREATE_SWITCH is not prepare")
-	}
+// CreateSwitch Создает модель коммутатора и пишет версию истории в одной транзакции
+func (r *DefaultSwitchRepository) CreateSwitch(_switch *models.Switch, userID int32) error {
+	history := &models.History{Entity: "SWITCH", Action: "CREATE", UserId: userID, CreatedAt: _switch.CreatedAt}
 
 	var operationModeID interface{}
 
@@ -130,29 +134,37 @@ func (r *DefaultSwitchRepository) CreateSwitch(_switch *models.Switch) error {
 		operationModeID = _switch.OperationMode.ID
 	}
 
-	if err := stmt.QueryRow(
-		_switch.Name,
-		operationModeID,
-		_switch.CommunityRead,
-		_switch.CommunityWrite,
-		_switch.PortAmount,
-		_switch.FirmwareOID,
-		_switch.SystemNameOID,
-		_switch.SerialNumberOID,
-		_switch.SaveConfigOID,
-		_switch.PortDescOID,
-		_switch.VlanOID,
-		_switch.PortUntaggedOID,
-		_switch.SpeedOID,
-		_switch.BatteryStatusOID,
-		_switch.BatteryChargeOID,
-		_switch.PortModeOID,
-		_switch.UptimeOID,
-		_switch.CreatedAt,
-		_switch.MacOID,
-	).Scan(&_switch.ID); err != nil {
-		return err
-	}
+	return changeWithHistory(r.Database, history, []string{"CREATE_SWITCH"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		if err := tx.Stmt(stmts["CREATE_SWITCH"]).QueryRow(
+			_switch.Name,
+			operationModeID,
+			_switch.CommunityRead,
+			_switch.CommunityWrite,
+			_switch.PortAmount,
+			_switch.FirmwareOID,
+			_switch.SystemNameOID,
+			_switch.SerialNumberOID,
+			_switch.SaveConfigOID,
+			_switch.PortDescOID,
+			_switch.VlanOID,
+			_switch.PortUntaggedOID,
+			_switch.SpeedOID,
+			_switch.BatteryStatusOID,
+			_switch.BatteryChargeOID,
+			_switch.PortModeOID,
+			_switch.UptimeOID,
+			_switch.CreatedAt,
+			_switch.MacOID,
+			_switch.TargetFirmware,
+			_switch.LldpChassisIdOID,
+			_switch.LldpSysNameOID,
+			_switch.LldpPortIdOID,
+		).Scan(&_switch.ID); err != nil {
+			return err
+		}
+
+		history.EntityID = _switch.ID
 
-	return nil
+		return nil
+	})
 }
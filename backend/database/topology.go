@@ -0,0 +1,1036 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+type TopologyRepository interface {
+	SaveLldpNeighbors(hardwareID int, neighbors []models.LldpNeighbor, seenAt int64) error
+	GetLldpNeighbors(hardwareID int) ([]models.LldpNeighbor, error)
+	DiscoverTopology(createdAt int64) (int, error)
+	GetTopologyProposals(status string) ([]models.TopologyProposal, error)
+	GetTopologyProposal(proposalID int) (*models.TopologyProposal, error)
+	ReviewTopologyProposal(proposalID int, status string, userID int32, reviewedAt int64) error
+	AcceptParentProposal(proposalID int, nodeID int, currentParentID int, move models.NodeMove, userID int32, reviewedAt int64) ([]models.NodeTreeItem, error)
+	AcceptLinkProposal(proposalID int, cable *models.Cable, userID int32) error
+}
+
+type DefaultTopologyRepository struct {
+	Database Database
+}
+
+// ErrProposalReviewed Предложение уже принято или отклонено другим запросом
+var ErrProposalReviewed = errors.New("topology proposal is already reviewed")
+
+// ErrNodeParentChanged parent_id узла изменился после обнаружения, предложение устарело
+var ErrNodeParentChanged = errors.New("node parent changed since discovery")
+
+// SaveLldpNeighbors Записывает таблицу соседей из опроса в одной транзакции. У известных соседей
+// обновляется порт и время последнего появления, соседи, пропавшие из таблицы, удаляются
+func (r *DefaultTopologyRepository) SaveLldpNeighbors(hardwareID int, neighbors []models.LldpNeighbor, seenAt int64) error {
+	keys := []string{"UPSERT_LLDP_NEIGHBOR", "DELETE_STALE_LLDP_NEIGHBORS"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsertStmt := tx.Stmt(stmts["UPSERT_LLDP_NEIGHBOR"])
+
+	for _, neighbor := range neighbors {
+		if _, err = upsertStmt.Exec(
+			hardwareID,
+			neighbor.LocalPort,
+			neighbor.ChassisID,
+			neighbor.SystemName,
+			neighbor.PortID,
+			seenAt,
+			neighbor.LocalIfIndex,
+		); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.Stmt(stmts["DELETE_STALE_LLDP_NEIGHBORS"]).Exec(hardwareID, seenAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetLldpNeighbors Возвращает соседей оборудования (при hardwareID = 0 - всех) с сопоставленным
+// известным оборудованием
+func (r *DefaultTopologyRepository) GetLldpNeighbors(hardwareID int) ([]models.LldpNeighbor, error) {
+	stmt, ok := r.Database.GetQuery("GET_LLDP_NEIGHBORS")
+	if !ok {
+		return nil, errors.New("query GET_LLDP_NEIGHBORS is not prepare")
+	}
+
+	rows, err := stmt.Query(hardwareID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	neighbors := make([]models.LldpNeighbor, 0)
+
+	for rows.Next() {
+		var neighbor models.LldpNeighbor
+
+		if err = rows.Scan(
+			&neighbor.ID,
+			&neighbor.LocalPort,
+			&neighbor.LocalIfIndex,
+			&neighbor.ChassisID,
+			&neighbor.SystemName,
+			&neighbor.PortID,
+			&neighbor.FirstSeen,
+			&neighbor.LastSeen,
+			&neighbor.Hardware.ID,
+			&neighbor.Hardware.Node.ID,
+		); err != nil {
+			return nil, err
+		}
+
+		neighbors = append(neighbors, neighbor)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(neighbors) == 0 {
+		return neighbors, nil
+	}
+
+	matcher, err := r.getNeighborMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range neighbors {
+		neighbors[i].RemoteHardware = matcher.match(neighbors[i])
+	}
+
+	return neighbors, nil
+}
+
+// DiscoverTopology Сравнивает таблицы соседей с инвентарем и создает предложения: родителя для узла без
+// parent_id или с другим parent_id (конфликт) и кабель между портами, которые не соединены в инвентаре.
+// Уже ожидающие и отклоненные предложения не повторяются, ожидающие предложения, которые больше
+// не подтверждаются соседями, удаляются. Возвращает количество новых предложений
+func (r *DefaultTopologyRepository) DiscoverTopology(createdAt int64) (int, error) {
+	keys := []string{"CREATE_TOPOLOGY_PROPOSAL", "DELETE_TOPOLOGY_PROPOSAL"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return 0, errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	neighbors, err := r.GetLldpNeighbors(0)
+	if err != nil {
+		return 0, err
+	}
+
+	nodes, err := r.getDiscoveryNodes()
+	if err != nil {
+		return 0, err
+	}
+
+	rules, err := r.getNodeTypeRules()
+	if err != nil {
+		return 0, err
+	}
+
+	ports, err := r.getDiscoveryPorts()
+	if err != nil {
+		return 0, err
+	}
+
+	open, err := r.getOpenTopologyProposals()
+	if err != nil {
+		return 0, err
+	}
+
+	proposals := buildTopologyProposals(neighbors, nodes, rules, ports)
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	createStmt := tx.Stmt(stmts["CREATE_TOPOLOGY_PROPOSAL"])
+	deleteStmt := tx.Stmt(stmts["DELETE_TOPOLOGY_PROPOSAL"])
+
+	created := 0
+	actual := make(map[string]struct{})
+
+	for _, proposal := range proposals {
+		key := topologyProposalKey(proposal)
+		actual[key] = struct{}{}
+
+		if _, ok := open[key]; ok {
+			continue
+		}
+
+		// Параллельное обнаружение могло уже создать такое предложение, тогда вставка пропускается
+		var (
+			result sql.Result
+			count  int64
+		)
+
+		result, err = createStmt.Exec(
+			proposal.Kind,
+			proposal.Hardware.ID,
+			proposal.LocalPort,
+			proposal.RemoteHardware.ID,
+			proposal.RemotePortID,
+			nodeIDArg(proposal.Node),
+			nodeIDArg(proposal.CurrentParent),
+			nodeIDArg(proposal.ProposedParent),
+			portIDArg(proposal.Port),
+			portIDArg(proposal.RemotePort),
+			createdAt,
+		)
+		if err != nil {
+			return 0, err
+		}
+
+		count, err = result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+
+		created += int(count)
+	}
+
+	for key, proposal := range open {
+		if _, ok := actual[key]; ok || proposal.Status != models.TopologyProposalPending {
+			continue
+		}
+
+		if _, err = deleteStmt.Exec(proposal.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return created, nil
+}
+
+// GetTopologyProposals Возвращает предложения с указанным статусом (пустой статус - все), новые первыми
+func (r *DefaultTopologyRepository) GetTopologyProposals(status string) ([]models.TopologyProposal, error) {
+	return r.getTopologyProposals(status, 0)
+}
+
+// GetTopologyProposal Возвращает предложение или sql.ErrNoRows
+func (r *DefaultTopologyRepository) GetTopologyProposal(proposalID int) (*models.TopologyProposal, error) {
+	proposals, err := r.getTopologyProposals("", proposalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(proposals) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &proposals[0], nil
+}
+
+// ReviewTopologyProposal Записывает решение оператора. Возвращает ErrProposalReviewed, если предложение
+// не найдено или уже рассмотрено
+func (r *DefaultTopologyRepository) ReviewTopologyProposal(proposalID int, status string, userID int32, reviewedAt int64) error {
+	stmt, ok := r.Database.GetQuery("REVIEW_TOPOLOGY_PROPOSAL")
+	if !ok {
+		return errors.New("query REVIEW_TOPOLOGY_PROPOSAL is not prepare")
+	}
+
+	return claimTopologyProposal(stmt, proposalID, status, userID, reviewedAt)
+}
+
+// AcceptParentProposal Отмечает предложение принятым и переносит узел под предложенного родителя
+// в одной транзакции. Предложение захватывается первым, поэтому при параллельном принятии перенос
+// выполняет только один запрос, остальные получают ErrProposalReviewed без изменений в дереве.
+// Если parent_id узла уже не равен currentParentID, возвращается ErrNodeParentChanged
+func (r *DefaultTopologyRepository) AcceptParentProposal(proposalID int, nodeID int, currentParentID int, move models.NodeMove, userID int32, reviewedAt int64) ([]models.NodeTreeItem, error) {
+	keys := append([]string{"REVIEW_TOPOLOGY_PROPOSAL", "GET_NODE_PARENT"}, moveNodeKeys...)
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return nil, errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = claimTopologyProposal(tx.Stmt(stmts["REVIEW_TOPOLOGY_PROPOSAL"]), proposalID, models.TopologyProposalAccepted, userID, reviewedAt); err != nil {
+		return nil, err
+	}
+
+	// Родитель сравнивается под той же блокировкой, что берет moveNode, иначе параллельный перенос
+	// между проверкой и переносом останется незамеченным
+	if err = lockNodePath(tx.Stmt(stmts["LOCK_NODE_PATH"]), nodeID, move.ParentID); err != nil {
+		return nil, err
+	}
+
+	var parentID int
+
+	if err = tx.Stmt(stmts["GET_NODE_PARENT"]).QueryRow(nodeID).Scan(&parentID); err != nil {
+		return nil, err
+	}
+
+	if parentID != currentParentID {
+		return nil, ErrNodeParentChanged
+	}
+
+	items, err := moveNode(tx, stmts, nodeID, move, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// AcceptLinkProposal Отмечает предложение принятым и создает кабель между портами в одной транзакции.
+// Время рассмотрения совпадает со временем создания кабеля
+func (r *DefaultTopologyRepository) AcceptLinkProposal(proposalID int, cable *models.Cable, userID int32) error {
+	keys := []string{"REVIEW_TOPOLOGY_PROPOSAL", "CREATE_CABLE"}
+	stmts := make(map[string]*sql.Stmt)
+
+	for _, key := range keys {
+		stmt, ok := r.Database.GetQuery(key)
+		if !ok {
+			return errors.New("query " + key + " is not prepare")
+		}
+
+		stmts[key] = stmt
+	}
+
+	tx, err := r.Database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = claimTopologyProposal(tx.Stmt(stmts["REVIEW_TOPOLOGY_PROPOSAL"]), proposalID, models.TopologyProposalAccepted, userID, cable.CreatedAt); err != nil {
+		return err
+	}
+
+	aNodeID, aPortID := cableEndpointArgs(cable.A)
+	bNodeID, bPortID := cableEndpointArgs(cable.B)
+
+	if err = tx.Stmt(stmts["CREATE_CABLE"]).QueryRow(
+		aNodeID,
+		aPortID,
+		bNodeID,
+		bPortID,
+		cable.Medium,
+		cable.Length,
+		cable.Label,
+		cable.Description,
+		cable.CreatedAt,
+		cable.FiberCount,
+	).Scan(&cable.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// claimTopologyProposal Переводит ожидающее предложение в статус status. Строка предложения остается
+// заблокированной до конца транзакции, поэтому второй запрос дождется ее и не найдет PENDING
+func claimTopologyProposal(stmt *sql.Stmt, proposalID int, status string, userID int32, reviewedAt int64) error {
+	var id int
+
+	err := stmt.QueryRow(proposalID, status, reviewedAt, userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProposalReviewed
+	}
+
+	return err
+}
+
+func (r *DefaultTopologyRepository) getTopologyProposals(status string, proposalID int) ([]models.TopologyProposal, error) {
+	stmt, ok := r.Database.GetQuery("GET_TOPOLOGY_PROPOSALS")
+	if !ok {
+		return nil, errors.New("query GET_TOPOLOGY_PROPOSALS is not prepare")
+	}
+
+	rows, err := stmt.Query(status, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	proposals := make([]models.TopologyProposal, 0)
+
+	for rows.Next() {
+		var (
+			proposal                                     models.TopologyProposal
+			nodeID, currentParentID, proposedParentID    sql.NullInt64
+			nodeName, currentParentName, proposedName    sql.NullString
+			nodeHouseID, currentHouseID, proposedHouseID sql.NullInt32
+			portID, portNumber, remotePortID, remoteNum  sql.NullInt64
+			portDescription, remotePortDescription       sql.NullString
+		)
+
+		if err = rows.Scan(
+			&proposal.ID,
+			&proposal.Kind,
+			&proposal.Status,
+			&proposal.LocalPort,
+			&proposal.RemotePortID,
+			&proposal.CreatedAt,
+			&proposal.ReviewedAt,
+			&proposal.ReviewedBy,
+			&proposal.Hardware.ID,
+			&proposal.Hardware.IpAddress,
+			&proposal.Hardware.Type.Value,
+			&proposal.Hardware.Node.ID,
+			&proposal.Hardware.Node.Name,
+			&proposal.Hardware.Node.HouseId,
+			&proposal.RemoteHardware.ID,
+			&proposal.RemoteHardware.IpAddress,
+			&proposal.RemoteHardware.Type.Value,
+			&proposal.RemoteHardware.Node.ID,
+			&proposal.RemoteHardware.Node.Name,
+			&proposal.RemoteHardware.Node.HouseId,
+			&nodeID,
+			&nodeName,
+			&nodeHouseID,
+			&currentParentID,
+			&currentParentName,
+			&currentHouseID,
+			&proposedParentID,
+			&proposedName,
+			&proposedHouseID,
+			&portID,
+			&portNumber,
+			&portDescription,
+			&remotePortID,
+			&remoteNum,
+			&remotePortDescription,
+		); err != nil {
+			return nil, err
+		}
+
+		if nodeID.Valid {
+			proposal.Node = &models.Node{ID: int(nodeID.Int64), Name: nodeName.String, HouseId: nodeHouseID.Int32}
+		}
+
+		if currentParentID.Valid {
+			proposal.CurrentParent = &models.Node{ID: int(currentParentID.Int64), Name: currentParentName.String, HouseId: currentHouseID.Int32}
+		}
+
+		if proposedParentID.Valid {
+			proposal.ProposedParent = &models.Node{ID: int(proposedParentID.Int64), Name: proposedName.String, HouseId: proposedHouseID.Int32}
+		}
+
+		if portID.Valid {
+			proposal.Port = &models.HardwarePort{
+				ID:          int(portID.Int64),
+				Hardware:    models.Hardware{ID: proposal.Hardware.ID},
+				Number:      int(portNumber.Int64),
+				Description: portDescription,
+			}
+		}
+
+		if remotePortID.Valid {
+			proposal.RemotePort = &models.HardwarePort{
+				ID:          int(remotePortID.Int64),
+				Hardware:    models.Hardware{ID: proposal.RemoteHardware.ID},
+				Number:      int(remoteNum.Int64),
+				Description: remotePortDescription,
+			}
+		}
+
+		proposal.IsConflict = proposal.Kind == models.TopologyProposalParent && proposal.CurrentParent != nil
+
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals, rows.Err()
+}
+
+// neighborMatcher Индексы известного оборудования для сопоставления соседей. nil в индексе означает
+// неоднозначный ключ, например одинаковое имя системы у двух коммутаторов
+type neighborMatcher struct {
+	byChassis map[string]*models.Hardware
+	byName    map[string]*models.Hardware
+	byIP      map[string]*models.Hardware
+}
+
+func (r *DefaultTopologyRepository) getNeighborMatcher() (*neighborMatcher, error) {
+	stmt, ok := r.Database.GetQuery("GET_HARDWARE_IDENTITIES")
+	if !ok {
+		return nil, errors.New("query GET_HARDWARE_IDENTITIES is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matcher := &neighborMatcher{
+		byChassis: make(map[string]*models.Hardware),
+		byName:    make(map[string]*models.Hardware),
+		byIP:      make(map[string]*models.Hardware),
+	}
+
+	add := func(index map[string]*models.Hardware, key string, hd *models.Hardware) {
+		if key == "" {
+			return
+		}
+
+		if existing, ok := index[key]; ok && (existing == nil || existing.ID != hd.ID) {
+			index[key] = nil
+			return
+		}
+
+		index[key] = hd
+	}
+
+	for rows.Next() {
+		var (
+			hd         models.Hardware
+			switchName sql.NullString
+			systemName sql.NullString
+			chassisID  sql.NullString
+		)
+
+		if err = rows.Scan(
+			&hd.ID,
+			&hd.IpAddress,
+			&hd.Type.Value,
+			&switchName,
+			&hd.Node.ID,
+			&hd.Node.Name,
+			&hd.Node.HouseId,
+			&systemName,
+			&chassisID,
+		); err != nil {
+			return nil, err
+		}
+
+		hd.Switch.Name = switchName.String
+
+		add(matcher.byChassis, strings.ToLower(strings.TrimSpace(chassisID.String)), &hd)
+		add(matcher.byName, normalizeSystemName(systemName.String), &hd)
+		add(matcher.byIP, strings.TrimSpace(hd.IpAddress.String), &hd)
+	}
+
+	return matcher, rows.Err()
+}
+
+// match Сопоставляет соседа по chassis ID, затем по имени системы, затем по IP адресу в chassis ID
+// (подтип networkAddress) или в имени системы (CDP может передавать IP вместо имени)
+func (m *neighborMatcher) match(neighbor models.LldpNeighbor) *models.Hardware {
+	lookups := []struct {
+		index map[string]*models.Hardware
+		key   string
+	}{
+		{m.byChassis, neighbor.ChassisID},
+		{m.byName, normalizeSystemName(neighbor.SystemName)},
+		{m.byIP, neighbor.ChassisID},
+		{m.byIP, strings.TrimSpace(neighbor.SystemName)},
+	}
+
+	for _, lookup := range lookups {
+		if lookup.key == "" {
+			continue
+		}
+
+		hd := lookup.index[lookup.key]
+		if hd == nil {
+			continue
+		}
+
+		if hd.ID == neighbor.Hardware.ID {
+			return nil
+		}
+
+		return hd
+	}
+
+	return nil
+}
+
+// normalizeSystemName Приводит имя системы к виду для сравнения: без регистра, домена и серийного
+// номера в скобках, который добавляет CDP (SW1(FOC1234X))
+func normalizeSystemName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	if i := strings.Index(name, "("); i > 0 {
+		name = name[:i]
+	}
+
+	if net.ParseIP(name) == nil {
+		if i := strings.Index(name, "."); i > 0 {
+			name = name[:i]
+		}
+	}
+
+	return strings.TrimSpace(name)
+}
+
+type discoveryNode struct {
+	parentID  int
+	isPassive bool
+	typeKey   string
+}
+
+type discoveryPort struct {
+	id       int
+	number   int
+	ifName   string
+	ifDescr  string
+	hasCable bool
+}
+
+func (r *DefaultTopologyRepository) getDiscoveryNodes() (map[int]discoveryNode, error) {
+	stmt, ok := r.Database.GetQuery("GET_DISCOVERY_NODES")
+	if !ok {
+		return nil, errors.New("query GET_DISCOVERY_NODES is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nodes := make(map[int]discoveryNode)
+
+	for rows.Next() {
+		var (
+			id       int
+			node     discoveryNode
+			parentID sql.NullInt64
+			typeKey  sql.NullString
+		)
+
+		if err = rows.Scan(&id, &parentID, &node.isPassive, &typeKey); err != nil {
+			return nil, err
+		}
+
+		node.parentID = int(parentID.Int64)
+		node.typeKey = typeKey.String
+		nodes[id] = node
+	}
+
+	return nodes, rows.Err()
+}
+
+// getNodeTypeRules Возвращает разрешенные пары типов узлов в виде "родитель/потомок"
+func (r *DefaultTopologyRepository) getNodeTypeRules() (map[string]bool, error) {
+	stmt, ok := r.Database.GetQuery("GET_NODE_TYPE_RULES")
+	if !ok {
+		return nil, errors.New("query GET_NODE_TYPE_RULES is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make(map[string]bool)
+
+	for rows.Next() {
+		var rule models.NodeTypeRule
+
+		if err = rows.Scan(
+			&rule.ID,
+			&rule.CreatedAt,
+			&rule.ParentType.ID,
+			&rule.ParentType.Key,
+			&rule.ParentType.Value,
+			&rule.ChildType.ID,
+			&rule.ChildType.Key,
+			&rule.ChildType.Value,
+		); err != nil {
+			return nil, err
+		}
+
+		rules[rule.ParentType.Key+"/"+rule.ChildType.Key] = true
+	}
+
+	return rules, rows.Err()
+}
+
+// getDiscoveryPorts Возвращает порты по ID оборудования
+func (r *DefaultTopologyRepository) getDiscoveryPorts() (map[int][]discoveryPort, error) {
+	stmt, ok := r.Database.GetQuery("GET_DISCOVERY_PORTS")
+	if !ok {
+		return nil, errors.New("query GET_DISCOVERY_PORTS is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ports := make(map[int][]discoveryPort)
+
+	for rows.Next() {
+		var (
+			port       discoveryPort
+			hardwareID int
+			ifName     sql.NullString
+			ifDescr    sql.NullString
+		)
+
+		if err = rows.Scan(&port.id, &hardwareID, &port.number, &ifName, &ifDescr, &port.hasCable); err != nil {
+			return nil, err
+		}
+
+		port.ifName = strings.ToLower(strings.TrimSpace(ifName.String))
+		port.ifDescr = strings.ToLower(strings.TrimSpace(ifDescr.String))
+		ports[hardwareID] = append(ports[hardwareID], port)
+	}
+
+	return ports, rows.Err()
+}
+
+// findPortByIfIndex Возвращает порт оборудования по ifIndex, если он известен
+func findPortByIfIndex(ports []discoveryPort, ifIndex sql.NullInt32) (discoveryPort, bool) {
+	if !ifIndex.Valid {
+		return discoveryPort{}, false
+	}
+
+	for _, port := range ports {
+		if port.number == int(ifIndex.Int32) {
+			return port, true
+		}
+	}
+
+	return discoveryPort{}, false
+}
+
+// findPortByName Возвращает порт, ifName или ifDescr которого совпадает с ID порта соседа без учета
+// регистра. Совпадение по ifName важнее совпадения по ifDescr. Если подходит несколько портов,
+// порт не определен
+func findPortByName(ports []discoveryPort, portID string) (discoveryPort, bool) {
+	portID = strings.ToLower(strings.TrimSpace(portID))
+	if portID == "" {
+		return discoveryPort{}, false
+	}
+
+	var byName, byDescr []discoveryPort
+
+	for _, port := range ports {
+		if port.ifName == portID {
+			byName = append(byName, port)
+		}
+
+		if port.ifDescr == portID {
+			byDescr = append(byDescr, port)
+		}
+	}
+
+	if len(byName) == 0 {
+		byName = byDescr
+	}
+
+	if len(byName) == 1 {
+		return byName[0], true
+	}
+
+	return discoveryPort{}, false
+}
+
+// getOpenTopologyProposals Возвращает ожидающие и отклоненные предложения по ключу topologyProposalKey
+func (r *DefaultTopologyRepository) getOpenTopologyProposals() (map[string]models.TopologyProposal, error) {
+	stmt, ok := r.Database.GetQuery("GET_OPEN_TOPOLOGY_PROPOSALS")
+	if !ok {
+		return nil, errors.New("query GET_OPEN_TOPOLOGY_PROPOSALS is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	proposals := make(map[string]models.TopologyProposal)
+
+	for rows.Next() {
+		var (
+			proposal                                  models.TopologyProposal
+			nodeID, currentParentID, proposedParentID sql.NullInt64
+			portID, remotePortID                      sql.NullInt64
+		)
+
+		if err = rows.Scan(
+			&proposal.ID,
+			&proposal.Kind,
+			&proposal.Status,
+			&nodeID,
+			&currentParentID,
+			&proposedParentID,
+			&portID,
+			&remotePortID,
+		); err != nil {
+			return nil, err
+		}
+
+		if nodeID.Valid {
+			proposal.Node = &models.Node{ID: int(nodeID.Int64)}
+		}
+
+		if currentParentID.Valid {
+			proposal.CurrentParent = &models.Node{ID: int(currentParentID.Int64)}
+		}
+
+		if proposedParentID.Valid {
+			proposal.ProposedParent = &models.Node{ID: int(proposedParentID.Int64)}
+		}
+
+		if portID.Valid {
+			proposal.Port = &models.HardwarePort{ID: int(portID.Int64)}
+		}
+
+		if remotePortID.Valid {
+			proposal.RemotePort = &models.HardwarePort{ID: int(remotePortID.Int64)}
+		}
+
+		key := topologyProposalKey(proposal)
+
+		// Отклоненное предложение важнее ожидающего: повторно оно не создается и не удаляется
+		if existing, ok := proposals[key]; ok && existing.Status == models.TopologyProposalRejected {
+			continue
+		}
+
+		proposals[key] = proposal
+	}
+
+	return proposals, rows.Err()
+}
+
+// buildTopologyProposals Строит предложения по сопоставленным соседям. Направление связи узлов
+// определяется правилами типов узлов, затем узел без родителя считается потомком, затем потомком
+// считается более глубокий в дереве узел. Если записанный родитель узла сам виден среди его соседей,
+// топология подтверждена и конфликты по остальным соседям (кольца, резервные линки) не создаются
+func buildTopologyProposals(neighbors []models.LldpNeighbor, nodes map[int]discoveryNode, rules map[string]bool, ports map[int][]discoveryPort) []models.TopologyProposal {
+	var proposals []models.TopologyProposal
+
+	seen := make(map[string]struct{})
+	confirmed := make(map[int]bool)
+	parentCandidates := make([]models.TopologyProposal, 0)
+
+	add := func(proposal models.TopologyProposal) {
+		key := topologyProposalKey(proposal)
+		if _, ok := seen[key]; ok {
+			return
+		}
+
+		seen[key] = struct{}{}
+		proposals = append(proposals, proposal)
+	}
+
+	for _, neighbor := range neighbors {
+		if neighbor.RemoteHardware == nil {
+			continue
+		}
+
+		proposal := models.TopologyProposal{
+			Hardware:       neighbor.Hardware,
+			LocalPort:      neighbor.LocalPort,
+			RemoteHardware: *neighbor.RemoteHardware,
+			RemotePortID:   neighbor.PortID,
+		}
+
+		// Кабель предлагается, только если оба порта определены однозначно: локальный по ifIndex,
+		// удаленный по имени интерфейса из ID порта соседа
+		local, localOk := findPortByIfIndex(ports[neighbor.Hardware.ID], neighbor.LocalIfIndex)
+		remote, remoteOk := findPortByName(ports[neighbor.RemoteHardware.ID], neighbor.PortID.String)
+
+		if localOk && remoteOk && !local.hasCable && !remote.hasCable {
+			link := proposal
+			link.Kind = models.TopologyProposalLink
+			link.Port = &models.HardwarePort{ID: local.id}
+			link.RemotePort = &models.HardwarePort{ID: remote.id}
+			add(link)
+		}
+
+		a, b := neighbor.Hardware.Node.ID, neighbor.RemoteHardware.Node.ID
+		if a == b {
+			continue
+		}
+
+		if nodes[a].parentID == b {
+			confirmed[a] = true
+			continue
+		}
+
+		if nodes[b].parentID == a {
+			confirmed[b] = true
+			continue
+		}
+
+		child, parent, ok := chooseParentNode(nodes, rules, a, b)
+		if !ok {
+			continue
+		}
+
+		proposal.Kind = models.TopologyProposalParent
+		proposal.Node = &models.Node{ID: child}
+		proposal.ProposedParent = &models.Node{ID: parent}
+
+		if nodes[child].parentID != 0 {
+			proposal.CurrentParent = &models.Node{ID: nodes[child].parentID}
+		}
+
+		parentCandidates = append(parentCandidates, proposal)
+	}
+
+	for _, proposal := range parentCandidates {
+		if !confirmed[proposal.Node.ID] {
+			add(proposal)
+		}
+	}
+
+	return proposals
+}
+
+// chooseParentNode Определяет, какой из соседних узлов должен быть родителем. Пассивные узлы и пары,
+// для которых направление не определить или связь создаст цикл, пропускаются
+func chooseParentNode(nodes map[int]discoveryNode, rules map[string]bool, a int, b int) (child int, parent int, ok bool) {
+	nodeA, okA := nodes[a]
+	nodeB, okB := nodes[b]
+
+	if !okA || !okB || nodeA.isPassive || nodeB.isPassive {
+		return 0, 0, false
+	}
+
+	aIsParent := rules[nodeA.typeKey+"/"+nodeB.typeKey]
+	bIsParent := rules[nodeB.typeKey+"/"+nodeA.typeKey]
+
+	switch {
+	case aIsParent && !bIsParent:
+		child, parent = b, a
+	case bIsParent && !aIsParent:
+		child, parent = a, b
+	case nodeA.parentID == 0 && nodeB.parentID != 0:
+		child, parent = a, b
+	case nodeB.parentID == 0 && nodeA.parentID != 0:
+		child, parent = b, a
+	default:
+		depthA, depthB := nodeDepth(nodes, a), nodeDepth(nodes, b)
+
+		switch {
+		case depthA > depthB:
+			child, parent = a, b
+		case depthB > depthA:
+			child, parent = b, a
+		default:
+			return 0, 0, false
+		}
+	}
+
+	if isAncestorNode(nodes, child, parent) {
+		return 0, 0, false
+	}
+
+	return child, parent, true
+}
+
+// nodeDepth Возвращает количество предков узла. Обход ограничен числом узлов на случай цикла в данных
+func nodeDepth(nodes map[int]discoveryNode, nodeID int) int {
+	depth := 0
+
+	for id := nodes[nodeID].parentID; id != 0 && depth <= len(nodes); id = nodes[id].parentID {
+		depth++
+	}
+
+	return depth
+}
+
+// isAncestorNode Возвращает true, если ancestorID - сам узел nodeID или один из его предков
+func isAncestorNode(nodes map[int]discoveryNode, ancestorID int, nodeID int) bool {
+	for i, id := 0, nodeID; id != 0 && i <= len(nodes); i, id = i+1, nodes[id].parentID {
+		if id == ancestorID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// topologyProposalKey Ключ для сравнения предложений между запусками обнаружения. Кабель не зависит
+// от порядка портов, родитель - от узла, текущего и предлагаемого родителя
+func topologyProposalKey(proposal models.TopologyProposal) string {
+	if proposal.Kind == models.TopologyProposalLink {
+		portID, remotePortID := portIDArg(proposal.Port), portIDArg(proposal.RemotePort)
+
+		a, _ := portID.(int)
+		b, _ := remotePortID.(int)
+
+		if a > b {
+			a, b = b, a
+		}
+
+		return fmt.Sprintf("%s/%d/%d", proposal.Kind, a, b)
+	}
+
+	current, _ := nodeIDArg(proposal.CurrentParent).(int)
+	proposed, _ := nodeIDArg(proposal.ProposedParent).(int)
+	node, _ := nodeIDArg(proposal.Node).(int)
+
+	return fmt.Sprintf("%s/%d/%d/%d", proposal.Kind, node, current, proposed)
+}
+
+func nodeIDArg(node *models.Node) interface{} {
+	if node == nil || node.ID == 0 {
+		return nil
+	}
+
+	return node.ID
+}
+
+func portIDArg(port *models.HardwarePort) interface{} {
+	if port == nil || port.ID == 0 {
+		return nil
+	}
+
+	return port.ID
+}
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestFindPortByName(t *testing.T) {
+	ports := []discoveryPort{
+		{id: 1, number: 10101, ifName: "gi0/1", ifDescr: "gigabitethernet0/1"},
+		{id: 2, number: 10102, ifName: "gi0/2", ifDescr: "gi0/1"},
+		{id: 3, number: 10103, ifName: "port", ifDescr: "uplink"},
+		{id: 4, number: 10104, ifName: "port", ifDescr: "uplink"},
+	}
+
+	tests := []struct {
+		name   string
+		portID string
+		want   int
+		ok     bool
+	}{
+		{name: "ifName ignoring case and spaces", portID: " Gi0/2 ", want: 2, ok: true},
+		{name: "ifDescr", portID: "GigabitEthernet0/1", want: 1, ok: true},
+		{name: "ifName wins over ifDescr", portID: "gi0/1", want: 1, ok: true},
+		{name: "ambiguous ifName", portID: "port"},
+		{name: "ambiguous ifDescr", portID: "uplink"},
+		{name: "no match", portID: "gi0/9"},
+		{name: "empty port id", portID: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findPortByName(ports, tt.portID)
+
+			if ok != tt.ok || got.id != tt.want {
+				t.Errorf("findPortByName(%q) = %d, %v, want %d, %v", tt.portID, got.id, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFindPortByIfIndex(t *testing.T) {
+	ports := []discoveryPort{{id: 1, number: 10101}, {id: 2, number: 10102}}
+
+	tests := []struct {
+		name    string
+		ifIndex sql.NullInt32
+		want    int
+		ok      bool
+	}{
+		{name: "known ifIndex", ifIndex: sql.NullInt32{Int32: 10102, Valid: true}, want: 2, ok: true},
+		{name: "unknown ifIndex", ifIndex: sql.NullInt32{Int32: 1, Valid: true}},
+		{name: "no ifIndex", ifIndex: sql.NullInt32{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findPortByIfIndex(ports, tt.ifIndex)
+
+			if ok != tt.ok || got.id != tt.want {
+				t.Errorf("findPortByIfIndex(%v) = %d, %v, want %d, %v", tt.ifIndex, got.id, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// topologyTestNodes Узел 1 - магистральный корень, 2 и 6 его потомки, 3 потомок 2, 7 потомок 3,
+// 4 без родителя, 5 пассивный
+func topologyTestNodes() (map[int]discoveryNode, map[string]bool) {
+	nodes := map[int]discoveryNode{
+		1: {typeKey: "BN"},
+		2: {parentID: 1, typeKey: "AN"},
+		3: {parentID: 2, typeKey: "AN"},
+		4: {typeKey: "AN"},
+		5: {parentID: 1, typeKey: "PN", isPassive: true},
+		6: {parentID: 1, typeKey: "AN"},
+		7: {parentID: 3, typeKey: "CN"},
+	}
+
+	rules := map[string]bool{
+		"BN/AN": true,
+		"CN/BN": true,
+	}
+
+	return nodes, rules
+}
+
+func TestChooseParentNode(t *testing.T) {
+	nodes, rules := topologyTestNodes()
+
+	tests := []struct {
+		name   string
+		a, b   int
+		child  int
+		parent int
+		ok     bool
+	}{
+		{name: "node type rule", a: 4, b: 1, child: 4, parent: 1, ok: true},
+		{name: "node type rule in reverse order", a: 1, b: 4, child: 4, parent: 1, ok: true},
+		{name: "node without parent is the child", a: 3, b: 4, child: 4, parent: 3, ok: true},
+		{name: "deeper node is the child", a: 6, b: 3, child: 3, parent: 6, ok: true},
+		{name: "same depth", a: 2, b: 6},
+		{name: "passive node", a: 5, b: 4},
+		{name: "unknown node", a: 4, b: 99},
+		{name: "rule would create a cycle", a: 7, b: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			child, parent, ok := chooseParentNode(nodes, rules, tt.a, tt.b)
+
+			if child != tt.child || parent != tt.parent || ok != tt.ok {
+				t.Errorf("chooseParentNode(%d, %d) = %d, %d, %v, want %d, %d, %v",
+					tt.a, tt.b, child, parent, ok, tt.child, tt.parent, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBuildTopologyProposals(t *testing.T) {
+	nodes, rules := topologyTestNodes()
+
+	hardware := func(id int, nodeID int) *models.Hardware {
+		return &models.Hardware{ID: id, Node: models.Node{ID: nodeID}}
+	}
+
+	neighbor := func(local *models.Hardware, ifIndex int32, remote *models.Hardware, portID string) models.LldpNeighbor {
+		return models.LldpNeighbor{
+			Hardware:       *local,
+			LocalPort:      1,
+			LocalIfIndex:   sql.NullInt32{Int32: ifIndex, Valid: ifIndex != 0},
+			PortID:         sql.NullString{String: portID, Valid: portID != ""},
+			RemoteHardware: remote,
+		}
+	}
+
+	onNode3 := hardware(10, 3)
+	onNode6 := hardware(20, 6)
+	onNode2 := hardware(30, 2)
+	alsoOnNode3 := hardware(40, 3)
+
+	ports := map[int][]discoveryPort{
+		10: {{id: 101, number: 10101, ifName: "gi0/1"}, {id: 102, number: 10102, ifName: "gi0/2", hasCable: true}},
+		20: {{id: 201, number: 1, ifName: "gi1/0/1"}, {id: 202, number: 2, ifName: "gi1/0/2", hasCable: true}},
+		40: {{id: 401, number: 1, ifName: "gi0/1"}},
+	}
+
+	tests := []struct {
+		name      string
+		neighbors []models.LldpNeighbor
+		want      []string
+	}{
+		{
+			name:      "link and parent conflict",
+			neighbors: []models.LldpNeighbor{neighbor(onNode3, 10101, onNode6, "Gi1/0/1")},
+			want:      []string{"LINK/101/201", "PARENT/3/2/6"},
+		},
+		{
+			name:      "remote port already has a cable",
+			neighbors: []models.LldpNeighbor{neighbor(onNode3, 10101, onNode6, "Gi1/0/2")},
+			want:      []string{"PARENT/3/2/6"},
+		},
+		{
+			name:      "local port already has a cable",
+			neighbors: []models.LldpNeighbor{neighbor(onNode3, 10102, onNode6, "Gi1/0/1")},
+			want:      []string{"PARENT/3/2/6"},
+		},
+		{
+			name:      "unknown local ifIndex",
+			neighbors: []models.LldpNeighbor{neighbor(onNode3, 0, onNode6, "Gi1/0/1")},
+			want:      []string{"PARENT/3/2/6"},
+		},
+		{
+			name:      "link inside one node",
+			neighbors: []models.LldpNeighbor{neighbor(onNode3, 10101, alsoOnNode3, "gi0/1")},
+			want:      []string{"LINK/101/401"},
+		},
+		{
+			name: "recorded parent among neighbors confirms topology",
+			neighbors: []models.LldpNeighbor{
+				neighbor(onNode3, 0, onNode6, "Gi1/0/1"),
+				neighbor(onNode3, 0, onNode2, ""),
+			},
+			want: nil,
+		},
+		{
+			name: "both sides of one link give one proposal each",
+			neighbors: []models.LldpNeighbor{
+				neighbor(onNode3, 10101, onNode6, "gi1/0/1"),
+				neighbor(onNode6, 1, onNode3, "gi0/1"),
+			},
+			want: []string{"LINK/101/201", "PARENT/3/2/6"},
+		},
+		{
+			name:      "unmatched neighbor",
+			neighbors: []models.LldpNeighbor{neighbor(onNode3, 10101, nil, "Gi1/0/1")},
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+
+			for _, proposal := range buildTopologyProposals(tt.neighbors, nodes, rules, ports) {
+				got = append(got, topologyProposalKey(proposal))
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildTopologyProposals() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
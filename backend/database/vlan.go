@@ -0,0 +1,220 @@
+package database
+
+import (
+	"backend/models"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type VlanRepository interface {
+	GetVlans() ([]models.Vlan, error)
+	CreateVlan(vlan *models.Vlan, userID int32) error
+	EditVlan(vlan *models.Vlan, userID int32) error
+	VlanExists(vlanID int) (bool, error)
+	ValidateMgmtVlan(mgmtVlan string) (bool, error)
+	GetVlanReport() (*models.VlanReport, error)
+	ValidateVlan(vlan models.Vlan) bool
+}
+
+type DefaultVlanRepository struct {
+	Database Database
+}
+
+func (r *DefaultVlanRepository) GetVlans() ([]models.Vlan, error) {
+	stmt, ok := r.Database.GetQuery("GET_VLANS")
+	if !ok {
+		return nil, errors.New("query GET_VLANS is not prepare")
+	}
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vlans := make([]models.Vlan, 0)
+
+	for rows.Next() {
+		var vlan models.Vlan
+
+		if err = rows.Scan(
+			&vlan.ID,
+			&vlan.VlanID,
+			&vlan.Name,
+			&vlan.Purpose,
+			&vlan.Zone,
+			&vlan.CreatedAt,
+			&vlan.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		vlans = append(vlans, vlan)
+	}
+
+	return vlans, nil
+}
+
+// CreateVlan Добавляет VLAN в реестр и пишет версию истории в одной транзакции
+func (r *DefaultVlanRepository) CreateVlan(vlan *models.Vlan, userID int32) error {
+	history := &models.History{Entity: "VLAN", Action: "CREATE", UserId: userID, CreatedAt: vlan.CreatedAt}
+
+	return changeWithHistory(r.Database, history, []string{"CREATE_VLAN"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		if err := tx.Stmt(stmts["CREATE_VLAN"]).QueryRow(
+			vlan.VlanID,
+			vlan.Name,
+			vlan.Purpose,
+			vlan.Zone,
+			vlan.CreatedAt,
+		).Scan(&vlan.ID); err != nil {
+			return err
+		}
+
+		history.EntityID = vlan.ID
+
+		return nil
+	})
+}
+
+// EditVlan Изменяет VLAN и пишет версию истории в одной транзакции
+func (r *DefaultVlanRepository) EditVlan(vlan *models.Vlan, userID int32) error {
+	history := &models.History{Entity: "VLAN", EntityID: vlan.ID, Action: "EDIT", UserId: userID, CreatedAt: vlan.UpdatedAt.Int64}
+
+	return changeWithHistory(r.Database, history, []string{"EDIT_VLAN"}, func(tx *sql.Tx, stmts map[string]*sql.Stmt) error {
+		res, err := tx.Stmt(stmts["EDIT_VLAN"]).Exec(
+			vlan.ID,
+			vlan.VlanID,
+			vlan.Name,
+			vlan.Purpose,
+			vlan.Zone,
+			vlan.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if affected, e := res.RowsAffected(); e == nil && affected == 0 {
+			return sql.ErrNoRows
+		}
+
+		return nil
+	})
+}
+
+func (r *DefaultVlanRepository) VlanExists(vlanID int) (bool, error) {
+	stmt, ok := r.Database.GetQuery("CHECK_VLAN_EXISTS")
+	if !ok {
+		return false, errors.New("query CHECK_VLAN_EXISTS is not prepare")
+	}
+
+	var exists bool
+
+	if err := stmt.QueryRow(vlanID).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// ValidateMgmtVlan Проверяет, что VLAN управления оборудования - номер VLAN из реестра.
+// Пустое значение допустимо
+func (r *DefaultVlanRepository) ValidateMgmtVlan(mgmtVlan string) (bool, error) {
+	mgmtVlan = strings.TrimSpace(mgmtVlan)
+
+	if mgmtVlan == "" {
+		return true, nil
+	}
+
+	vlanID, err := strconv.Atoi(mgmtVlan)
+	if err != nil || vlanID < 1 || vlanID > 4094 {
+		return false, nil
+	}
+
+	return r.VlanExists(vlanID)
+}
+
+// GetVlanReport Сверяет реестр с коммутаторами: VLAN, настроенные на оборудовании, но отсутствующие
+// в реестре, и VLAN реестра, которых нет ни на одном оборудовании
+func (r *DefaultVlanRepository) GetVlanReport() (*models.VlanReport, error) {
+	unregisteredStmt, ok := r.Database.GetQuery("GET_UNREGISTERED_VLANS")
+	if !ok {
+		return nil, errors.New("query GET_UNREGISTERED_VLANS is not prepare")
+	}
+
+	unusedStmt, ok := r.Database.GetQuery("GET_UNUSED_VLANS")
+	if !ok {
+		return nil, errors.New("query GET_UNUSED_VLANS is not prepare")
+	}
+
+	report := &models.VlanReport{
+		Unregistered: make([]models.VlanUsage, 0),
+		Unused:       make([]models.Vlan, 0),
+	}
+
+	rows, err := unregisteredStmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			vlanID     int
+			hardware   models.Hardware
+			switchName sql.NullString
+		)
+
+		if err = rows.Scan(
+			&vlanID,
+			&hardware.ID,
+			&hardware.IpAddress,
+			&switchName,
+			&hardware.Node.ID,
+			&hardware.Node.Name,
+			&hardware.Node.HouseId,
+		); err != nil {
+			return nil, err
+		}
+
+		hardware.Switch.Name = switchName.String
+
+		if n := len(report.Unregistered); n == 0 || report.Unregistered[n-1].VlanID != vlanID {
+			report.Unregistered = append(report.Unregistered, models.VlanUsage{VlanID: vlanID})
+		}
+
+		usage := &report.Unregistered[len(report.Unregistered)-1]
+		usage.Hardware = append(usage.Hardware, hardware)
+	}
+
+	unusedRows, err := unusedStmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer unusedRows.Close()
+
+	for unusedRows.Next() {
+		var vlan models.Vlan
+
+		if err = unusedRows.Scan(
+			&vlan.ID,
+			&vlan.VlanID,
+			&vlan.Name,
+			&vlan.Purpose,
+			&vlan.Zone,
+			&vlan.CreatedAt,
+			&vlan.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		report.Unused = append(report.Unused, vlan)
+	}
+
+	return report, nil
+}
+
+func (r *DefaultVlanRepository) ValidateVlan(vlan models.Vlan) bool {
+	return vlan.VlanID >= 1 && vlan.VlanID <= 4094 && strings.TrimSpace(vlan.Name) != ""
+}
@@ -14,6 +14,7 @@ type HTTPError struct {
 	Code    int
 	Line    int
 	File    string
+	Details interface{}
 }
 
 func (e *HTTPError) Error() string {
@@ -41,3 +42,9 @@ func NewHTTPError(err error, msg string, code int) *HTTPError {
 		Line:    line,
 	}
 }
+
+// WithDetails Прикладывает к ошибке структурированное описание, которое будет отдано клиенту вместе с сообщением
+func (e *HTTPError) WithDetails(details interface{}) *HTTPError {
+	e.Details = details
+	return e
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	goErrors "errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AvailabilityHandler interface {
+	HandlerGetAvailability(c *gin.Context)
+	HandlerGetCurrentOutages(c *gin.Context)
+	HandlerGetHardwareOutages(c *gin.Context)
+}
+
+type DefaultAvailabilityHandler struct {
+	AvailabilityRepo database.AvailabilityRepository
+	AddressService   addresspb.AddressServiceClient
+	Metadata         utils.Metadata
+}
+
+func NewAvailabilityHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) AvailabilityHandler {
+	return &DefaultAvailabilityHandler{
+		AvailabilityRepo: &database.DefaultAvailabilityRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+// HandlerGetAvailability Возвращает доступность за период from - to (unix time, по умолчанию 30 дней)
+// по оборудованию, узлам или домам (by = hardware, node, house). Для узла и дома время наблюдения
+// и простоя суммируется по всему их оборудованию. Можно ограничить выборку параметрами nodeId и houseId
+func (h *DefaultAvailabilityHandler) HandlerGetAvailability(c *gin.Context) {
+	from, to, httpErr := parsePeriod(c, 30*24*time.Hour)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	nodeID, err := strconv.Atoi(c.DefaultQuery("nodeId", "0"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(nodeId) to int", http.StatusBadRequest))
+		return
+	}
+
+	houseID, err := strconv.Atoi(c.DefaultQuery("houseId", "0"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(houseId) to int", http.StatusBadRequest))
+		return
+	}
+
+	by := c.DefaultQuery("by", "hardware")
+	if by != "hardware" && by != "node" && by != "house" {
+		c.Error(errors.NewHTTPError(nil, "by must be hardware, node or house", http.StatusBadRequest))
+		return
+	}
+
+	items, err := h.AvailabilityRepo.GetAvailability(from, to, nodeID, houseID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get availability", http.StatusInternalServerError))
+		return
+	}
+
+	if by != "hardware" {
+		items = groupAvailability(items, by)
+	}
+
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for i := range items {
+		if items[i].Monitored > 0 {
+			items[i].Percent = float64(items[i].Monitored-items[i].Downtime) * 100 / float64(items[i].Monitored)
+		}
+
+		if _, ok := houseIDSet[items[i].HouseID]; !ok {
+			houseIDSet[items[i].HouseID] = struct{}{}
+			houseIDs = append(houseIDs, items[i].HouseID)
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		res, e := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, "failed to get addresses", http.StatusInternalServerError))
+			return
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for i := range items {
+		items[i].Address = addressMap[items[i].HouseID]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"From":  from,
+		"To":    to,
+		"Items": items,
+		"Count": len(items),
+	})
+}
+
+// HandlerGetCurrentOutages Возвращает оборудование, которое сейчас недоступно, с адресами домов
+func (h *DefaultAvailabilityHandler) HandlerGetCurrentOutages(c *gin.Context) {
+	outages, err := h.AvailabilityRepo.GetCurrentOutages()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get outages", http.StatusInternalServerError))
+		return
+	}
+
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+	now := time.Now().Unix()
+
+	var houseIDs []int32
+
+	for i := range outages {
+		outages[i].Duration = now - outages[i].StartedAt
+
+		if _, ok := houseIDSet[outages[i].Hardware.Node.HouseId]; !ok {
+			houseIDSet[outages[i].Hardware.Node.HouseId] = struct{}{}
+			houseIDs = append(houseIDs, outages[i].Hardware.Node.HouseId)
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		res, e := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, "failed to get addresses", http.StatusInternalServerError))
+			return
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for i := range outages {
+		outages[i].Hardware.Node.Address = addressMap[outages[i].Hardware.Node.HouseId]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": outages,
+		"Count": len(outages),
+	})
+}
+
+// HandlerGetHardwareOutages Возвращает простои оборудования за период from - to, по умолчанию 30 дней
+func (h *DefaultAvailabilityHandler) HandlerGetHardwareOutages(c *gin.Context) {
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	from, to, httpErr := parsePeriod(c, 30*24*time.Hour)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	outages, err := h.AvailabilityRepo.GetHardwareOutages(hardwareID, from, to)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get outages", http.StatusInternalServerError))
+		return
+	}
+
+	now := time.Now().Unix()
+
+	for i := range outages {
+		if outages[i].EndedAt.Valid {
+			outages[i].Duration = outages[i].EndedAt.Int64 - outages[i].StartedAt
+		} else {
+			outages[i].Duration = now - outages[i].StartedAt
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": outages,
+		"Count": len(outages),
+	})
+}
+
+// groupAvailability Суммирует доступность оборудования по узлам или домам с сохранением порядка
+func groupAvailability(items []models.Availability, by string) []models.Availability {
+	grouped := make([]models.Availability, 0)
+	index := make(map[int]int)
+
+	for _, item := range items {
+		key := int(item.HouseID)
+
+		if by == "node" {
+			key = item.Hardware.Node.ID
+		}
+
+		i, ok := index[key]
+		if !ok {
+			group := models.Availability{HouseID: item.HouseID}
+
+			if by == "node" {
+				node := item.Hardware.Node
+				group.Node = &node
+			}
+
+			grouped = append(grouped, group)
+			i = len(grouped) - 1
+			index[key] = i
+		}
+
+		grouped[i].Monitored += item.Monitored
+		grouped[i].Downtime += item.Downtime
+		grouped[i].Outages += item.Outages
+	}
+
+	return grouped
+}
+
+// parsePeriod Разбирает параметры from и to (unix time). По умолчанию to - текущее время,
+// from - на period раньше. Конец периода не может быть позже текущего времени
+func parsePeriod(c *gin.Context, period time.Duration) (int64, int64, *errors.HTTPError) {
+	now := time.Now()
+
+	to, err := strconv.ParseInt(c.DefaultQuery("to", strconv.FormatInt(now.Unix(), 10)), 10, 64)
+	if err != nil {
+		return 0, 0, errors.NewHTTPError(err, "failed to parse query(to) to int", http.StatusBadRequest)
+	}
+
+	from, err := strconv.ParseInt(c.DefaultQuery("from", strconv.FormatInt(now.Add(-period).Unix(), 10)), 10, 64)
+	if err != nil {
+		return 0, 0, errors.NewHTTPError(err, "failed to parse query(from) to int", http.StatusBadRequest)
+	}
+
+	if to > now.Unix() {
+		to = now.Unix()
+	}
+
+	if from >= to {
+		return 0, 0, errors.NewHTTPError(goErrors.New("from must be less than to"), "invalid period", http.StatusBadRequest)
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,449 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CableHandler interface {
+	HandlerGetCables(c *gin.Context)
+	HandlerGetCable(c *gin.Context)
+	HandlerGetNodeCables(c *gin.Context)
+	HandlerCreateCable(c *gin.Context)
+	HandlerEditCable(c *gin.Context)
+	HandlerDeleteCable(c *gin.Context)
+	HandlerGetHardwareNeighbours(c *gin.Context)
+	HandlerGetNodeTrace(c *gin.Context)
+}
+
+type DefaultCableHandler struct {
+	Privilege      Privilege
+	CableRepo      database.CableRepository
+	FiberRepo      database.FiberRepository
+	EventRepo      database.EventRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+func NewCableHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) CableHandler {
+	return &DefaultCableHandler{
+		Privilege: &DefaultPrivilege{},
+		CableRepo: &database.DefaultCableRepository{
+			Database: *db,
+		},
+		FiberRepo: &database.DefaultFiberRepository{
+			Database: *db,
+		},
+		EventRepo: &database.DefaultEventRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+// HandlerGetCables Возвращает кабели, при query(nodeId) - только подключенные к узлу и его оборудованию
+func (h *DefaultCableHandler) HandlerGetCables(c *gin.Context) {
+	nodeID, err := strconv.Atoi(c.DefaultQuery("nodeId", "0"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(nodeId) to int", http.StatusBadRequest))
+		return
+	}
+
+	h.getCables(c, nodeID)
+}
+
+// HandlerGetNodeCables Возвращает кабели, подключенные к узлу и его оборудованию
+func (h *DefaultCableHandler) HandlerGetNodeCables(c *gin.Context) {
+	nodeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	h.getCables(c, nodeID)
+}
+
+func (h *DefaultCableHandler) getCables(c *gin.Context, nodeID int) {
+	cables, err := h.CableRepo.GetCables(nodeID, 0)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get cables", http.StatusInternalServerError))
+		return
+	}
+
+	var endpoints []*models.CableEndpoint
+
+	for i := range cables {
+		endpoints = append(endpoints, &cables[i].A, &cables[i].B)
+	}
+
+	if httpErr := setEndpointAddresses(c, h.AddressService, h.Metadata, endpoints); httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": cables,
+		"Count": len(cables),
+	})
+}
+
+func (h *DefaultCableHandler) HandlerGetCable(c *gin.Context) {
+	cableID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	cable, err := h.CableRepo.GetCable(cableID)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "cable not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get cable", http.StatusInternalServerError))
+		return
+	}
+
+	if httpErr := setEndpointAddresses(c, h.AddressService, h.Metadata, []*models.CableEndpoint{&cable.A, &cable.B}); httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, cable)
+}
+
+func (h *DefaultCableHandler) HandlerCreateCable(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	cable, httpErr := h.bindCable(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	cable.CreatedAt = time.Now().Unix()
+
+	if err := h.CableRepo.CreateCable(&cable); err != nil {
+		c.Error(cableError(err, "failed to create cable"))
+		return
+	}
+
+	h.createCableEvent(c, session.User.Id, cable.ID, "Создание кабеля")
+}
+
+func (h *DefaultCableHandler) HandlerEditCable(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	cable, httpErr := h.bindCable(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	splices, err := h.FiberRepo.GetFiberSplices(0, cable.ID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get splices", http.StatusInternalServerError))
+		return
+	}
+
+	for _, splice := range splices {
+		if !cableFitsSplice(cable, splice) {
+			c.Error(errors.NewHTTPError(nil, fmt.Sprintf("cable fibers are used in splice %d", splice.ID), http.StatusConflict))
+			return
+		}
+	}
+
+	cable.UpdatedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+
+	if err = h.CableRepo.EditCable(&cable); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "cable not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(cableError(err, "failed to edit cable"))
+		return
+	}
+
+	h.createCableEvent(c, session.User.Id, cable.ID, "Изменение кабеля")
+}
+
+func (h *DefaultCableHandler) HandlerDeleteCable(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	cableID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	cable, err := h.CableRepo.GetCable(cableID)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "cable not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get cable", http.StatusInternalServerError))
+		return
+	}
+
+	if err = h.CableRepo.DeleteCable(cableID); err != nil {
+		if isForeignKeyViolation(err) {
+			c.Error(errors.NewHTTPError(err, "cable has splices", http.StatusConflict))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to delete cable", http.StatusInternalServerError))
+		return
+	}
+
+	h.createEvent(c, session.User.Id, *cable, "Удаление кабеля")
+
+	c.JSON(http.StatusOK, cable)
+}
+
+// HandlerGetHardwareNeighbours Возвращает, что подключено к портам оборудования с другой стороны кабелей
+func (h *DefaultCableHandler) HandlerGetHardwareNeighbours(c *gin.Context) {
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	cables, err := h.CableRepo.GetCables(0, hardwareID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get cables", http.StatusInternalServerError))
+		return
+	}
+
+	neighbours := make([]models.Neighbour, 0, len(cables))
+
+	for _, cable := range cables {
+		neighbour := models.Neighbour{Cable: cable, Local: cable.A, Remote: cable.B}
+
+		if cable.A.Hardware == nil || cable.A.Hardware.ID != hardwareID {
+			neighbour.Local, neighbour.Remote = cable.B, cable.A
+		}
+
+		neighbours = append(neighbours, neighbour)
+	}
+
+	var endpoints []*models.CableEndpoint
+
+	for i := range neighbours {
+		endpoints = append(endpoints, &neighbours[i].Remote)
+	}
+
+	if httpErr := setEndpointAddresses(c, h.AddressService, h.Metadata, endpoints); httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": neighbours,
+		"Count": len(neighbours),
+	})
+}
+
+// HandlerGetNodeTrace Возвращает путь по кабелям от узла (обычно домового) до магистрального узла
+func (h *DefaultCableHandler) HandlerGetNodeTrace(c *gin.Context) {
+	nodeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	trace, err := h.CableRepo.TraceToBackbone(nodeID)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "path to backbone not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to trace cables", http.StatusInternalServerError))
+		return
+	}
+
+	backbone := models.CableEndpoint{Node: trace.Backbone}
+	endpoints := []*models.CableEndpoint{&backbone}
+
+	for i := range trace.Hops {
+		endpoints = append(endpoints, &trace.Hops[i].From, &trace.Hops[i].To)
+	}
+
+	if httpErr := setEndpointAddresses(c, h.AddressService, h.Metadata, endpoints); httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	trace.Backbone = backbone.Node
+
+	c.JSON(http.StatusOK, trace)
+}
+
+// bindCable Разбирает кабель из тела запроса, проверяет данные и то, что порты концов свободны
+func (h *DefaultCableHandler) bindCable(c *gin.Context) (models.Cable, *errors.HTTPError) {
+	var cable models.Cable
+
+	if err := c.BindJSON(&cable); err != nil {
+		return cable, errors.NewHTTPError(err, "invalid json", http.StatusBadRequest)
+	}
+
+	if !h.CableRepo.ValidateCable(cable) {
+		return cable, errors.NewHTTPError(nil, "invalid cable data", http.StatusBadRequest)
+	}
+
+	for _, endpoint := range []models.CableEndpoint{cable.A, cable.B} {
+		if endpoint.Port == nil || endpoint.Port.ID == 0 {
+			continue
+		}
+
+		exists, err := h.CableRepo.PortHasCable(endpoint.Port.ID, cable.ID)
+		if err != nil {
+			return cable, errors.NewHTTPError(err, "failed to check port cable", http.StatusInternalServerError)
+		}
+
+		if exists {
+			return cable, errors.NewHTTPError(nil, fmt.Sprintf("port %d already has a cable", endpoint.Port.ID), http.StatusConflict)
+		}
+	}
+
+	return cable, nil
+}
+
+// createCableEvent Перечитывает сохраненный кабель, чтобы вернуть концы с узлами, и пишет событие
+func (h *DefaultCableHandler) createCableEvent(c *gin.Context, userID int32, cableID int, action string) {
+	cable, err := h.CableRepo.GetCable(cableID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get cable", http.StatusInternalServerError))
+		return
+	}
+
+	h.createEvent(c, userID, *cable, action)
+
+	c.JSON(http.StatusOK, cable)
+}
+
+func (h *DefaultCableHandler) createEvent(c *gin.Context, userID int32, cable models.Cable, action string) {
+	description := fmt.Sprintf("%s %s: %s - %s", action, models.CableMediums[cable.Medium], cable.A.Node.Name, cable.B.Node.Name)
+
+	if cable.Label.Valid && cable.Label.String != "" {
+		description = fmt.Sprintf("%s (%s)", description, cable.Label.String)
+	}
+
+	for _, endpoint := range []models.CableEndpoint{cable.A, cable.B} {
+		event := models.Event{
+			HouseId:     endpoint.Node.HouseId,
+			Node:        &models.Node{ID: endpoint.Node.ID},
+			UserId:      userID,
+			Description: description,
+			CreatedAt:   time.Now().Unix(),
+		}
+
+		if endpoint.Hardware != nil {
+			event.Hardware = &models.Hardware{ID: endpoint.Hardware.ID}
+		}
+
+		if err := h.EventRepo.CreateEvent(event); err != nil {
+			c.Error(errors.NewHTTPError(err, "failed to create event", http.StatusInternalServerError))
+			return
+		}
+	}
+}
+
+// setEndpointAddresses Заполняет адреса узлов концов кабелей одним запросом к сервису адресов
+func setEndpointAddresses(c *gin.Context, service addresspb.AddressServiceClient, metadata utils.Metadata, endpoints []*models.CableEndpoint) *errors.HTTPError {
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for _, endpoint := range endpoints {
+		if _, ok := houseIDSet[endpoint.Node.HouseId]; !ok {
+			houseIDSet[endpoint.Node.HouseId] = struct{}{}
+			houseIDs = append(houseIDs, endpoint.Node.HouseId)
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := metadata.SetAuthorizationHeader(c)
+
+		res, err := service.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if err != nil {
+			return errors.NewHTTPError(err, "failed to get addresses", http.StatusInternalServerError)
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for _, endpoint := range endpoints {
+		endpoint.Node.Address = addressMap[endpoint.Node.HouseId]
+	}
+
+	return nil
+}
+
+// cableFitsSplice Проверяет, что после изменения кабель по-прежнему заходит в узел сварки
+// и в нем есть сваренное волокно
+func cableFitsSplice(cable models.Cable, splice models.FiberSplice) bool {
+	for _, end := range []models.FiberEnd{splice.A, splice.B} {
+		if end.Cable.ID != cable.ID {
+			continue
+		}
+
+		if !cable.FiberCount.Valid || end.Fiber.Number > int(cable.FiberCount.Int32) {
+			return false
+		}
+	}
+
+	for _, endpoint := range []models.CableEndpoint{cable.A, cable.B} {
+		if (endpoint.Port == nil || endpoint.Port.ID == 0) && endpoint.Node.ID == splice.Node.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cableError Порт уже занят другим кабелем или конец ссылается на несуществующий узел или порт
+func cableError(err error, message string) *errors.HTTPError {
+	if isUniqueViolation(err) {
+		return errors.NewHTTPError(err, "port already has a cable", http.StatusConflict)
+	}
+
+	if isForeignKeyViolation(err) {
+		return errors.NewHTTPError(err, "node or port not found", http.StatusBadRequest)
+	}
+
+	return errors.NewHTTPError(err, message, http.StatusInternalServerError)
+}
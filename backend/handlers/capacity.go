@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type CapacityHandler interface {
+	HandlerGetCapacity(c *gin.Context)
+	HandlerGetHouseCapacity(c *gin.Context)
+	HandlerGetLowCapacity(c *gin.Context)
+	HandlerGetLowCapacityExcel(c *gin.Context)
+}
+
+type DefaultCapacityHandler struct {
+	CapacityRepo   database.CapacityRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+	Threshold      int
+}
+
+func NewCapacityHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) CapacityHandler {
+	threshold, err := strconv.Atoi(os.Getenv("CAPACITY_FREE_THRESHOLD"))
+	if err != nil {
+		threshold = 4
+	}
+
+	return &DefaultCapacityHandler{
+		CapacityRepo: &database.DefaultCapacityRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+		Threshold:      threshold,
+	}
+}
+
+// HandlerGetCapacity Возвращает свободные и занятые порты по оборудованию, узлам, домам или зонам
+// (by = hardware, node, house, zone). Можно ограничить выборку параметрами nodeId, houseId и zone
+func (h *DefaultCapacityHandler) HandlerGetCapacity(c *gin.Context) {
+	nodeID, err := strconv.Atoi(c.DefaultQuery("nodeId", "0"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(nodeId) to int", http.StatusBadRequest))
+		return
+	}
+
+	houseID, err := strconv.Atoi(c.DefaultQuery("houseId", "0"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(houseId) to int", http.StatusBadRequest))
+		return
+	}
+
+	by := c.DefaultQuery("by", "hardware")
+	if by != "hardware" && by != "node" && by != "house" && by != "zone" {
+		c.Error(errors.NewHTTPError(nil, "by must be hardware, node, house or zone", http.StatusBadRequest))
+		return
+	}
+
+	items, httpErr := h.getCapacity(c, nodeID, houseID, c.Query("zone"), by)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": items,
+		"Count": len(items),
+	})
+}
+
+// HandlerGetHouseCapacity Отвечает, может ли дом принять новых абонентов: итог по дому и емкость
+// каждого коммутатора в нем
+func (h *DefaultCapacityHandler) HandlerGetHouseCapacity(c *gin.Context) {
+	houseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	items, httpErr := h.getCapacity(c, 0, houseID, "", "hardware")
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	total := models.Capacity{HouseID: int32(houseID)}
+
+	if grouped := groupCapacity(items, "house"); len(grouped) > 0 {
+		total = grouped[0]
+		total.Address = items[0].Address
+		setCapacityUtilization(&total)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Capacity": total,
+		"Items":    items,
+		"Count":    len(items),
+	})
+}
+
+// HandlerGetLowCapacity Возвращает дома, где свободных портов меньше порога. Порог берется
+// из CAPACITY_FREE_THRESHOLD, его можно переопределить параметром threshold
+func (h *DefaultCapacityHandler) HandlerGetLowCapacity(c *gin.Context) {
+	threshold, items, httpErr := h.getLowCapacity(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Threshold": threshold,
+		"Items":     items,
+		"Count":     len(items),
+	})
+}
+
+// HandlerGetLowCapacityExcel Выгружает дома с низкой емкостью портов в XLSX
+func (h *DefaultCapacityHandler) HandlerGetLowCapacityExcel(c *gin.Context) {
+	threshold, items, httpErr := h.getLowCapacity(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	f, err := generateCapacityExcel(threshold, items)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to generate Excel", http.StatusInternalServerError))
+		return
+	}
+	defer f.Close()
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="capacity_%s.xlsx"`, time.Now().Format("20060102_150405")))
+
+	if err = f.Write(c.Writer); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to write Excel", http.StatusInternalServerError))
+	}
+}
+
+func (h *DefaultCapacityHandler) getLowCapacity(c *gin.Context) (int, []models.Capacity, *errors.HTTPError) {
+	threshold, err := strconv.Atoi(c.DefaultQuery("threshold", strconv.Itoa(h.Threshold)))
+	if err != nil || threshold < 0 {
+		return 0, nil, errors.NewHTTPError(err, "threshold must be a non-negative number of ports", http.StatusBadRequest)
+	}
+
+	houses, httpErr := h.getCapacity(c, 0, 0, c.Query("zone"), "house")
+	if httpErr != nil {
+		return 0, nil, httpErr
+	}
+
+	items := make([]models.Capacity, 0)
+
+	for _, house := range houses {
+		if house.Free < threshold {
+			items = append(items, house)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Free < items[j].Free
+	})
+
+	return threshold, items, nil
+}
+
+func (h *DefaultCapacityHandler) getCapacity(c *gin.Context, nodeID int, houseID int, zone string, by string) ([]models.Capacity, *errors.HTTPError) {
+	items, err := h.CapacityRepo.GetPortCapacity(nodeID, houseID, zone)
+	if err != nil {
+		return nil, errors.NewHTTPError(err, "failed to get port capacity", http.StatusInternalServerError)
+	}
+
+	if by != "hardware" {
+		items = groupCapacity(items, by)
+	}
+
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for i := range items {
+		setCapacityUtilization(&items[i])
+
+		if by == "zone" {
+			continue
+		}
+
+		if _, ok := houseIDSet[items[i].HouseID]; !ok {
+			houseIDSet[items[i].HouseID] = struct{}{}
+			houseIDs = append(houseIDs, items[i].HouseID)
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		res, e := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if e != nil {
+			return nil, errors.NewHTTPError(e, "failed to get addresses", http.StatusInternalServerError)
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for i := range items {
+		if by != "zone" {
+			items[i].Address = addressMap[items[i].HouseID]
+		}
+	}
+
+	return items, nil
+}
+
+// groupCapacity Суммирует емкость оборудования по узлам, домам или зонам. Зона дома берется
+// из первого узла, в котором есть оборудование
+func groupCapacity(items []models.Capacity, by string) []models.Capacity {
+	grouped := make([]models.Capacity, 0)
+	index := make(map[string]int)
+
+	for _, item := range items {
+		key := strconv.Itoa(int(item.HouseID))
+
+		switch by {
+		case "node":
+			key = strconv.Itoa(item.Hardware.Node.ID)
+		case "zone":
+			key = item.Zone.String
+		}
+
+		i, ok := index[key]
+		if !ok {
+			group := models.Capacity{Zone: item.Zone}
+
+			switch by {
+			case "node":
+				node := item.Hardware.Node
+				group.Node = &node
+				group.HouseID = item.HouseID
+			case "house":
+				group.HouseID = item.HouseID
+			}
+
+			grouped = append(grouped, group)
+			i = len(grouped) - 1
+			index[key] = i
+		}
+
+		grouped[i].HardwareCount += item.HardwareCount
+		grouped[i].UnpolledCount += item.UnpolledCount
+		grouped[i].Total += item.Total
+		grouped[i].Used += item.Used
+		grouped[i].Unknown += item.Unknown
+	}
+
+	return grouped
+}
+
+// setCapacityUtilization Считает свободные порты и загрузку. Порты неопрошенного оборудования
+// в свободные не входят
+func setCapacityUtilization(item *models.Capacity) {
+	item.Free = item.Total - item.Used - item.Unknown
+
+	if item.Total > 0 {
+		item.Utilization = float64(item.Used) * 100 / float64(item.Total)
+	}
+}
+
+func generateCapacityExcel(threshold int, items []models.Capacity) (*excelize.File, error) {
+	f := excelize.NewFile()
+	sheet := fmt.Sprintf("Свободно меньше %d", threshold)
+
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+
+	headers := []interface{}{
+		"Адрес", "Зона", "Коммутаторов", "Не опрошено", "Всего портов", "Занято", "Неизвестно", "Свободно", "Загрузка, %",
+	}
+
+	if err := f.SetSheetRow(sheet, "A1", &headers); err != nil {
+		return nil, err
+	}
+
+	for i, item := range items {
+		row := []interface{}{
+			formatAddress(item.Address),
+			item.Zone.String,
+			item.HardwareCount,
+			item.UnpolledCount,
+			item.Total,
+			item.Used,
+			item.Unknown,
+			item.Free,
+			fmt.Sprintf("%.1f", item.Utilization),
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := f.SetColWidth(sheet, "A", "A", 50); err != nil {
+		return nil, err
+	}
+
+	if err := f.SetColWidth(sheet, "B", "I", 18); err != nil {
+		return nil, err
+	}
+
+	if err := f.AutoFilter(sheet, fmt.Sprintf("A1:I%d", len(items)+1), nil); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
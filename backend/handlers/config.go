@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"backend/backup"
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+type ConfigHandler interface {
+	HandlerGetHardwareConfigs(c *gin.Context)
+	HandlerGetHardwareConfig(c *gin.Context)
+	HandlerGetHardwareConfigDiff(c *gin.Context)
+	HandlerBackupHardwareConfig(c *gin.Context)
+}
+
+type DefaultConfigHandler struct {
+	Privilege  Privilege
+	ConfigRepo database.ConfigRepository
+	Backuper   backup.Backuper
+}
+
+func NewConfigHandler(backuper backup.Backuper, db *database.Database) ConfigHandler {
+	return &DefaultConfigHandler{
+		Privilege: &DefaultPrivilege{},
+		ConfigRepo: &database.DefaultConfigRepository{
+			Database: *db,
+		},
+		Backuper: backuper,
+	}
+}
+
+// HandlerGetHardwareConfigs Возвращает сохраненные версии конфигурации оборудования, начиная с последней.
+// Конфигурации содержат SNMP community и ключи, поэтому доступны только оператору и администратору
+func (h *DefaultConfigHandler) HandlerGetHardwareConfigs(c *gin.Context) {
+	_, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	configs, err := h.ConfigRepo.GetHardwareConfigs(hardwareID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get configs", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": configs,
+		"Count": len(configs),
+	})
+}
+
+// HandlerGetHardwareConfig Отдает файл конфигурации указанной версии
+func (h *DefaultConfigHandler) HandlerGetHardwareConfig(c *gin.Context) {
+	_, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(version) to int", http.StatusBadRequest))
+		return
+	}
+
+	config, content, httpErr := h.getConfigContent(hardwareID, version)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="hardware_%d_v%d.cfg"`, hardwareID, config.Version))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", content)
+}
+
+// HandlerGetHardwareConfigDiff Возвращает unified diff между версиями from и to.
+// По умолчанию сравниваются две последние версии
+func (h *DefaultConfigHandler) HandlerGetHardwareConfigDiff(c *gin.Context) {
+	_, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	to, err := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(to) to int", http.StatusBadRequest))
+		return
+	}
+
+	toConfig, toContent, httpErr := h.getConfigContent(hardwareID, to)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	from, err := strconv.Atoi(c.DefaultQuery("from", strconv.Itoa(toConfig.Version-1)))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(from) to int", http.StatusBadRequest))
+		return
+	}
+
+	if from <= 0 {
+		c.Error(errors.NewHTTPError(nil, "there is no previous version to compare", http.StatusNotFound))
+		return
+	}
+
+	fromConfig, fromContent, httpErr := h.getConfigContent(hardwareID, from)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	diff, err := backup.UnifiedDiff(*fromConfig, fromContent, *toConfig, toContent)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to build diff", http.StatusInternalServerError))
+		return
+	}
+
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(diff))
+}
+
+// HandlerBackupHardwareConfig Снимает конфигурацию сразу, не дожидаясь расписания
+func (h *DefaultConfigHandler) HandlerBackupHardwareConfig(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	result, err := h.Backuper.BackupHardware(hardwareID, session.User.Id)
+	if err != nil {
+		if goErrors.Is(err, backup.ErrNoSaveConfig) {
+			c.Error(errors.NewHTTPError(err, "hardware has no ip address or save config oid", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to backup config", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *DefaultConfigHandler) getConfigContent(hardwareID int, version int) (*models.HardwareConfig, []byte, *errors.HTTPError) {
+	config, err := h.ConfigRepo.GetHardwareConfig(hardwareID, version)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, nil, errors.NewHTTPError(err, "config not found", http.StatusNotFound)
+		}
+
+		return nil, nil, errors.NewHTTPError(err, "failed to get config", http.StatusInternalServerError)
+	}
+
+	content, err := os.ReadFile(config.Path)
+	if err != nil {
+		return nil, nil, errors.NewHTTPError(err, "failed to read config file", http.StatusInternalServerError)
+	}
+
+	return config, content, nil
+}
@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type FiberHandler interface {
+	HandlerGetCableFibers(c *gin.Context)
+	HandlerGetFiberTrace(c *gin.Context)
+	HandlerGetNodeSplices(c *gin.Context)
+	HandlerCreateFiberSplice(c *gin.Context)
+	HandlerEditFiberSplice(c *gin.Context)
+	HandlerDeleteFiberSplice(c *gin.Context)
+	HandlerGetFiberAttenuation(c *gin.Context)
+	HandlerEditFiberAttenuation(c *gin.Context)
+}
+
+type DefaultFiberHandler struct {
+	Privilege      Privilege
+	FiberRepo      database.FiberRepository
+	CableRepo      database.CableRepository
+	EventRepo      database.EventRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+func NewFiberHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) FiberHandler {
+	return &DefaultFiberHandler{
+		Privilege: &DefaultPrivilege{},
+		FiberRepo: &database.DefaultFiberRepository{
+			Database: *db,
+		},
+		CableRepo: &database.DefaultCableRepository{
+			Database: *db,
+		},
+		EventRepo: &database.DefaultEventRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+// HandlerGetCableFibers Возвращает волокна оптического кабеля с цветами и сварками на концах
+func (h *DefaultFiberHandler) HandlerGetCableFibers(c *gin.Context) {
+	cableID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	cable, err := h.CableRepo.GetCable(cableID)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "cable not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get cable", http.StatusInternalServerError))
+		return
+	}
+
+	splices, err := h.FiberRepo.GetFiberSplices(0, cableID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get splices", http.StatusInternalServerError))
+		return
+	}
+
+	fibers := make([]models.CableFiber, 0, cable.FiberCount.Int32)
+
+	for number := 1; number <= int(cable.FiberCount.Int32); number++ {
+		fiber := models.CableFiber{Fiber: models.NewFiber(number), Splices: make([]models.FiberSplice, 0)}
+
+		for _, splice := range splices {
+			if (splice.A.Cable.ID == cableID && splice.A.Fiber.Number == number) ||
+				(splice.B.Cable.ID == cableID && splice.B.Fiber.Number == number) {
+				fiber.Splices = append(fiber.Splices, splice)
+			}
+		}
+
+		fibers = append(fibers, fiber)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Cable": cable,
+		"Items": fibers,
+		"Count": len(fibers),
+	})
+}
+
+// HandlerGetFiberTrace Возвращает путь волокна от конца до конца и расчет затухания.
+// Параметры: wavelength - длина волны в нм (по умолчанию все из справочника), txPower - мощность
+// передатчика в дБм, rxMin и rxMax - диапазон входа приемника в дБм (например, оптического приемника OR-826H)
+func (h *DefaultFiberHandler) HandlerGetFiberTrace(c *gin.Context) {
+	cableID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	fiber, err := strconv.Atoi(c.Param("fiber"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(fiber) to int", http.StatusBadRequest))
+		return
+	}
+
+	wavelength, err := strconv.Atoi(c.DefaultQuery("wavelength", "0"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(wavelength) to int", http.StatusBadRequest))
+		return
+	}
+
+	var levels [3]sql.NullFloat64
+
+	for i, key := range []string{"txPower", "rxMin", "rxMax"} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+
+		level, e := strconv.ParseFloat(value, 64)
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, fmt.Sprintf("failed to parse query(%s) to float", key), http.StatusBadRequest))
+			return
+		}
+
+		levels[i] = sql.NullFloat64{Float64: level, Valid: true}
+	}
+
+	txPower, rxMin, rxMax := levels[0], levels[1], levels[2]
+
+	trace, err := h.FiberRepo.TraceFiber(cableID, fiber)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "fiber not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to trace fiber", http.StatusInternalServerError))
+		return
+	}
+
+	attenuation, err := h.FiberRepo.GetFiberAttenuation()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get fiber attenuation", http.StatusInternalServerError))
+		return
+	}
+
+	trace.Budgets = database.BuildLossBudgets(*trace, attenuation, wavelength)
+
+	if wavelength != 0 && len(trace.Budgets) == 0 {
+		c.Error(errors.NewHTTPError(nil, fmt.Sprintf("attenuation for wavelength %d is not set", wavelength), http.StatusBadRequest))
+		return
+	}
+
+	if txPower.Valid {
+		for i := range trace.Budgets {
+			budget := &trace.Budgets[i]
+			received := txPower.Float64 - budget.TotalLoss
+
+			budget.Received = sql.NullFloat64{Float64: received, Valid: true}
+
+			if rxMin.Valid {
+				budget.Margin = sql.NullFloat64{Float64: received - rxMin.Float64, Valid: true}
+				budget.IsSufficient = sql.NullBool{
+					Bool:  received >= rxMin.Float64 && (!rxMax.Valid || received <= rxMax.Float64),
+					Valid: true,
+				}
+			}
+		}
+	}
+
+	var endpoints []*models.CableEndpoint
+
+	for i := range trace.Hops {
+		endpoints = append(endpoints, &trace.Hops[i].From, &trace.Hops[i].To)
+	}
+
+	if httpErr := setEndpointAddresses(c, h.AddressService, h.Metadata, endpoints); httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, trace)
+}
+
+// HandlerGetNodeSplices Возвращает сварки волокон в пассивном узле
+func (h *DefaultFiberHandler) HandlerGetNodeSplices(c *gin.Context) {
+	nodeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	splices, err := h.FiberRepo.GetFiberSplices(nodeID, 0)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get splices", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": splices,
+		"Count": len(splices),
+	})
+}
+
+func (h *DefaultFiberHandler) HandlerCreateFiberSplice(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	splice, httpErr := h.bindFiberSplice(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	splice.CreatedAt = time.Now().Unix()
+
+	if err := h.FiberRepo.CreateFiberSplice(&splice); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to create splice", http.StatusInternalServerError))
+		return
+	}
+
+	h.createSpliceEvent(c, session.User.Id, splice, "Создание сварки")
+
+	c.JSON(http.StatusOK, splice)
+}
+
+func (h *DefaultFiberHandler) HandlerEditFiberSplice(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	splice, httpErr := h.bindFiberSplice(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	splice.UpdatedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+
+	if err := h.FiberRepo.EditFiberSplice(&splice); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "splice not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to edit splice", http.StatusInternalServerError))
+		return
+	}
+
+	h.createSpliceEvent(c, session.User.Id, splice, "Изменение сварки")
+
+	c.JSON(http.StatusOK, splice)
+}
+
+func (h *DefaultFiberHandler) HandlerDeleteFiberSplice(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	spliceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	splice, err := h.FiberRepo.GetFiberSplice(spliceID)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "splice not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get splice", http.StatusInternalServerError))
+		return
+	}
+
+	if err = h.FiberRepo.DeleteFiberSplice(spliceID); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to delete splice", http.StatusInternalServerError))
+		return
+	}
+
+	h.createSpliceEvent(c, session.User.Id, *splice, "Удаление сварки")
+
+	c.JSON(http.StatusOK, splice)
+}
+
+// HandlerGetFiberAttenuation Возвращает справочник затухания по средам и длинам волн
+func (h *DefaultFiberHandler) HandlerGetFiberAttenuation(c *gin.Context) {
+	items, err := h.FiberRepo.GetFiberAttenuation()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get fiber attenuation", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *DefaultFiberHandler) HandlerEditFiberAttenuation(c *gin.Context) {
+	_, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	var attenuation models.FiberAttenuation
+
+	if err := c.BindJSON(&attenuation); err != nil {
+		c.Error(errors.NewHTTPError(err, "invalid json", http.StatusBadRequest))
+		return
+	}
+
+	if !h.FiberRepo.ValidateFiberAttenuation(attenuation) {
+		c.Error(errors.NewHTTPError(nil, "invalid fiber attenuation data", http.StatusBadRequest))
+		return
+	}
+
+	attenuation.UpdatedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+
+	if err := h.FiberRepo.EditFiberAttenuation(&attenuation); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "fiber attenuation not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to edit fiber attenuation", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, attenuation)
+}
+
+// bindFiberSplice Разбирает сварку из тела запроса и проверяет, что оба кабеля оптические с одной средой,
+// заходят в пассивный узел сварки и их волокна в нем еще не сварены
+func (h *DefaultFiberHandler) bindFiberSplice(c *gin.Context) (models.FiberSplice, *errors.HTTPError) {
+	var splice models.FiberSplice
+
+	if err := c.BindJSON(&splice); err != nil {
+		return splice, errors.NewHTTPError(err, "invalid json", http.StatusBadRequest)
+	}
+
+	if !h.FiberRepo.ValidateFiberSplice(splice) {
+		return splice, errors.NewHTTPError(nil, "invalid splice data", http.StatusBadRequest)
+	}
+
+	for _, end := range []*models.FiberEnd{&splice.A, &splice.B} {
+		cable, err := h.CableRepo.GetCable(end.Cable.ID)
+		if err != nil {
+			if goErrors.Is(err, sql.ErrNoRows) {
+				return splice, errors.NewHTTPError(err, "cable not found", http.StatusNotFound)
+			}
+
+			return splice, errors.NewHTTPError(err, "failed to get cable", http.StatusInternalServerError)
+		}
+
+		if !cable.FiberCount.Valid {
+			return splice, errors.NewHTTPError(nil, fmt.Sprintf("cable %d is not a fiber cable", cable.ID), http.StatusBadRequest)
+		}
+
+		if end.Fiber.Number > int(cable.FiberCount.Int32) {
+			return splice, errors.NewHTTPError(nil, fmt.Sprintf("cable %d has only %d fibers", cable.ID, cable.FiberCount.Int32), http.StatusBadRequest)
+		}
+
+		var node *models.Node
+
+		for _, endpoint := range []models.CableEndpoint{cable.A, cable.B} {
+			if endpoint.Port == nil && endpoint.Node.ID == splice.Node.ID {
+				node = &endpoint.Node
+			}
+		}
+
+		if node == nil {
+			return splice, errors.NewHTTPError(nil, fmt.Sprintf("cable %d does not end in node %d", cable.ID, splice.Node.ID), http.StatusBadRequest)
+		}
+
+		if !node.IsPassive {
+			return splice, errors.NewHTTPError(nil, "splices are allowed only in passive nodes", http.StatusBadRequest)
+		}
+
+		splice.Node = *node
+		end.Cable = *cable
+		end.Fiber = models.NewFiber(end.Fiber.Number)
+	}
+
+	if splice.A.Cable.Medium != splice.B.Cable.Medium {
+		return splice, errors.NewHTTPError(nil, "cables have different medium", http.StatusBadRequest)
+	}
+
+	for _, end := range []models.FiberEnd{splice.A, splice.B} {
+		exists, err := h.FiberRepo.FiberHasSplice(splice.Node.ID, end.Cable.ID, end.Fiber.Number, splice.ID)
+		if err != nil {
+			return splice, errors.NewHTTPError(err, "failed to check fiber splice", http.StatusInternalServerError)
+		}
+
+		if exists {
+			return splice, errors.NewHTTPError(nil, fmt.Sprintf("fiber %d of cable %d is already spliced", end.Fiber.Number, end.Cable.ID), http.StatusConflict)
+		}
+	}
+
+	return splice, nil
+}
+
+func (h *DefaultFiberHandler) createSpliceEvent(c *gin.Context, userID int32, splice models.FiberSplice, action string) {
+	if err := h.EventRepo.CreateEvent(models.Event{
+		HouseId: splice.Node.HouseId,
+		Node:    &models.Node{ID: splice.Node.ID},
+		UserId:  userID,
+		Description: fmt.Sprintf("%s: кабель %s волокно %d - кабель %s волокно %d", action,
+			cableName(splice.A.Cable), splice.A.Fiber.Number, cableName(splice.B.Cable), splice.B.Fiber.Number),
+		CreatedAt: time.Now().Unix(),
+	}); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to create event", http.StatusInternalServerError))
+	}
+}
+
+// cableName Маркировка кабеля, если она есть, иначе его номер
+func cableName(cable models.Cable) string {
+	if cable.Label.Valid && cable.Label.String != "" {
+		return cable.Label.String
+	}
+
+	return fmt.Sprintf("#%d", cable.ID)
+}
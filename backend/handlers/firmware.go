@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"net/http"
+	"time"
+)
+
+type FirmwareHandler interface {
+	HandlerGetFirmwareCompliance(c *gin.Context)
+	HandlerGetFirmwareComplianceExcel(c *gin.Context)
+}
+
+type DefaultFirmwareHandler struct {
+	FirmwareRepo   database.FirmwareRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+func NewFirmwareHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) FirmwareHandler {
+	return &DefaultFirmwareHandler{
+		FirmwareRepo: &database.DefaultFirmwareRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+// HandlerGetFirmwareCompliance Возвращает сводку по моделям и оборудование, прошивка которого
+// не совпадает с целевой, сгруппированное по модели, зоне и владельцу
+func (h *DefaultFirmwareHandler) HandlerGetFirmwareCompliance(c *gin.Context) {
+	report, httpErr := h.getFirmwareCompliance(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// HandlerGetFirmwareComplianceExcel Выгружает отчет о несоответствии прошивок в XLSX для бригады обновления
+func (h *DefaultFirmwareHandler) HandlerGetFirmwareComplianceExcel(c *gin.Context) {
+	report, httpErr := h.getFirmwareCompliance(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	f, err := generateFirmwareExcel(report)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to generate Excel", http.StatusInternalServerError))
+		return
+	}
+	defer f.Close()
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="firmware_%s.xlsx"`, time.Now().Format("20060102_150405")))
+
+	if err = f.Write(c.Writer); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to write Excel", http.StatusInternalServerError))
+	}
+}
+
+func (h *DefaultFirmwareHandler) getFirmwareCompliance(c *gin.Context) (*models.FirmwareComplianceReport, *errors.HTTPError) {
+	report, err := h.FirmwareRepo.GetFirmwareCompliance()
+	if err != nil {
+		return nil, errors.NewHTTPError(err, "failed to get firmware compliance", http.StatusInternalServerError)
+	}
+
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for _, group := range report.Groups {
+		for _, item := range group.Hardware {
+			if _, ok := houseIDSet[item.Hardware.Node.HouseId]; !ok {
+				houseIDSet[item.Hardware.Node.HouseId] = struct{}{}
+				houseIDs = append(houseIDs, item.Hardware.Node.HouseId)
+			}
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		res, e := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if e != nil {
+			return nil, errors.NewHTTPError(e, "failed to get addresses", http.StatusInternalServerError)
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for i := range report.Groups {
+		for j := range report.Groups[i].Hardware {
+			node := &report.Groups[i].Hardware[j].Hardware.Node
+			node.Address = addressMap[node.HouseId]
+		}
+	}
+
+	return report, nil
+}
+
+var firmwareStatusNames = map[string]string{
+	models.FirmwareOutdated: "Устарела",
+	models.FirmwareUnknown:  "Не опрошено",
+}
+
+func generateFirmwareExcel(report *models.FirmwareComplianceReport) (*excelize.File, error) {
+	f := excelize.NewFile()
+
+	sheets := []struct {
+		name    string
+		headers []interface{}
+		rows    [][]interface{}
+	}{
+		{
+			name: "Несоответствие",
+			headers: []interface{}{
+				"Модель", "Зона", "Владелец", "Узел", "Адрес", "Оборудование", "IP адрес", "Прошивка",
+				"Целевая прошивка", "Статус", "Дата опроса",
+			},
+		},
+		{
+			name:    "Сводка",
+			headers: []interface{}{"Модель", "Целевая прошивка", "Всего", "Соответствует", "Устарела", "Не опрошено", "%"},
+		},
+	}
+
+	for _, group := range report.Groups {
+		for _, item := range group.Hardware {
+			hd := item.Hardware
+			polledAt := ""
+
+			if hd.FirmwarePolledAt.Valid {
+				polledAt = time.Unix(hd.FirmwarePolledAt.Int64, 0).Format("02.01.2006 15:04")
+			}
+
+			sheets[0].rows = append(sheets[0].rows, []interface{}{
+				group.Switch.Name,
+				group.Zone.String,
+				group.Owner.Value,
+				hd.Node.Name,
+				formatAddress(hd.Node.Address),
+				hd.Type.Value,
+				hd.IpAddress.String,
+				hd.Firmware.String,
+				group.Switch.TargetFirmware.String,
+				firmwareStatusNames[item.Status],
+				polledAt,
+			})
+		}
+	}
+
+	for _, summary := range report.Summary {
+		sheets[1].rows = append(sheets[1].rows, []interface{}{
+			summary.Switch.Name,
+			summary.Switch.TargetFirmware.String,
+			summary.Total,
+			summary.Compliant,
+			summary.Outdated,
+			summary.Unknown,
+			fmt.Sprintf("%.1f", summary.Percent),
+		})
+	}
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", sheet.name); err != nil {
+				return nil, err
+			}
+		} else if _, err := f.NewSheet(sheet.name); err != nil {
+			return nil, err
+		}
+
+		if err := f.SetSheetRow(sheet.name, "A1", &sheet.headers); err != nil {
+			return nil, err
+		}
+
+		for j := range sheet.rows {
+			cell, _ := excelize.CoordinatesToCellName(1, j+2)
+
+			if err := f.SetSheetRow(sheet.name, cell, &sheet.rows[j]); err != nil {
+				return nil, err
+			}
+		}
+
+		lastCol, _ := excelize.ColumnNumberToName(len(sheet.headers))
+
+		if err := f.SetColWidth(sheet.name, "A", lastCol, 25); err != nil {
+			return nil, err
+		}
+
+		if err := f.AutoFilter(sheet.name, fmt.Sprintf("A1:%s%d", lastCol, len(sheet.rows)+1), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
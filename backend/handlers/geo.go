@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"context"
+	"encoding/xml"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type GeoHandler interface {
+	HandlerGetNodesGeoJSON(c *gin.Context)
+	HandlerGetNodesKML(c *gin.Context)
+}
+
+type DefaultGeoHandler struct {
+	NodeRepo       database.NodeRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+type kml struct {
+	XMLName  xml.Name    `xml:"http://www.opengis.net/kml/2.2 kml"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name    string      `xml:"name"`
+	Folders []kmlFolder `xml:"Folder"`
+}
+
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name        string       `xml:"name"`
+	Description string       `xml:"description,omitempty"`
+	Point       *kmlGeometry `xml:"Point,omitempty"`
+	LineString  *kmlGeometry `xml:"LineString,omitempty"`
+}
+
+type kmlGeometry struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+func NewGeoHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) GeoHandler {
+	return &DefaultGeoHandler{
+		NodeRepo: &database.DefaultNodeRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+// HandlerGetNodesGeoJSON Возвращает узлы в области bbox=minLon,minLat,maxLon,maxLat как точки
+// и связи родитель-потомок как линии в формате GeoJSON
+func (h *DefaultGeoHandler) HandlerGetNodesGeoJSON(c *gin.Context) {
+	bbox, err := parseBBox(c.Query("bbox"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(bbox)", http.StatusBadRequest))
+		return
+	}
+
+	nodes, links, httpErr := h.getNodesInBBox(c, bbox)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	collection := models.GeoFeatureCollection{
+		Type:     "FeatureCollection",
+		BBox:     []float64{bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat},
+		Features: make([]models.GeoFeature, 0, len(nodes)+len(links)),
+	}
+
+	for _, node := range nodes {
+		properties := map[string]interface{}{
+			"kind":      "node",
+			"id":        node.ID,
+			"name":      node.Name,
+			"zone":      node.Zone.String,
+			"owner":     node.Owner.Value,
+			"isPassive": node.IsPassive,
+			"houseId":   node.HouseId,
+			"address":   formatAddress(node.Address),
+			"typeKey":   "",
+			"type":      "",
+			"parentId":  nil,
+		}
+
+		if node.Type != nil {
+			properties["typeKey"] = node.Type.Key
+			properties["type"] = node.Type.Value
+		}
+
+		if node.Parent != nil {
+			properties["parentId"] = node.Parent.ID
+		}
+
+		collection.Features = append(collection.Features, models.GeoFeature{
+			Type: "Feature",
+			ID:   fmt.Sprintf("node-%d", node.ID),
+			Geometry: models.GeoGeometry{
+				Type:        "Point",
+				Coordinates: []float64{node.Longitude.Float64, node.Latitude.Float64},
+			},
+			Properties: properties,
+		})
+	}
+
+	for _, link := range links {
+		collection.Features = append(collection.Features, models.GeoFeature{
+			Type: "Feature",
+			ID:   fmt.Sprintf("link-%d-%d", link.Parent.ID, link.Child.ID),
+			Geometry: models.GeoGeometry{
+				Type: "LineString",
+				Coordinates: [][]float64{
+					{link.Parent.Longitude.Float64, link.Parent.Latitude.Float64},
+					{link.Child.Longitude.Float64, link.Child.Latitude.Float64},
+				},
+			},
+			Properties: map[string]interface{}{
+				"kind":       "link",
+				"parentId":   link.Parent.ID,
+				"parentName": link.Parent.Name,
+				"childId":    link.Child.ID,
+				"childName":  link.Child.Name,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// HandlerGetNodesKML Выгружает узлы и связи в KML для мобильных приложений выездных бригад.
+// Без bbox выгружаются все узлы с координатами
+func (h *DefaultGeoHandler) HandlerGetNodesKML(c *gin.Context) {
+	bbox := models.BBox{MinLon: -180, MinLat: -90, MaxLon: 180, MaxLat: 90}
+
+	if value := c.Query("bbox"); value != "" {
+		var err error
+
+		if bbox, err = parseBBox(value); err != nil {
+			c.Error(errors.NewHTTPError(err, "failed to parse query(bbox)", http.StatusBadRequest))
+			return
+		}
+	}
+
+	nodes, links, httpErr := h.getNodesInBBox(c, bbox)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	nodeFolder := kmlFolder{Name: "Узлы"}
+	linkFolder := kmlFolder{Name: "Связи"}
+
+	for _, node := range nodes {
+		description := []string{formatAddress(node.Address)}
+
+		if node.Type != nil {
+			description = append(description, fmt.Sprintf("Тип: %s", node.Type.Value))
+		}
+
+		if node.IsPassive {
+			description = append(description, "Пассивный")
+		}
+
+		if node.Zone.Valid {
+			description = append(description, fmt.Sprintf("Зона: %s", node.Zone.String))
+		}
+
+		description = append(description, fmt.Sprintf("Владелец: %s", node.Owner.Value))
+
+		nodeFolder.Placemarks = append(nodeFolder.Placemarks, kmlPlacemark{
+			Name:        node.Name,
+			Description: strings.Join(description, "\n"),
+			Point:       &kmlGeometry{Coordinates: formatKMLCoordinates(node)},
+		})
+	}
+
+	for _, link := range links {
+		linkFolder.Placemarks = append(linkFolder.Placemarks, kmlPlacemark{
+			Name: fmt.Sprintf("%s - %s", link.Parent.Name, link.Child.Name),
+			LineString: &kmlGeometry{
+				Coordinates: formatKMLCoordinates(link.Parent) + " " + formatKMLCoordinates(link.Child),
+			},
+		})
+	}
+
+	document := kml{
+		Document: kmlDocument{
+			Name:    fmt.Sprintf("Узлы сети %s", time.Now().Format("02.01.2006")),
+			Folders: []kmlFolder{nodeFolder, linkFolder},
+		},
+	}
+
+	data, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to generate KML", http.StatusInternalServerError))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="nodes_%s.kml"`, time.Now().Format("20060102_150405")))
+	c.Data(http.StatusOK, "application/vnd.google-earth.kml+xml", append([]byte(xml.Header), data...))
+}
+
+func (h *DefaultGeoHandler) getNodesInBBox(c *gin.Context, bbox models.BBox) ([]models.Node, []models.NodeLink, *errors.HTTPError) {
+	nodes, err := h.NodeRepo.GetNodesInBBox(bbox)
+	if err != nil {
+		return nil, nil, errors.NewHTTPError(err, "failed to get nodes", http.StatusInternalServerError)
+	}
+
+	links, err := h.NodeRepo.GetNodeLinksInBBox(bbox)
+	if err != nil {
+		return nil, nil, errors.NewHTTPError(err, "failed to get node links", http.StatusInternalServerError)
+	}
+
+	ctx := h.Metadata.SetAuthorizationHeader(c)
+
+	if err = h.getAddressesForGeoNodes(ctx, nodes); err != nil {
+		return nil, nil, errors.NewHTTPError(err, "failed to get addresses", http.StatusInternalServerError)
+	}
+
+	return nodes, links, nil
+}
+
+func (h *DefaultGeoHandler) getAddressesForGeoNodes(ctx context.Context, nodes []models.Node) error {
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for _, node := range nodes {
+		if _, ok := houseIDSet[node.HouseId]; !ok {
+			houseIDSet[node.HouseId] = struct{}{}
+			houseIDs = append(houseIDs, node.HouseId)
+		}
+	}
+
+	if len(houseIDs) == 0 {
+		return nil
+	}
+
+	res, err := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+	if err != nil {
+		return err
+	}
+
+	for _, address := range res.Addresses {
+		addressMap[address.House.Id] = address
+	}
+
+	for i := range nodes {
+		nodes[i].Address = addressMap[nodes[i].HouseId]
+	}
+
+	return nil
+}
+
+// parseBBox Разбирает область в порядке GeoJSON: minLon,minLat,maxLon,maxLat
+func parseBBox(value string) (models.BBox, error) {
+	var bbox models.BBox
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return bbox, goErrors.New("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+
+	values := make([]float64, 4)
+
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return bbox, err
+		}
+
+		values[i] = v
+	}
+
+	bbox = models.BBox{MinLon: values[0], MinLat: values[1], MaxLon: values[2], MaxLat: values[3]}
+
+	if bbox.MinLon > bbox.MaxLon || bbox.MinLat > bbox.MaxLat {
+		return bbox, goErrors.New("bbox min values must not exceed max values")
+	}
+
+	if bbox.MinLat < -90 || bbox.MaxLat > 90 || bbox.MinLon < -180 || bbox.MaxLon > 180 {
+		return bbox, goErrors.New("bbox is out of coordinate range")
+	}
+
+	return bbox, nil
+}
+
+func formatKMLCoordinates(node models.Node) string {
+	return strconv.FormatFloat(node.Longitude.Float64, 'f', -1, 64) + "," + strconv.FormatFloat(node.Latitude.Float64, 'f', -1, 64)
+}
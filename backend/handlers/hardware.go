@@ -10,11 +10,13 @@ import (
 	"backend/utils"
 	"context"
 	"database/sql"
+	goErrors "errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -29,12 +31,17 @@ type HardwareHandler interface {
 	HandlerDeleteHardware(c *gin.Context)
 	SendBatchHardware(ctx context.Context) error
 	SendSingleHardware(ctx context.Context, hardwareID int) error
+	HandlerRestoreHardware(c *gin.Context)
+	HandlerGetHardwareStatusHistory(c *gin.Context)
+	HandlerGetWarrantyReport(c *gin.Context)
 }
 
 type DefaultHardwareHandler struct {
 	Privilege      Privilege
 	HardwareRepo   database.HardwareRepository
 	EventRepo      database.EventRepository
+	VlanRepo       database.VlanRepository
+	IpamRepo       database.IpamRepository
 	AddressService addresspb.AddressServiceClient
 	Metadata       utils.Metadata
 	SearchService  searchpb.SearchServiceClient
@@ -51,6 +58,12 @@ func NewHardwareHandler(addressClient *addresspb.AddressServiceClient, searchCli
 		EventRepo: &database.DefaultEventRepository{
 			Database: *db,
 		},
+		VlanRepo: &database.DefaultVlanRepository{
+			Database: *db,
+		},
+		IpamRepo: &database.DefaultIpamRepository{
+			Database: *db,
+		},
 		AddressService:   *addressClient,
 		Metadata:         &utils.DefaultMetadata{},
 		SearchService:    *searchClient,
@@ -60,13 +73,18 @@ func NewHardwareHandler(addressClient *addresspb.AddressServiceClient, searchCli
 }
 
 func (h *DefaultHardwareHandler) SendSingleHardware(ctx context.Context, hardwareID int) error {
+	return sendSingleHardware(ctx, h.HardwareRepo, h.AddressService, h.HardwareProducer, hardwareID)
+}
+
+// sendSingleHardware Отправляет актуальное состояние оборудования на переиндексацию в сервис поиска
+func sendSingleHardware(ctx context.Context, hardwareRepo database.HardwareRepository, addressService addresspb.AddressServiceClient, producer kafka.HardwareProducer, hardwareID int) error {
 	hd := &models.Hardware{ID: hardwareID}
 
-	if err := h.HardwareRepo.GetHardwareByID(hd); err != nil {
+	if err := hardwareRepo.GetHardwareByID(hd); err != nil {
 		return err
 	}
 
-	res, err := h.AddressService.GetAddress(ctx, &addresspb.GetAddressRequest{HouseId: hd.Node.HouseId})
+	res, err := addressService.GetAddress(ctx, &addresspb.GetAddressRequest{HouseId: hd.Node.HouseId})
 	if err != nil {
 		return err
 	}
@@ -86,7 +104,7 @@ func (h *DefaultHardwareHandler) SendSingleHardware(ctx context.Context, hardwar
 		IsDelete: hd.IsDelete,
 	}
 
-	if err = h.HardwareProducer.SendSingleHardware(ctx, grpcHd); err != nil {
+	if err = producer.SendSingleHardware(ctx, grpcHd); err != nil {
 		return err
 	}
 
@@ -192,7 +210,7 @@ func (h *DefaultHardwareHandler) SendBatchHardware(ctx context.Context) error {
 //}
 
 func (h *DefaultHardwareHandler) HandlerDeleteHardware(c *gin.Context) {
-	_, isAdmin, _ := h.Privilege.getPrivilege(c)
+	session, isAdmin, _ := h.Privilege.getPrivilege(c)
 
 	if !isAdmin {
 		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
@@ -205,7 +223,12 @@ func (h *DefaultHardwareHandler) HandlerDeleteHardware(c *gin.Context) {
 		return
 	}
 
-	if err = h.HardwareRepo.DeleteHardware(hardwareID); err != nil {
+	if err = h.HardwareRepo.DeleteHardware(hardwareID, session.User.Id, time.Now().Unix()); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "hardware not found", http.StatusNotFound))
+			return
+		}
+
 		c.Error(errors.NewHTTPError(err, "failed to delete hardware", http.StatusInternalServerError))
 		return
 	}
@@ -220,6 +243,82 @@ func (h *DefaultHardwareHandler) HandlerDeleteHardware(c *gin.Context) {
 	c.JSON(http.StatusOK, true)
 }
 
+func (h *DefaultHardwareHandler) HandlerRestoreHardware(c *gin.Context) {
+	session, isAdmin, _ := h.Privilege.getPrivilege(c)
+
+	if !isAdmin {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	var (
+		err      error
+		hardware models.Hardware
+	)
+
+	hardware.ID, err = strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	if err = h.HardwareRepo.GetHardwareByID(&hardware); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "hardware not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError))
+		return
+	}
+
+	if !hardware.IsDelete {
+		c.Error(errors.NewHTTPError(nil, "hardware is not deleted", http.StatusBadRequest))
+		return
+	}
+
+	if hardware.Node.IsDelete {
+		c.Error(errors.NewHTTPError(nil, "node of hardware is deleted, restore the node first", http.StatusConflict))
+		return
+	}
+
+	if err = h.HardwareRepo.RestoreHardware(hardware.ID, session.User.Id, time.Now().Unix()); err != nil {
+		if isConstraintViolation(err, "idx_hardware_ip_address_unique") {
+			c.Error(errors.NewHTTPError(err, "ip address is already used", http.StatusConflict))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to restore hardware", http.StatusInternalServerError))
+		return
+	}
+
+	hardware.IsDelete = false
+	hardware.DeletedAt = sql.NullInt64{}
+	hardware.DeletedBy = sql.NullInt32{}
+
+	event := models.Event{
+		HouseId:     hardware.Node.HouseId,
+		Node:        &models.Node{ID: hardware.Node.ID},
+		Hardware:    &models.Hardware{ID: hardware.ID},
+		UserId:      session.User.Id,
+		Description: fmt.Sprintf("Восстановление оборудования: %s", hardware.Type.Value),
+		CreatedAt:   time.Now().Unix(),
+	}
+
+	if err = h.EventRepo.CreateEvent(event); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to create event", http.StatusInternalServerError))
+	}
+
+	go func() {
+		if e := h.SendSingleHardware(context.Background(), hardware.ID); e != nil {
+			log.Printf("failed to send single hardware: %v\n", e)
+			h.Logger.Println(e)
+		}
+	}()
+
+	c.JSON(http.StatusOK, hardware)
+}
+
 func (h *DefaultHardwareHandler) HandlerGetHardwareByID(c *gin.Context) {
 	var (
 		err      error
@@ -273,9 +372,56 @@ func (h *DefaultHardwareHandler) HandlerEditHardware(c *gin.Context) {
 		return
 	}
 
+	stored := models.Hardware{ID: hardware.ID}
+
+	if err := h.HardwareRepo.GetHardwareByID(&stored); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "hardware not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError))
+		return
+	}
+
+	// У старых записей VLAN управления может быть записан в свободной форме, такой VLAN
+	// проверяется по реестру, только если его изменили
+	if strings.TrimSpace(hardware.MgmtVlan.String) != strings.TrimSpace(stored.MgmtVlan.String) {
+		if isRegistered, err := h.VlanRepo.ValidateMgmtVlan(hardware.MgmtVlan.String); err != nil {
+			c.Error(errors.NewHTTPError(err, "failed to check management vlan", http.StatusInternalServerError))
+			return
+		} else if !isRegistered {
+			c.Error(errors.NewHTTPError(nil, "management vlan is not in vlan registry", http.StatusBadRequest))
+			return
+		}
+	}
+
+	if violation, err := h.IpamRepo.ValidateHardwareIP(hardware); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to validate ip address", http.StatusInternalServerError))
+		return
+	} else if violation != nil {
+		c.Error(errors.NewHTTPError(nil, violation.Message, http.StatusBadRequest).WithDetails(violation))
+		return
+	}
+
 	hardware.UpdatedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
 
-	if err := h.HardwareRepo.EditHardware(&hardware); err != nil {
+	if err := h.HardwareRepo.EditHardware(&hardware, session.User.Id); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "hardware not found", http.StatusNotFound))
+			return
+		}
+
+		if isConstraintViolation(err, "idx_hardware_ip_address_unique") {
+			c.Error(errors.NewHTTPError(err, "ip address is already used", http.StatusConflict))
+			return
+		}
+
+		if isUniqueViolation(err) {
+			c.Error(errors.NewHTTPError(err, "inventory number already exists", http.StatusConflict))
+			return
+		}
+
 		c.Error(errors.NewHTTPError(err, "failed to edit hardware", http.StatusInternalServerError))
 		return
 	}
@@ -319,14 +465,44 @@ func (h *DefaultHardwareHandler) HandlerCreateHardware(c *gin.Context) {
 		return
 	}
 
+	if hardware.Status == "" {
+		hardware.Status = models.HardwareStatusInstalled
+	}
+
 	if !h.HardwareRepo.ValidateHardware(hardware) {
 		c.Error(errors.NewHTTPError(nil, "invalid hardware data", http.StatusBadRequest))
 		return
 	}
 
+	if isRegistered, err := h.VlanRepo.ValidateMgmtVlan(hardware.MgmtVlan.String); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to check management vlan", http.StatusInternalServerError))
+		return
+	} else if !isRegistered {
+		c.Error(errors.NewHTTPError(nil, "management vlan is not in vlan registry", http.StatusBadRequest))
+		return
+	}
+
+	if violation, err := h.IpamRepo.ValidateHardwareIP(hardware); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to validate ip address", http.StatusInternalServerError))
+		return
+	} else if violation != nil {
+		c.Error(errors.NewHTTPError(nil, violation.Message, http.StatusBadRequest).WithDetails(violation))
+		return
+	}
+
 	hardware.CreatedAt = time.Now().Unix()
 
-	if err := h.HardwareRepo.CreateHardware(&hardware); err != nil {
+	if err := h.HardwareRepo.CreateHardware(&hardware, session.User.Id); err != nil {
+		if isConstraintViolation(err, "idx_hardware_ip_address_unique") {
+			c.Error(errors.NewHTTPError(err, "ip address is already used", http.StatusConflict))
+			return
+		}
+
+		if isUniqueViolation(err) {
+			c.Error(errors.NewHTTPError(err, "inventory number already exists", http.StatusConflict))
+			return
+		}
+
 		c.Error(errors.NewHTTPError(err, "failed to create hardware", http.StatusInternalServerError))
 		return
 	}
@@ -524,3 +700,84 @@ func (h *DefaultHardwareHandler) getAddressesForHardware(ctx context.Context, ha
 
 	return nil
 }
+
+// HandlerGetHardwareStatusHistory Возвращает историю статусов жизненного цикла оборудования
+func (h *DefaultHardwareHandler) HandlerGetHardwareStatusHistory(c *gin.Context) {
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	changes, err := h.HardwareRepo.GetHardwareStatusHistory(hardwareID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get hardware status history", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// HandlerGetWarrantyReport Возвращает оборудование, гарантия которого заканчивается в ближайшие days дней
+// (по умолчанию 90). Оборудование с закончившейся гарантией выводится, если не передан expired=false
+func (h *DefaultHardwareHandler) HandlerGetWarrantyReport(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+	if err != nil || days < 0 {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(days) to int", http.StatusBadRequest))
+		return
+	}
+
+	withExpired, err := strconv.ParseBool(c.DefaultQuery("expired", "true"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(expired) to bool", http.StatusBadRequest))
+		return
+	}
+
+	now := time.Now()
+	from := now.Unix()
+
+	if withExpired {
+		from = 0
+	}
+
+	items, err := h.HardwareRepo.GetWarrantyExpiring(now.AddDate(0, 0, days).Unix(), from)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get warranty report", http.StatusInternalServerError))
+		return
+	}
+
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for _, item := range items {
+		if _, ok := houseIDSet[item.Hardware.Node.HouseId]; !ok {
+			houseIDSet[item.Hardware.Node.HouseId] = struct{}{}
+			houseIDs = append(houseIDs, item.Hardware.Node.HouseId)
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		res, e := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, "failed to get addresses", http.StatusInternalServerError))
+			return
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for i := range items {
+		items[i].Hardware.Node.Address = addressMap[items[i].Hardware.Node.HouseId]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": items,
+		"Count": len(items),
+	})
+}
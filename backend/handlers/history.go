@@ -0,0 +1,487 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/kafka"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/proto/userpb"
+	"backend/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+type HistoryHandler interface {
+	HandlerGetHistory(c *gin.Context, entity string)
+	HandlerRevertHistory(c *gin.Context, entity string)
+}
+
+type DefaultHistoryHandler struct {
+	Privilege        Privilege
+	HistoryRepo      database.HistoryRepository
+	NodeRepo         database.NodeRepository
+	HardwareRepo     database.HardwareRepository
+	ReferenceRepo    database.ReferenceRepository
+	VlanRepo         database.VlanRepository
+	IpamRepo         database.IpamRepository
+	EventRepo        database.EventRepository
+	UserService      userpb.UserServiceClient
+	AddressService   addresspb.AddressServiceClient
+	Metadata         utils.Metadata
+	NodeProducer     kafka.NodeProducer
+	HardwareProducer kafka.HardwareProducer
+	utils.Logger
+}
+
+func NewHistoryHandler(userClient *userpb.UserServiceClient, addressClient *addresspb.AddressServiceClient, db *database.Database, logger *utils.Logger) HistoryHandler {
+	return &DefaultHistoryHandler{
+		Privilege: &DefaultPrivilege{},
+		HistoryRepo: &database.DefaultHistoryRepository{
+			Database: *db,
+		},
+		NodeRepo: &database.DefaultNodeRepository{
+			Database: *db,
+		},
+		HardwareRepo: &database.DefaultHardwareRepository{
+			Database: *db,
+		},
+		ReferenceRepo: &database.DefaultReferenceRepository{
+			Database: *db,
+		},
+		VlanRepo: &database.DefaultVlanRepository{
+			Database: *db,
+		},
+		IpamRepo: &database.DefaultIpamRepository{
+			Database: *db,
+		},
+		EventRepo: &database.DefaultEventRepository{
+			Database: *db,
+		},
+		UserService:      *userClient,
+		AddressService:   *addressClient,
+		Metadata:         &utils.DefaultMetadata{},
+		NodeProducer:     kafka.NewNodeProducer(kafka.NewKafkaWriter("index-node")),
+		HardwareProducer: kafka.NewHardwareProducer(kafka.NewKafkaWriter("index-node")),
+		Logger:           *logger,
+	}
+}
+
+// HandlerGetHistory Возвращает версии сущности от новых к старым с изменениями по каждому полю
+func (h *DefaultHistoryHandler) HandlerGetHistory(c *gin.Context, entity string) {
+	if _, ok := database.HistoryEntities[entity]; !ok {
+		c.Error(errors.NewHTTPError(nil, fmt.Sprintf("entity is unsupported (%s)", entity), http.StatusBadRequest))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(offset) to int", http.StatusBadRequest))
+		return
+	}
+
+	history, count, err := h.HistoryRepo.GetHistory(entity, id, offset)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get history", http.StatusInternalServerError))
+		return
+	}
+
+	userIDSet := make(map[int32]struct{})
+	var userIDs []int32
+
+	for i := range history {
+		history[i].Changes, err = diffSnapshots(history[i].Before, history[i].After)
+		if err != nil {
+			c.Error(errors.NewHTTPError(err, "failed to compare versions", http.StatusInternalServerError))
+			return
+		}
+
+		if _, ok := userIDSet[history[i].UserId]; !ok {
+			userIDSet[history[i].UserId] = struct{}{}
+			userIDs = append(userIDs, history[i].UserId)
+		}
+	}
+
+	if len(userIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		userRes, e := h.UserService.GetUsersByIds(ctx, &userpb.GetUsersByIdsRequest{Ids: userIDs})
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, "failed to get users", http.StatusInternalServerError))
+			return
+		}
+
+		usersMap := make(map[int32]*userpb.User)
+
+		for _, user := range userRes.Users {
+			usersMap[user.Id] = user
+		}
+
+		for i := range history {
+			history[i].User = usersMap[history[i].UserId]
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": history,
+		"Count": count,
+	})
+}
+
+// HandlerRevertHistory Возвращает сущность к состоянию после указанной версии. Откат сохраняется новой версией
+func (h *DefaultHistoryHandler) HandlerRevertHistory(c *gin.Context, entity string) {
+	session, isAdmin, _ := h.Privilege.getPrivilege(c)
+
+	if !isAdmin {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	if _, ok := database.HistoryEntities[entity]; !ok {
+		c.Error(errors.NewHTTPError(nil, fmt.Sprintf("entity is unsupported (%s)", entity), http.StatusBadRequest))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(version) to int", http.StatusBadRequest))
+		return
+	}
+
+	record, err := h.HistoryRepo.GetHistoryVersion(entity, id, version)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "version not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get version", http.StatusInternalServerError))
+		return
+	}
+
+	if record.After == nil {
+		c.Error(errors.NewHTTPError(nil, "version has no snapshot to revert to", http.StatusBadRequest))
+		return
+	}
+
+	var httpErr *errors.HTTPError
+
+	switch entity {
+	case "NODE":
+		httpErr = h.validateNodeSnapshot(id, record.After)
+	case "HARDWARE":
+		httpErr = h.validateHardwareSnapshot(id, record.After)
+	}
+
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	history, err := h.HistoryRepo.RevertEntity(entity, id, record.After, session.User.Id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "entity not found", http.StatusNotFound))
+			return
+		}
+
+		if isUniqueViolation(err) {
+			c.Error(errors.NewHTTPError(err, "version conflicts with current data", http.StatusConflict))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to revert entity", http.StatusInternalServerError))
+		return
+	}
+
+	if history.Changes, err = diffSnapshots(history.Before, history.After); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to compare versions", http.StatusInternalServerError))
+		return
+	}
+
+	switch entity {
+	case "NODE":
+		node := models.Node{ID: id}
+
+		if err = h.NodeRepo.GetNode(&node); err != nil {
+			c.Error(errors.NewHTTPError(err, "failed to get node", http.StatusInternalServerError))
+			return
+		}
+
+		if err = h.EventRepo.CreateEvent(models.Event{
+			HouseId:     node.HouseId,
+			Node:        &models.Node{ID: node.ID},
+			UserId:      session.User.Id,
+			Description: fmt.Sprintf("Откат узла к версии %d: %s", version, node.Name),
+			CreatedAt:   history.CreatedAt,
+		}); err != nil {
+			c.Error(errors.NewHTTPError(err, "failed to create event", http.StatusInternalServerError))
+		}
+
+		go func() {
+			if e := sendSingleNode(context.Background(), h.NodeRepo, h.AddressService, h.NodeProducer, id); e != nil {
+				log.Printf("failed to send single node: %v\n", e)
+				h.Logger.Println(e)
+			}
+		}()
+	case "HARDWARE":
+		hardware := models.Hardware{ID: id}
+
+		if err = h.HardwareRepo.GetHardwareByID(&hardware); err != nil {
+			c.Error(errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError))
+			return
+		}
+
+		if err = h.EventRepo.CreateEvent(models.Event{
+			HouseId:     hardware.Node.HouseId,
+			Node:        &models.Node{ID: hardware.Node.ID},
+			Hardware:    &models.Hardware{ID: hardware.ID},
+			UserId:      session.User.Id,
+			Description: fmt.Sprintf("Откат оборудования к версии %d: %s", version, hardware.Type.Value),
+			CreatedAt:   history.CreatedAt,
+		}); err != nil {
+			c.Error(errors.NewHTTPError(err, "failed to create event", http.StatusInternalServerError))
+		}
+
+		go func() {
+			if e := sendSingleHardware(context.Background(), h.HardwareRepo, h.AddressService, h.HardwareProducer, id); e != nil {
+				log.Printf("failed to send single hardware: %v\n", e)
+				h.Logger.Println(e)
+			}
+		}()
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// validateNodeSnapshot Проверяет, что возврат узлу родителя и типа из снимка не нарушит топологию сети,
+// а активный узел не окажется под удаленным родителем
+func (h *DefaultHistoryHandler) validateNodeSnapshot(nodeID int, snapshot []byte) *errors.HTTPError {
+	var fields struct {
+		ParentID  *int `json:"parent_id"`
+		TypeID    *int `json:"type_id"`
+		IsPassive bool `json:"is_passive"`
+		IsDelete  bool `json:"is_delete"`
+	}
+
+	if err := json.Unmarshal(snapshot, &fields); err != nil {
+		return errors.NewHTTPError(err, "failed to decode snapshot", http.StatusInternalServerError)
+	}
+
+	node := models.Node{ID: nodeID, IsPassive: fields.IsPassive}
+
+	if fields.ParentID != nil {
+		node.Parent = &models.Node{ID: *fields.ParentID}
+	}
+
+	if fields.TypeID != nil {
+		node.Type = &models.Reference{ID: *fields.TypeID}
+	}
+
+	violation, err := h.NodeRepo.ValidateNodeTopology(node)
+	if err != nil {
+		return errors.NewHTTPError(err, "failed to validate node topology", http.StatusInternalServerError)
+	}
+
+	if violation != nil {
+		return errors.NewHTTPError(nil, violation.Message, http.StatusBadRequest).WithDetails(violation)
+	}
+
+	if fields.IsDelete || node.Parent == nil {
+		return nil
+	}
+
+	parent := models.Node{ID: node.Parent.ID}
+
+	if err = h.NodeRepo.GetNode(&parent); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.NewHTTPError(err, "parent node not found", http.StatusBadRequest)
+		}
+
+		return errors.NewHTTPError(err, "failed to get parent node", http.StatusInternalServerError)
+	}
+
+	if parent.IsDelete {
+		return errors.NewHTTPError(nil, "parent node is deleted, restore the parent first", http.StatusConflict).WithDetails(parent)
+	}
+
+	return nil
+}
+
+// validateHardwareSnapshot Проверяет снимок оборудования теми же правилами, что создание, изменение
+// и восстановление: узел существует и не удален, данные оборудования корректны, VLAN управления есть
+// в реестре, IP адрес свободен и подходит подсети
+func (h *DefaultHistoryHandler) validateHardwareSnapshot(hardwareID int, snapshot []byte) *errors.HTTPError {
+	hardware, isDelete, err := decodeHardwareSnapshot(hardwareID, snapshot)
+	if err != nil {
+		return errors.NewHTTPError(err, "failed to decode snapshot", http.StatusInternalServerError)
+	}
+
+	if err = h.NodeRepo.GetNode(&hardware.Node); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.NewHTTPError(err, "node not found", http.StatusBadRequest)
+		}
+
+		return errors.NewHTTPError(err, "failed to get node", http.StatusInternalServerError)
+	}
+
+	if !isDelete && hardware.Node.IsDelete {
+		return errors.NewHTTPError(nil, "node of hardware is deleted, restore the node first", http.StatusConflict).WithDetails(hardware.Node)
+	}
+
+	// Тип нужен ValidateHardware по значению (switch требует модель и IP), в снимке есть только type_id
+	hardwareTypes, err := h.ReferenceRepo.GetReferenceRecords("HARDWARE_TYPES")
+	if err != nil {
+		return errors.NewHTTPError(err, "failed to get hardware types", http.StatusInternalServerError)
+	}
+
+	for _, hardwareType := range hardwareTypes {
+		if hardwareType.ID == hardware.Type.ID {
+			hardware.Type = hardwareType
+		}
+	}
+
+	if !h.HardwareRepo.ValidateHardware(hardware) {
+		return errors.NewHTTPError(nil, "invalid hardware data", http.StatusBadRequest)
+	}
+
+	// Удаленное оборудование не занимает IP адрес, проверка нужна только для активного снимка
+	if isDelete {
+		return nil
+	}
+
+	if isRegistered, err := h.VlanRepo.ValidateMgmtVlan(hardware.MgmtVlan.String); err != nil {
+		return errors.NewHTTPError(err, "failed to check management vlan", http.StatusInternalServerError)
+	} else if !isRegistered {
+		return errors.NewHTTPError(nil, "management vlan is not in vlan registry", http.StatusBadRequest)
+	}
+
+	if violation, err := h.IpamRepo.ValidateHardwareIP(hardware); err != nil {
+		return errors.NewHTTPError(err, "failed to validate ip address", http.StatusInternalServerError)
+	} else if violation != nil {
+		return errors.NewHTTPError(nil, violation.Message, http.StatusBadRequest).WithDetails(violation)
+	}
+
+	return nil
+}
+
+// decodeHardwareSnapshot Собирает из снимка строки "Hardware" поля, которые проверяются при сохранении
+func decodeHardwareSnapshot(hardwareID int, snapshot []byte) (models.Hardware, bool, error) {
+	var fields struct {
+		NodeID       int     `json:"node_id"`
+		TypeID       int     `json:"type_id"`
+		SwitchID     *int    `json:"switch_id"`
+		IpAddress    *string `json:"ip_address"`
+		MgmtVlan     *string `json:"mgmt_vlan"`
+		IsDelete     bool    `json:"is_delete"`
+		Status       string  `json:"status"`
+		PurchaseDate *int64  `json:"purchase_date"`
+		WarrantyEnd  *int64  `json:"warranty_end"`
+	}
+
+	if err := json.Unmarshal(snapshot, &fields); err != nil {
+		return models.Hardware{}, false, err
+	}
+
+	hardware := models.Hardware{
+		ID:     hardwareID,
+		Node:   models.Node{ID: fields.NodeID},
+		Type:   models.Reference{ID: fields.TypeID},
+		Status: fields.Status,
+	}
+
+	if fields.SwitchID != nil {
+		hardware.Switch.ID = *fields.SwitchID
+	}
+
+	if fields.IpAddress != nil {
+		hardware.IpAddress = sql.NullString{String: *fields.IpAddress, Valid: true}
+	}
+
+	if fields.MgmtVlan != nil {
+		hardware.MgmtVlan = sql.NullString{String: *fields.MgmtVlan, Valid: true}
+	}
+
+	if fields.PurchaseDate != nil {
+		hardware.PurchaseDate = sql.NullInt64{Int64: *fields.PurchaseDate, Valid: true}
+	}
+
+	if fields.WarrantyEnd != nil {
+		hardware.WarrantyEnd = sql.NullInt64{Int64: *fields.WarrantyEnd, Valid: true}
+	}
+
+	return hardware, fields.IsDelete, nil
+}
+
+// diffSnapshots Сравнивает два снимка сущности и возвращает только отличающиеся поля
+func diffSnapshots(before, after []byte) ([]models.HistoryChange, error) {
+	beforeFields := make(map[string]interface{})
+	afterFields := make(map[string]interface{})
+
+	if before != nil {
+		if err := json.Unmarshal(before, &beforeFields); err != nil {
+			return nil, err
+		}
+	}
+
+	if after != nil {
+		if err := json.Unmarshal(after, &afterFields); err != nil {
+			return nil, err
+		}
+	}
+
+	fieldSet := make(map[string]struct{})
+
+	for field := range beforeFields {
+		fieldSet[field] = struct{}{}
+	}
+
+	for field := range afterFields {
+		fieldSet[field] = struct{}{}
+	}
+
+	fields := make([]string, 0, len(fieldSet))
+
+	for field := range fieldSet {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	changes := make([]models.HistoryChange, 0)
+
+	for _, field := range fields {
+		if reflect.DeepEqual(beforeFields[field], afterFields[field]) {
+			continue
+		}
+
+		changes = append(changes, models.HistoryChange{
+			Field:  field,
+			Before: beforeFields[field],
+			After:  afterFields[field],
+		})
+	}
+
+	return changes, nil
+}
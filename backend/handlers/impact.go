@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"net/http"
+	"strconv"
+)
+
+type ImpactHandler interface {
+	HandlerGetNodeImpact(c *gin.Context)
+	HandlerGetHardwareImpact(c *gin.Context)
+	HandlerGetNodeImpactExcel(c *gin.Context)
+	HandlerGetHardwareImpactExcel(c *gin.Context)
+}
+
+type DefaultImpactHandler struct {
+	NodeRepo       database.NodeRepository
+	HardwareRepo   database.HardwareRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+func NewImpactHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) ImpactHandler {
+	return &DefaultImpactHandler{
+		NodeRepo: &database.DefaultNodeRepository{
+			Database: *db,
+		},
+		HardwareRepo: &database.DefaultHardwareRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+func (h *DefaultImpactHandler) HandlerGetNodeImpact(c *gin.Context) {
+	impact, httpErr := h.getImpact(c, "NODE")
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, impact)
+}
+
+func (h *DefaultImpactHandler) HandlerGetHardwareImpact(c *gin.Context) {
+	impact, httpErr := h.getImpact(c, "HARDWARE")
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, impact)
+}
+
+func (h *DefaultImpactHandler) HandlerGetNodeImpactExcel(c *gin.Context) {
+	h.handlerImpactExcel(c, "NODE")
+}
+
+func (h *DefaultImpactHandler) HandlerGetHardwareImpactExcel(c *gin.Context) {
+	h.handlerImpactExcel(c, "HARDWARE")
+}
+
+func (h *DefaultImpactHandler) handlerImpactExcel(c *gin.Context, from string) {
+	impact, httpErr := h.getImpact(c, from)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	excelData, err := generateImpactExcel(impact)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to generate Excel", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, base64.StdEncoding.EncodeToString(excelData))
+}
+
+// getImpact Собирает все узлы и оборудование, которые окажутся без связи при отказе узла или оборудования.
+// При отказе оборудования затрагиваются дочерние узлы его узла со всем их оборудованием,
+// остальное оборудование в самом узле считается незатронутым
+func (h *DefaultImpactHandler) getImpact(c *gin.Context, from string) (*models.Impact, *errors.HTTPError) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest)
+	}
+
+	impact := &models.Impact{
+		Source:   from,
+		SourceID: id,
+	}
+
+	nodeID := id
+	var failedHardware *models.Hardware
+
+	if from == "HARDWARE" {
+		failedHardware = &models.Hardware{ID: id}
+
+		if err = h.HardwareRepo.GetHardwareByID(failedHardware); err != nil {
+			if goErrors.Is(err, sql.ErrNoRows) {
+				return nil, errors.NewHTTPError(err, "hardware not found", http.StatusNotFound)
+			}
+
+			return nil, errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError)
+		}
+
+		nodeID = failedHardware.Node.ID
+	}
+
+	impact.Nodes, err = h.NodeRepo.GetNodeTree(nodeID)
+	if err != nil {
+		return nil, errors.NewHTTPError(err, "failed to get node tree", http.StatusInternalServerError)
+	}
+
+	if len(impact.Nodes) == 0 {
+		return nil, errors.NewHTTPError(nil, "node not found", http.StatusNotFound)
+	}
+
+	var nodeIDs []int32
+
+	for _, item := range impact.Nodes {
+		if failedHardware != nil && item.Depth == 0 {
+			continue
+		}
+
+		nodeIDs = append(nodeIDs, int32(item.Node.ID))
+	}
+
+	if len(nodeIDs) > 0 {
+		impact.Hardware, err = h.HardwareRepo.GetHardwareByNodeIDs(nodeIDs)
+		if err != nil {
+			return nil, errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError)
+		}
+	}
+
+	if failedHardware != nil {
+		impact.Hardware = append([]models.Hardware{*failedHardware}, impact.Hardware...)
+	}
+
+	ctx := h.Metadata.SetAuthorizationHeader(c)
+
+	if err = h.getAddressesForImpact(ctx, impact); err != nil {
+		return nil, errors.NewHTTPError(err, "failed to get addresses", http.StatusInternalServerError)
+	}
+
+	return impact, nil
+}
+
+func (h *DefaultImpactHandler) getAddressesForImpact(ctx context.Context, impact *models.Impact) error {
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for _, item := range impact.Nodes {
+		if _, ok := houseIDSet[item.Node.HouseId]; !ok {
+			houseIDSet[item.Node.HouseId] = struct{}{}
+			houseIDs = append(houseIDs, item.Node.HouseId)
+		}
+	}
+
+	res, err := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+	if err != nil {
+		return err
+	}
+
+	for _, address := range res.Addresses {
+		addressMap[address.House.Id] = address
+	}
+
+	for _, houseID := range houseIDs {
+		if address, ok := addressMap[houseID]; ok {
+			impact.Addresses = append(impact.Addresses, address)
+		}
+	}
+
+	for i := range impact.Nodes {
+		impact.Nodes[i].Node.Address = addressMap[impact.Nodes[i].Node.HouseId]
+	}
+
+	for i := range impact.Hardware {
+		impact.Hardware[i].Node.Address = addressMap[impact.Hardware[i].Node.HouseId]
+	}
+
+	return nil
+}
+
+func generateImpactExcel(impact *models.Impact) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheets := []struct {
+		name    string
+		headers []interface{}
+		rows    [][]interface{}
+	}{
+		{
+			name:    "Дома",
+			headers: []interface{}{"Адрес"},
+		},
+		{
+			name:    "Узлы",
+			headers: []interface{}{"Узел", "Тип", "Зона", "Адрес", "Уровень", "Оборудование, шт."},
+		},
+		{
+			name:    "Оборудование",
+			headers: []interface{}{"Оборудование", "Модель", "IP адрес", "Узел", "Адрес"},
+		},
+	}
+
+	for _, address := range impact.Addresses {
+		sheets[0].rows = append(sheets[0].rows, []interface{}{formatAddress(address)})
+	}
+
+	for _, item := range impact.Nodes {
+		nodeType := ""
+
+		if item.Node.Type != nil {
+			nodeType = item.Node.Type.Value
+		}
+
+		sheets[1].rows = append(sheets[1].rows, []interface{}{
+			item.Node.Name,
+			nodeType,
+			item.Node.Zone.String,
+			formatAddress(item.Node.Address),
+			item.Depth,
+			item.HardwareAmount,
+		})
+	}
+
+	for _, hd := range impact.Hardware {
+		sheets[2].rows = append(sheets[2].rows, []interface{}{
+			hd.Type.Value,
+			hd.Switch.Name,
+			hd.IpAddress.String,
+			hd.Node.Name,
+			formatAddress(hd.Node.Address),
+		})
+	}
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", sheet.name); err != nil {
+				return nil, err
+			}
+		} else if _, err := f.NewSheet(sheet.name); err != nil {
+			return nil, err
+		}
+
+		if err := f.SetSheetRow(sheet.name, "A1", &sheet.headers); err != nil {
+			return nil, err
+		}
+
+		for j := range sheet.rows {
+			cell, _ := excelize.CoordinatesToCellName(1, j+2)
+
+			if err := f.SetSheetRow(sheet.name, cell, &sheet.rows[j]); err != nil {
+				return nil, err
+			}
+		}
+
+		lastCol, _ := excelize.ColumnNumberToName(len(sheet.headers))
+
+		if err := f.SetColWidth(sheet.name, "A", lastCol, 30); err != nil {
+			return nil, err
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// formatAddress Формирует строку адреса вида "ул. Ленина, д. 1"
+func formatAddress(address *addresspb.Address) string {
+	if address == nil || address.Street == nil || address.House == nil {
+		return ""
+	}
+
+	streetType, houseType := "", ""
+
+	if address.Street.Type != nil {
+		streetType = address.Street.Type.ShortName
+	}
+
+	if address.House.Type != nil {
+		houseType = address.House.Type.ShortName
+	}
+
+	return fmt.Sprintf("%s %s, %s %s", streetType, address.Street.Name, houseType, address.House.Name)
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type InventoryHandler interface {
+	HandlerExportInventory(c *gin.Context)
+}
+
+type DefaultInventoryHandler struct {
+	NodeRepo       database.NodeRepository
+	HardwareRepo   database.HardwareRepository
+	SwitchRepo     database.SwitchRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+type inventorySheet struct {
+	name    string
+	headers []interface{}
+	rows    [][]interface{}
+}
+
+func NewInventoryHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) InventoryHandler {
+	return &DefaultInventoryHandler{
+		NodeRepo: &database.DefaultNodeRepository{
+			Database: *db,
+		},
+		HardwareRepo: &database.DefaultHardwareRepository{
+			Database: *db,
+		},
+		SwitchRepo: &database.DefaultSwitchRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+// HandlerExportInventory Выгружает узлы, оборудование и модели коммутаторов с учетом фильтров.
+// format=xlsx отдает книгу с листом на каждую сущность, format=csv отдает одну сущность из параметра entity
+func (h *DefaultInventoryHandler) HandlerExportInventory(c *gin.Context) {
+	filter, httpErr := parseInventoryFilter(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	format := c.DefaultQuery("format", "xlsx")
+	entity := c.DefaultQuery("entity", "nodes")
+
+	if format != "xlsx" && format != "csv" {
+		c.Error(errors.NewHTTPError(nil, fmt.Sprintf("format is unsupported (%s)", format), http.StatusBadRequest))
+		return
+	}
+
+	if entity != "nodes" && entity != "hardware" && entity != "switches" {
+		c.Error(errors.NewHTTPError(nil, fmt.Sprintf("entity is unsupported (%s)", entity), http.StatusBadRequest))
+		return
+	}
+
+	nodes, err := h.NodeRepo.GetInventoryNodes(filter)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get nodes", http.StatusInternalServerError))
+		return
+	}
+
+	hardware, err := h.HardwareRepo.GetInventoryHardware(filter)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError))
+		return
+	}
+
+	switches, err := h.SwitchRepo.GetSwitches()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get switches", http.StatusInternalServerError))
+		return
+	}
+
+	ctx := h.Metadata.SetAuthorizationHeader(c)
+
+	addressMap, err := h.getInventoryAddresses(ctx, nodes, hardware)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get addresses", http.StatusInternalServerError))
+		return
+	}
+
+	sheets := generateInventorySheets(nodes, hardware, switches, addressMap)
+	fileName := fmt.Sprintf("inventory_%s", time.Now().Format("20060102_150405"))
+
+	if format == "csv" {
+		var sheet inventorySheet
+
+		for _, s := range sheets {
+			if s.name == inventorySheetNames[entity] {
+				sheet = s
+			}
+		}
+
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_%s.csv"`, fileName, entity))
+
+		if err = writeInventoryCSV(c.Writer, sheet); err != nil {
+			c.Error(errors.NewHTTPError(err, "failed to generate CSV", http.StatusInternalServerError))
+		}
+
+		return
+	}
+
+	f, err := generateInventoryExcel(sheets)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to generate Excel", http.StatusInternalServerError))
+		return
+	}
+	defer f.Close()
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, fileName))
+
+	if err = f.Write(c.Writer); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to write Excel", http.StatusInternalServerError))
+	}
+}
+
+func parseInventoryFilter(c *gin.Context) (models.InventoryFilter, *errors.HTTPError) {
+	var filter models.InventoryFilter
+	var err error
+
+	intParams := []struct {
+		name  string
+		value *int
+	}{
+		{"house_id", &filter.HouseID},
+		{"owner_id", &filter.OwnerID},
+		{"node_type_id", &filter.NodeTypeID},
+		{"hardware_type_id", &filter.HardwareTypeID},
+	}
+
+	for _, param := range intParams {
+		if *param.value, err = strconv.Atoi(c.DefaultQuery(param.name, "0")); err != nil {
+			return filter, errors.NewHTTPError(err, fmt.Sprintf("failed to parse query(%s) to int", param.name), http.StatusBadRequest)
+		}
+	}
+
+	filter.Zone = c.DefaultQuery("zone", "")
+
+	flagParams := []struct {
+		name         string
+		defaultValue string
+		value        *sql.NullBool
+	}{
+		{"deleted", "false", &filter.IsDelete},
+		{"passive", "all", &filter.IsPassive},
+	}
+
+	for _, param := range flagParams {
+		value := c.DefaultQuery(param.name, param.defaultValue)
+
+		if value == "all" {
+			continue
+		}
+
+		flag, e := strconv.ParseBool(value)
+		if e != nil {
+			return filter, errors.NewHTTPError(e, fmt.Sprintf("failed to parse query(%s) to bool", param.name), http.StatusBadRequest)
+		}
+
+		*param.value = sql.NullBool{Bool: flag, Valid: true}
+	}
+
+	return filter, nil
+}
+
+func (h *DefaultInventoryHandler) getInventoryAddresses(ctx context.Context, nodes []models.Node, hardware []models.Hardware) (map[int32]*addresspb.Address, error) {
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	addHouseID := func(houseID int32) {
+		if _, ok := houseIDSet[houseID]; !ok {
+			houseIDSet[houseID] = struct{}{}
+			houseIDs = append(houseIDs, houseID)
+		}
+	}
+
+	for _, node := range nodes {
+		addHouseID(node.HouseId)
+	}
+
+	for _, hd := range hardware {
+		addHouseID(hd.Node.HouseId)
+	}
+
+	if len(houseIDs) == 0 {
+		return addressMap, nil
+	}
+
+	res, err := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, address := range res.Addresses {
+		addressMap[address.House.Id] = address
+	}
+
+	return addressMap, nil
+}
+
+var inventorySheetNames = map[string]string{
+	"nodes":    "Узлы",
+	"hardware": "Оборудование",
+	"switches": "Модели коммутаторов",
+}
+
+func generateInventorySheets(nodes []models.Node, hardware []models.Hardware, switches []models.Switch, addressMap map[int32]*addresspb.Address) []inventorySheet {
+	sheets := []inventorySheet{
+		{
+			name: inventorySheetNames["nodes"],
+			headers: []interface{}{"ID", "Узел", "Адрес", "Тип", "Владелец", "Зона", "Размещение", "Питание", "Доступ",
+				"Описание", "Родитель", "Пассивный", "Удален", "Создан", "Изменен"},
+		},
+		{
+			name: inventorySheetNames["hardware"],
+			headers: []interface{}{"ID", "Оборудование", "Модель", "IP адрес", "Mgmt VLAN", "Узел", "Адрес", "Описание",
+				"Серийный номер", "Инвентарный номер", "Статус", "Дата покупки", "Гарантия до", "Удалено", "Создано",
+				"Изменено"},
+		},
+		{
+			name:    inventorySheetNames["switches"],
+			headers: []interface{}{"ID", "Модель", "Режим работы", "Количество портов", "Оборудование, шт."},
+		},
+	}
+
+	for _, node := range nodes {
+		nodeType, parent := "", ""
+
+		if node.Type != nil {
+			nodeType = node.Type.Value
+		}
+
+		if node.Parent != nil {
+			parent = node.Parent.Name
+		}
+
+		sheets[0].rows = append(sheets[0].rows, []interface{}{
+			node.ID,
+			node.Name,
+			formatAddress(addressMap[node.HouseId]),
+			nodeType,
+			node.Owner.Value,
+			node.Zone.String,
+			node.Placement.String,
+			node.Supply.String,
+			node.Access.String,
+			node.Description.String,
+			parent,
+			formatInventoryBool(node.IsPassive),
+			formatInventoryBool(node.IsDelete),
+			formatInventoryTime(node.CreatedAt),
+			formatInventoryTime(node.UpdatedAt.Int64),
+		})
+	}
+
+	switchAmount := make(map[int]int)
+
+	for _, hd := range hardware {
+		if hd.Switch.ID != 0 {
+			switchAmount[hd.Switch.ID]++
+		}
+
+		sheets[1].rows = append(sheets[1].rows, []interface{}{
+			hd.ID,
+			hd.Type.Value,
+			hd.Switch.Name,
+			hd.IpAddress.String,
+			hd.MgmtVlan.String,
+			hd.Node.Name,
+			formatAddress(addressMap[hd.Node.HouseId]),
+			hd.Description.String,
+			hd.SerialNumber.String,
+			hd.InventoryNumber.String,
+			models.HardwareStatuses[hd.Status],
+			formatInventoryDate(hd.PurchaseDate.Int64),
+			formatInventoryDate(hd.WarrantyEnd.Int64),
+			formatInventoryBool(hd.IsDelete),
+			formatInventoryTime(hd.CreatedAt),
+			formatInventoryTime(hd.UpdatedAt.Int64),
+		})
+	}
+
+	for _, _switch := range switches {
+		sheets[2].rows = append(sheets[2].rows, []interface{}{
+			_switch.ID,
+			_switch.Name,
+			_switch.OperationMode.Value,
+			_switch.PortAmount,
+			switchAmount[_switch.ID],
+		})
+	}
+
+	return sheets
+}
+
+func generateInventoryExcel(sheets []inventorySheet) (*excelize.File, error) {
+	f := excelize.NewFile()
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", sheet.name); err != nil {
+				f.Close()
+				return nil, err
+			}
+		} else if _, err := f.NewSheet(sheet.name); err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		sw, err := f.NewStreamWriter(sheet.name)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		if err = sw.SetColWidth(1, len(sheet.headers), 25); err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		if err = sw.SetRow("A1", sheet.headers); err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		for j, row := range sheet.rows {
+			cell, _ := excelize.CoordinatesToCellName(1, j+2)
+
+			if err = sw.SetRow(cell, row); err != nil {
+				f.Close()
+				return nil, err
+			}
+		}
+
+		if err = sw.Flush(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// writeInventoryCSV Записывает лист в CSV с разделителем ";" и BOM, чтобы файл корректно открывался в Excel
+func writeInventoryCSV(w io.Writer, sheet inventorySheet) error {
+	if _, err := w.Write([]byte("\xef\xbb\xbf")); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+
+	for _, row := range append([][]interface{}{sheet.headers}, sheet.rows...) {
+		record := make([]string, len(row))
+
+		for i, value := range row {
+			record[i] = fmt.Sprint(value)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func formatInventoryBool(value bool) string {
+	if value {
+		return "Да"
+	}
+
+	return "Нет"
+}
+
+func formatInventoryTime(value int64) string {
+	if value == 0 {
+		return ""
+	}
+
+	return time.Unix(value, 0).Format("02.01.2006 15:04")
+}
+
+func formatInventoryDate(value int64) string {
+	if value == 0 {
+		return ""
+	}
+
+	return time.Unix(value, 0).Format("02.01.2006")
+}
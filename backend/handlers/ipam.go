@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type IpamHandler interface {
+	HandlerGetSubnets(c *gin.Context)
+	HandlerCreateSubnet(c *gin.Context)
+	HandlerEditSubnet(c *gin.Context)
+	HandlerGetNextFreeIP(c *gin.Context)
+	HandlerGetSubnetUtilization(c *gin.Context)
+}
+
+type DefaultIpamHandler struct {
+	Privilege Privilege
+	IpamRepo  database.IpamRepository
+	VlanRepo  database.VlanRepository
+}
+
+func NewIpamHandler(db *database.Database) IpamHandler {
+	return &DefaultIpamHandler{
+		Privilege: &DefaultPrivilege{},
+		IpamRepo: &database.DefaultIpamRepository{
+			Database: *db,
+		},
+		VlanRepo: &database.DefaultVlanRepository{
+			Database: *db,
+		},
+	}
+}
+
+func (h *DefaultIpamHandler) HandlerGetSubnets(c *gin.Context) {
+	subnets, err := h.IpamRepo.GetSubnets()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get subnets", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, subnets)
+}
+
+func (h *DefaultIpamHandler) HandlerCreateSubnet(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	subnet, httpErr := h.bindSubnet(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	subnet.CreatedAt = time.Now().Unix()
+
+	if err := h.IpamRepo.CreateSubnet(&subnet, session.User.Id); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to create subnet", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, subnet)
+}
+
+func (h *DefaultIpamHandler) HandlerEditSubnet(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	subnet, httpErr := h.bindSubnet(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	subnet.UpdatedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+
+	if err := h.IpamRepo.EditSubnet(&subnet, session.User.Id); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "subnet not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to edit subnet", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, subnet)
+}
+
+// HandlerGetNextFreeIP Возвращает первый свободный адрес подсети для нового оборудования
+func (h *DefaultIpamHandler) HandlerGetNextFreeIP(c *gin.Context) {
+	subnetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	subnet := models.Subnet{ID: subnetID}
+
+	if err = h.IpamRepo.GetSubnet(&subnet); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "subnet not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get subnet", http.StatusInternalServerError))
+		return
+	}
+
+	ipAddress, err := h.IpamRepo.GetNextFreeIP(subnetID)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "subnet has no free addresses", http.StatusConflict))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get next free ip address", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Subnet":    subnet,
+		"IpAddress": ipAddress,
+	})
+}
+
+// HandlerGetSubnetUtilization Возвращает занятость каждой подсети IPAM
+func (h *DefaultIpamHandler) HandlerGetSubnetUtilization(c *gin.Context) {
+	utilization, err := h.IpamRepo.GetSubnetUtilization()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get subnet utilization", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, utilization)
+}
+
+// bindSubnet Разбирает подсеть из тела запроса и проверяет VLAN и пересечение с другими подсетями
+func (h *DefaultIpamHandler) bindSubnet(c *gin.Context) (models.Subnet, *errors.HTTPError) {
+	var subnet models.Subnet
+
+	if err := c.BindJSON(&subnet); err != nil {
+		return subnet, errors.NewHTTPError(err, "invalid json", http.StatusBadRequest)
+	}
+
+	subnet.Network = strings.TrimSpace(subnet.Network)
+	subnet.Gateway.String = strings.TrimSpace(subnet.Gateway.String)
+	subnet.Gateway.Valid = subnet.Gateway.String != ""
+
+	if !h.IpamRepo.ValidateSubnet(subnet) {
+		return subnet, errors.NewHTTPError(nil, "invalid subnet data", http.StatusBadRequest)
+	}
+
+	if subnet.VlanID.Valid {
+		exists, err := h.VlanRepo.VlanExists(int(subnet.VlanID.Int32))
+		if err != nil {
+			return subnet, errors.NewHTTPError(err, "failed to check vlan", http.StatusInternalServerError)
+		}
+
+		if !exists {
+			return subnet, errors.NewHTTPError(nil, "vlan is not in vlan registry", http.StatusBadRequest)
+		}
+	}
+
+	overlapping, err := h.IpamRepo.GetOverlappingSubnet(subnet)
+	if err != nil {
+		return subnet, errors.NewHTTPError(err, "failed to check subnet overlap", http.StatusInternalServerError)
+	}
+
+	if overlapping != nil {
+		return subnet, errors.NewHTTPError(nil, fmt.Sprintf("subnet overlaps with %s", overlapping.Network), http.StatusConflict).WithDetails(overlapping)
+	}
+
+	return subnet, nil
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/proto/addresspb"
+	"backend/utils"
+	goErrors "errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+type MacHandler interface {
+	HandlerFindMac(c *gin.Context)
+}
+
+type DefaultMacHandler struct {
+	MacRepo        database.MacRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+func NewMacHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) MacHandler {
+	return &DefaultMacHandler{
+		MacRepo: &database.DefaultMacRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+// HandlerFindMac Ищет, на каком оборудовании и порту замечен MAC адрес абонента.
+// MAC принимается в любом формате: aa:bb:cc:dd:ee:ff, aa-bb-cc-dd-ee-ff, aabb.ccdd.eeff или aabbccddeeff
+func (h *DefaultMacHandler) HandlerFindMac(c *gin.Context) {
+	mac, err := normalizeMac(c.Param("mac"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(mac)", http.StatusBadRequest))
+		return
+	}
+
+	entries, err := h.MacRepo.GetMacEntries(mac)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get mac entries", http.StatusInternalServerError))
+		return
+	}
+
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for _, entry := range entries {
+		if _, ok := houseIDSet[entry.Hardware.Node.HouseId]; !ok {
+			houseIDSet[entry.Hardware.Node.HouseId] = struct{}{}
+			houseIDs = append(houseIDs, entry.Hardware.Node.HouseId)
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		res, e := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, "failed to get addresses", http.StatusInternalServerError))
+			return
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for i := range entries {
+		entries[i].Hardware.Node.Address = addressMap[entries[i].Hardware.Node.HouseId]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Mac":   mac,
+		"Items": entries,
+		"Count": len(entries),
+	})
+}
+
+// normalizeMac Приводит MAC адрес к виду aa:bb:cc:dd:ee:ff
+func normalizeMac(value string) (string, error) {
+	hex := strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "", " ", "").Replace(value))
+
+	if len(hex) != 12 || strings.Trim(hex, "0123456789abcdef") != "" {
+		return "", goErrors.New("mac address must contain 12 hex digits")
+	}
+
+	parts := make([]string, 6)
+
+	for i := range parts {
+		parts[i] = hex[i*2 : i*2+2]
+	}
+
+	return strings.Join(parts, ":"), nil
+}
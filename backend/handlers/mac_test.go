@@ -0,0 +1,35 @@
+package handlers
+
+import "testing"
+
+func TestNormalizeMac(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "colons", value: "00:11:22:AA:BB:CC", want: "00:11:22:aa:bb:cc"},
+		{name: "dashes", value: "00-11-22-aa-bb-cc", want: "00:11:22:aa:bb:cc"},
+		{name: "cisco dots", value: "0011.22aa.bbcc", want: "00:11:22:aa:bb:cc"},
+		{name: "plain with spaces", value: " 001122 aabbcc ", want: "00:11:22:aa:bb:cc"},
+		{name: "too short", value: "00:11:22:aa:bb", wantErr: true},
+		{name: "too long", value: "00:11:22:aa:bb:cc:dd", wantErr: true},
+		{name: "not hex", value: "00:11:22:aa:bb:zz", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeMac(tt.value)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeMac(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("normalizeMac(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
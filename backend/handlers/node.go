@@ -11,6 +11,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/base64"
+	goErrors "errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
@@ -31,16 +32,27 @@ type NodeHandler interface {
 	SendBatchNodes(ctx context.Context) error
 	SendSingleNode(ctx context.Context, nodeID int) error
 	HandlerGetNodesExcel(c *gin.Context)
+	HandlerGetNodeTree(c *gin.Context)
+	HandlerGetNodePath(c *gin.Context)
+	HandlerGetNodeTypeRules(c *gin.Context)
+	HandlerCreateNodeTypeRule(c *gin.Context)
+	HandlerDeleteNodeTypeRule(c *gin.Context)
+	HandlerMoveNode(c *gin.Context)
+	HandlerRestoreNode(c *gin.Context)
+	HandlerImportNodes(c *gin.Context)
 }
 
 type DefaultNodeHandler struct {
-	Privilege      Privilege
-	NodeRepo       database.NodeRepository
-	ReportRepo     database.ReportRepository
-	EventRepo      database.EventRepository
-	AddressService addresspb.AddressServiceClient
-	Metadata       utils.Metadata
-	SearchService  searchpb.SearchServiceClient
+	Privilege        Privilege
+	NodeRepo         database.NodeRepository
+	HardwareRepo     database.HardwareRepository
+	ReportRepo       database.ReportRepository
+	ReferenceRepo    database.ReferenceRepository
+	EventRepo        database.EventRepository
+	AddressService   addresspb.AddressServiceClient
+	Metadata         utils.Metadata
+	SearchService    searchpb.SearchServiceClient
+	HardwareProducer kafka.HardwareProducer
 	kafka.NodeProducer
 	utils.Logger
 }
@@ -51,17 +63,24 @@ func NewNodeHandler(addressClient *addresspb.AddressServiceClient, searchClient
 		NodeRepo: &database.DefaultNodeRepository{
 			Database: *db,
 		},
+		HardwareRepo: &database.DefaultHardwareRepository{
+			Database: *db,
+		},
 		ReportRepo: &database.DefaultReportRepository{
 			Database: *db,
 		},
+		ReferenceRepo: &database.DefaultReferenceRepository{
+			Database: *db,
+		},
 		EventRepo: &database.DefaultEventRepository{
 			Database: *db,
 		},
-		AddressService: *addressClient,
-		Metadata:       &utils.DefaultMetadata{},
-		SearchService:  *searchClient,
-		NodeProducer:   kafka.NewNodeProducer(kafka.NewKafkaWriter("index-node")),
-		Logger:         *logger,
+		AddressService:   *addressClient,
+		Metadata:         &utils.DefaultMetadata{},
+		SearchService:    *searchClient,
+		HardwareProducer: kafka.NewHardwareProducer(kafka.NewKafkaWriter("index-node")),
+		NodeProducer:     kafka.NewNodeProducer(kafka.NewKafkaWriter("index-node")),
+		Logger:           *logger,
 	}
 }
 
@@ -293,13 +312,18 @@ func parseReportData(reportData []models.Report) (map[string]string, error) {
 }
 
 func (h *DefaultNodeHandler) SendSingleNode(ctx context.Context, nodeID int) error {
+	return sendSingleNode(ctx, h.NodeRepo, h.AddressService, h.NodeProducer, nodeID)
+}
+
+// sendSingleNode Отправляет актуальное состояние узла на переиндексацию в сервис поиска
+func sendSingleNode(ctx context.Context, nodeRepo database.NodeRepository, addressService addresspb.AddressServiceClient, producer kafka.NodeProducer, nodeID int) error {
 	node := &models.Node{ID: nodeID}
 
-	if err := h.NodeRepo.GetNode(node); err != nil {
+	if err := nodeRepo.GetNode(node); err != nil {
 		return err
 	}
 
-	res, err := h.AddressService.GetAddress(ctx, &addresspb.GetAddressRequest{HouseId: node.HouseId})
+	res, err := addressService.GetAddress(ctx, &addresspb.GetAddressRequest{HouseId: node.HouseId})
 	if err != nil {
 		return err
 	}
@@ -326,7 +350,7 @@ func (h *DefaultNodeHandler) SendSingleNode(ctx context.Context, nodeID int) err
 		IsPassive: node.IsPassive,
 	}
 
-	if err = h.NodeProducer.SendSingleNode(ctx, grpcNode); err != nil {
+	if err = producer.SendSingleNode(ctx, grpcNode); err != nil {
 		return err
 	}
 
@@ -393,8 +417,13 @@ func (h *DefaultNodeHandler) SendBatchNodes(ctx context.Context) error {
 	return nil
 }
 
+// HandlerDeleteNode Удаляет узел по одной из политик (query policy):
+// reject - отказ, если у узла есть дочерние узлы или оборудование;
+// cascade - удаление узла вместе со всей веткой и оборудованием;
+// reparent - перенос дочерних узлов к родителю удаляемого узла и удаление оборудования самого узла.
+// При dry_run=true ничего не меняется, а возвращается перечень затрагиваемых сущностей
 func (h *DefaultNodeHandler) HandlerDeleteNode(c *gin.Context) {
-	_, isAdmin, _ := h.Privilege.getPrivilege(c)
+	session, isAdmin, _ := h.Privilege.getPrivilege(c)
 
 	if !isAdmin {
 		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
@@ -407,19 +436,200 @@ func (h *DefaultNodeHandler) HandlerDeleteNode(c *gin.Context) {
 		return
 	}
 
-	if err = h.NodeRepo.DeleteNode(nodeID); err != nil {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(dry_run) to bool", http.StatusBadRequest))
+		return
+	}
+
+	plan, httpErr := h.getNodeDeletePlan(nodeID, c.DefaultQuery("policy", "reject"))
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	plan.DryRun = dryRun
+
+	if dryRun {
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+
+	if plan.IsBlocked {
+		c.Error(errors.NewHTTPError(nil, "node has child nodes or hardware", http.StatusConflict).WithDetails(plan))
+		return
+	}
+
+	if err = h.NodeRepo.ExecuteNodeDeletePlan(plan, session.User.Id, time.Now().Unix()); err != nil {
 		c.Error(errors.NewHTTPError(err, "failed to delete node", http.StatusInternalServerError))
 		return
 	}
 
 	go func() {
-		if e := h.SendSingleNode(context.Background(), nodeID); e != nil {
+		ctx := context.Background()
+
+		for _, nodes := range [][]models.Node{plan.DeletedNodes, plan.ReparentedNodes} {
+			for _, node := range nodes {
+				if e := h.SendSingleNode(ctx, node.ID); e != nil {
+					log.Printf("failed to send single node: %v\n", e)
+					h.Logger.Println(e)
+				}
+			}
+		}
+
+		for _, hd := range plan.DeletedHardware {
+			if e := sendSingleHardware(ctx, h.HardwareRepo, h.AddressService, h.HardwareProducer, hd.ID); e != nil {
+				log.Printf("failed to send single hardware: %v\n", e)
+				h.Logger.Println(e)
+			}
+		}
+	}()
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *DefaultNodeHandler) getNodeDeletePlan(nodeID int, policy string) (*models.NodeDeletePlan, *errors.HTTPError) {
+	if policy != "reject" && policy != "cascade" && policy != "reparent" {
+		return nil, errors.NewHTTPError(nil, fmt.Sprintf("policy is unsupported (%s)", policy), http.StatusBadRequest)
+	}
+
+	plan := &models.NodeDeletePlan{
+		Policy: policy,
+		Node:   models.Node{ID: nodeID},
+	}
+
+	if err := h.NodeRepo.GetNode(&plan.Node); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, errors.NewHTTPError(err, "node not found", http.StatusNotFound)
+		}
+
+		return nil, errors.NewHTTPError(err, "failed to get node", http.StatusInternalServerError)
+	}
+
+	if plan.Node.IsDelete {
+		return nil, errors.NewHTTPError(nil, "node is already deleted", http.StatusBadRequest)
+	}
+
+	items, err := h.NodeRepo.GetNodeTree(nodeID)
+	if err != nil {
+		return nil, errors.NewHTTPError(err, "failed to get node tree", http.StatusInternalServerError)
+	}
+
+	if len(items) == 0 {
+		return nil, errors.NewHTTPError(nil, "node not found", http.StatusNotFound)
+	}
+
+	nodeIDs := []int32{int32(nodeID)}
+
+	for _, item := range items[1:] {
+		if item.Depth == 1 {
+			plan.ChildNodes = append(plan.ChildNodes, item.Node)
+		}
+
+		if policy == "cascade" {
+			nodeIDs = append(nodeIDs, int32(item.Node.ID))
+		}
+	}
+
+	plan.DeletedHardware, err = h.HardwareRepo.GetHardwareByNodeIDs(nodeIDs)
+	if err != nil {
+		return nil, errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError)
+	}
+
+	plan.DeletedNodes = []models.Node{plan.Node}
+
+	switch policy {
+	case "reject":
+		plan.IsBlocked = len(plan.ChildNodes) > 0 || len(plan.DeletedHardware) > 0
+	case "cascade":
+		for _, item := range items[1:] {
+			plan.DeletedNodes = append(plan.DeletedNodes, item.Node)
+		}
+	case "reparent":
+		plan.NewParent = plan.Node.Parent
+		plan.ReparentedNodes = plan.ChildNodes
+
+		for _, child := range plan.ChildNodes {
+			child.Parent = plan.NewParent
+
+			violation, e := h.NodeRepo.ValidateNodeTopology(child)
+			if e != nil {
+				return nil, errors.NewHTTPError(e, "failed to validate node topology", http.StatusInternalServerError)
+			}
+
+			if violation != nil {
+				return nil, errors.NewHTTPError(nil, violation.Message, http.StatusBadRequest).WithDetails(violation)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+func (h *DefaultNodeHandler) HandlerRestoreNode(c *gin.Context) {
+	session, isAdmin, _ := h.Privilege.getPrivilege(c)
+
+	if !isAdmin {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	var (
+		err  error
+		node models.Node
+	)
+
+	node.ID, err = strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	if err = h.NodeRepo.GetNode(&node); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "node not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get node", http.StatusInternalServerError))
+		return
+	}
+
+	if !node.IsDelete {
+		c.Error(errors.NewHTTPError(nil, "node is not deleted", http.StatusBadRequest))
+		return
+	}
+
+	if err = h.NodeRepo.RestoreNode(node.ID, session.User.Id, time.Now().Unix()); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to restore node", http.StatusInternalServerError))
+		return
+	}
+
+	node.IsDelete = false
+	node.DeletedAt = sql.NullInt64{}
+	node.DeletedBy = sql.NullInt32{}
+
+	event := models.Event{
+		HouseId:     node.HouseId,
+		Node:        &models.Node{ID: node.ID},
+		Hardware:    nil,
+		UserId:      session.User.Id,
+		Description: fmt.Sprintf("Восстановление узла: %s", node.Name),
+		CreatedAt:   time.Now().Unix(),
+	}
+
+	if err = h.EventRepo.CreateEvent(event); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to create event", http.StatusInternalServerError))
+	}
+
+	go func() {
+		if e := h.SendSingleNode(context.Background(), node.ID); e != nil {
 			log.Printf("failed to send single node: %v\n", e)
 			h.Logger.Println(e)
 		}
 	}()
 
-	c.JSON(http.StatusOK, true)
+	c.JSON(http.StatusOK, node)
 }
 
 func (h *DefaultNodeHandler) HandlerGetSearchNodes(c *gin.Context) {
@@ -494,12 +704,28 @@ func (h *DefaultNodeHandler) HandlerEditNode(c *gin.Context) {
 		return
 	}
 
+	violation, err := h.NodeRepo.ValidateNodeTopology(node)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to validate node topology", http.StatusInternalServerError))
+		return
+	}
+
+	if violation != nil {
+		c.Error(errors.NewHTTPError(nil, violation.Message, http.StatusBadRequest).WithDetails(violation))
+		return
+	}
+
 	node.UpdatedAt = sql.NullInt64{
 		Int64: time.Now().Unix(),
 		Valid: true,
 	}
 
-	if err := h.NodeRepo.EditNode(&node); err != nil {
+	if err = h.NodeRepo.EditNode(&node, session.User.Id); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "node not found", http.StatusNotFound))
+			return
+		}
+
 		c.Error(errors.NewHTTPError(err, "failed to edit node", http.StatusInternalServerError))
 		return
 	}
@@ -547,9 +773,20 @@ func (h *DefaultNodeHandler) HandlerCreateNode(c *gin.Context) {
 		return
 	}
 
+	violation, err := h.NodeRepo.ValidateNodeTopology(node)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to validate node topology", http.StatusInternalServerError))
+		return
+	}
+
+	if violation != nil {
+		c.Error(errors.NewHTTPError(nil, violation.Message, http.StatusBadRequest).WithDetails(violation))
+		return
+	}
+
 	node.CreatedAt = time.Now().Unix()
 
-	if err := h.NodeRepo.CreateNode(&node); err != nil {
+	if err = h.NodeRepo.CreateNode(&node, session.User.Id); err != nil {
 		c.Error(errors.NewHTTPError(err, "failed to create node", http.StatusInternalServerError))
 		return
 	}
@@ -685,6 +922,275 @@ func (h *DefaultNodeHandler) HandlerGetNodes(c *gin.Context) {
 	})
 }
 
+func (h *DefaultNodeHandler) HandlerGetNodeTree(c *gin.Context) {
+	nodeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	items, err := h.NodeRepo.GetNodeTree(nodeID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get node tree", http.StatusInternalServerError))
+		return
+	}
+
+	if len(items) == 0 {
+		c.Error(errors.NewHTTPError(nil, "node not found", http.StatusNotFound))
+		return
+	}
+
+	ctx := h.Metadata.SetAuthorizationHeader(c)
+
+	if err = h.getAddressesForTreeItems(ctx, items); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get addresses", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, buildNodeTree(items))
+}
+
+func (h *DefaultNodeHandler) HandlerGetNodePath(c *gin.Context) {
+	nodeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	items, err := h.NodeRepo.GetNodePath(nodeID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get node path", http.StatusInternalServerError))
+		return
+	}
+
+	if len(items) == 0 {
+		c.Error(errors.NewHTTPError(nil, "node not found", http.StatusNotFound))
+		return
+	}
+
+	ctx := h.Metadata.SetAuthorizationHeader(c)
+
+	if err = h.getAddressesForTreeItems(ctx, items); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get addresses", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *DefaultNodeHandler) HandlerMoveNode(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	var (
+		err  error
+		node models.Node
+		move models.NodeMove
+	)
+
+	node.ID, err = strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	if err = c.BindJSON(&move); err != nil {
+		c.Error(errors.NewHTTPError(err, "invalid json", http.StatusBadRequest))
+		return
+	}
+
+	if err = h.NodeRepo.GetNode(&node); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "node not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get node", http.StatusInternalServerError))
+		return
+	}
+
+	if node.IsDelete {
+		c.Error(errors.NewHTTPError(nil, "node is deleted", http.StatusBadRequest))
+		return
+	}
+
+	if node.IsPassive && move.ParentID != 0 {
+		c.Error(errors.NewHTTPError(nil, "passive node cannot have a parent", http.StatusBadRequest))
+		return
+	}
+
+	node.Parent = nil
+
+	if move.ParentID != 0 {
+		node.Parent = &models.Node{ID: move.ParentID}
+	}
+
+	violation, err := h.NodeRepo.ValidateNodeTopology(node)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to validate node topology", http.StatusInternalServerError))
+		return
+	}
+
+	if violation != nil {
+		c.Error(errors.NewHTTPError(nil, violation.Message, http.StatusBadRequest).WithDetails(violation))
+		return
+	}
+
+	items, err := h.NodeRepo.MoveNode(node.ID, move, session.User.Id)
+	if err != nil {
+		if goErrors.Is(err, database.ErrNodeCycle) {
+			c.Error(nodeCycleError(err, node.ID, move.ParentID))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to move node", http.StatusInternalServerError))
+		return
+	}
+
+	go func() {
+		ctx := context.Background()
+		nodeIDs := make([]int32, 0, len(items))
+
+		for _, item := range items {
+			nodeIDs = append(nodeIDs, int32(item.Node.ID))
+
+			if e := h.SendSingleNode(ctx, item.Node.ID); e != nil {
+				log.Printf("failed to send single node: %v\n", e)
+				h.Logger.Println(e)
+			}
+		}
+
+		// В индексе оборудования хранится адрес узла, поэтому оборудование ветки тоже переиндексируется
+		hardware, e := h.HardwareRepo.GetHardwareByNodeIDs(nodeIDs)
+		if e != nil {
+			log.Printf("failed to get hardware of moved nodes: %v\n", e)
+			h.Logger.Println(e)
+			return
+		}
+
+		for _, hd := range hardware {
+			if e = sendSingleHardware(ctx, h.HardwareRepo, h.AddressService, h.HardwareProducer, hd.ID); e != nil {
+				log.Printf("failed to send single hardware: %v\n", e)
+				h.Logger.Println(e)
+			}
+		}
+	}()
+
+	ctx := h.Metadata.SetAuthorizationHeader(c)
+
+	if err = h.getAddressesForTreeItems(ctx, items); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get addresses", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, buildNodeTree(items))
+}
+
+func (h *DefaultNodeHandler) HandlerGetNodeTypeRules(c *gin.Context) {
+	rules, err := h.NodeRepo.GetNodeTypeRules()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get node type rules", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *DefaultNodeHandler) HandlerCreateNodeTypeRule(c *gin.Context) {
+	_, isAdmin, _ := h.Privilege.getPrivilege(c)
+
+	if !isAdmin {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	var rule models.NodeTypeRule
+
+	if err := c.BindJSON(&rule); err != nil {
+		c.Error(errors.NewHTTPError(err, "invalid json", http.StatusBadRequest))
+		return
+	}
+
+	if rule.ParentType.ID == 0 || rule.ChildType.ID == 0 {
+		c.Error(errors.NewHTTPError(nil, "invalid node type rule data", http.StatusBadRequest))
+		return
+	}
+
+	rule.CreatedAt = time.Now().Unix()
+
+	if err := h.NodeRepo.CreateNodeTypeRule(&rule); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to create node type rule", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *DefaultNodeHandler) HandlerDeleteNodeTypeRule(c *gin.Context) {
+	_, isAdmin, _ := h.Privilege.getPrivilege(c)
+
+	if !isAdmin {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	ruleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	if err = h.NodeRepo.DeleteNodeTypeRule(ruleID); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to delete node type rule", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
+
+// buildNodeTree Собирает плоский список узлов, упорядоченный по глубине, во вложенное дерево
+func buildNodeTree(items []models.NodeTreeItem) *models.NodeTreeItem {
+	itemMap := make(map[int]*models.NodeTreeItem)
+
+	for i := range items {
+		itemMap[items[i].Node.ID] = &items[i]
+	}
+
+	for i := 1; i < len(items); i++ {
+		if items[i].Node.Parent == nil {
+			continue
+		}
+
+		if parent, ok := itemMap[items[i].Node.Parent.ID]; ok {
+			parent.Children = append(parent.Children, &items[i])
+		}
+	}
+
+	return &items[0]
+}
+
+func (h *DefaultNodeHandler) getAddressesForTreeItems(ctx context.Context, items []models.NodeTreeItem) error {
+	nodes := make([]models.Node, len(items))
+
+	for i := range items {
+		nodes[i] = items[i].Node
+	}
+
+	if err := h.getAddressesForNodes(ctx, nodes); err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i].Node.Address = nodes[i].Address
+	}
+
+	return nil
+}
+
 func (h *DefaultNodeHandler) getAddressesForNodes(ctx context.Context, nodes []models.Node) error {
 	houseIDSet := make(map[int32]struct{})
 	addressMap := make(map[int32]*addresspb.Address)
@@ -713,3 +1219,15 @@ func (h *DefaultNodeHandler) getAddressesForNodes(ctx context.Context, nodes []m
 
 	return nil
 }
+
+// nodeCycleError Ответ на цикл, найденный при повторной проверке в транзакции переноса
+func nodeCycleError(err error, nodeID int, parentID int) *errors.HTTPError {
+	violation := &models.TopologyViolation{
+		Code:     "CYCLE",
+		Message:  "parent node is a descendant of the node",
+		NodeID:   nodeID,
+		ParentID: parentID,
+	}
+
+	return errors.NewHTTPError(err, violation.Message, http.StatusBadRequest).WithDetails(violation)
+}
@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nodeImportColumns Допустимые заголовки столбцов файла импорта для каждого поля узла
+var nodeImportColumns = map[string][]string{
+	"name":        {"name", "название", "наименование", "узел"},
+	"address":     {"address", "адрес"},
+	"owner":       {"owner", "владелец"},
+	"type":        {"type", "тип"},
+	"zone":        {"zone", "зона"},
+	"placement":   {"placement", "размещение"},
+	"supply":      {"supply", "питание", "электропитание"},
+	"access":      {"access", "доступ"},
+	"description": {"description", "описание"},
+	"passive":     {"passive", "пассивный"},
+	"parent":      {"parent", "родитель", "родительский узел"},
+	"latitude":    {"latitude", "lat", "широта"},
+	"longitude":   {"longitude", "lon", "lng", "долгота"},
+}
+
+// HandlerImportNodes Импортирует узлы из XLSX или CSV файла. Первая строка файла - заголовки столбцов.
+// Родитель указывается либо ID существующего узла, либо названием узла из строк выше в том же файле.
+// По умолчанию (dry_run=true) возвращает только отчет о проверке строк, при dry_run=false
+// создает все узлы в одной транзакции, если ни в одной строке нет ошибок
+func (h *DefaultNodeHandler) HandlerImportNodes(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultPostForm("dry_run", "true"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(dry_run) to bool", http.StatusBadRequest))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get file", http.StatusBadRequest))
+		return
+	}
+
+	srcFile, err := file.Open()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to open file", http.StatusBadRequest))
+		return
+	}
+	defer srcFile.Close()
+
+	records, err := readImportRecords(srcFile, strings.ToLower(filepath.Ext(file.Filename)))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to read file", http.StatusBadRequest))
+		return
+	}
+
+	if len(records) < 2 {
+		c.Error(errors.NewHTTPError(nil, "file has no data rows", http.StatusBadRequest))
+		return
+	}
+
+	columns := make(map[string]int)
+
+	for i, header := range records[0] {
+		header = normalizeImportValue(header)
+
+		for field, aliases := range nodeImportColumns {
+			for _, alias := range aliases {
+				if header == alias {
+					columns[field] = i
+				}
+			}
+		}
+	}
+
+	var missingColumns []string
+
+	for _, field := range []string{"name", "address", "owner"} {
+		if _, ok := columns[field]; !ok {
+			missingColumns = append(missingColumns, field)
+		}
+	}
+
+	if len(missingColumns) > 0 {
+		c.Error(errors.NewHTTPError(nil, "required columns are missing", http.StatusBadRequest).WithDetails(missingColumns))
+		return
+	}
+
+	owners, err := h.ReferenceRepo.GetReferenceRecords("OWNERS")
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get owners", http.StatusInternalServerError))
+		return
+	}
+
+	nodeTypes, err := h.ReferenceRepo.GetReferenceRecords("NODE_TYPES")
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get node types", http.StatusInternalServerError))
+		return
+	}
+
+	rules, err := h.NodeRepo.GetNodeTypeRules()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get node type rules", http.StatusInternalServerError))
+		return
+	}
+
+	ownerMap := make(map[string]models.Reference)
+	nodeTypeMap := make(map[string]models.Reference)
+	ruleMap := make(map[[2]int]struct{})
+
+	for _, owner := range owners {
+		ownerMap[normalizeImportValue(owner.Value)] = owner
+	}
+
+	for _, nodeType := range nodeTypes {
+		nodeTypeMap[normalizeImportValue(nodeType.Value)] = nodeType
+	}
+
+	for _, rule := range rules {
+		ruleMap[[2]int{rule.ParentType.ID, rule.ChildType.ID}] = struct{}{}
+	}
+
+	ctx := h.Metadata.SetAuthorizationHeader(c)
+
+	report := models.NodeImportReport{DryRun: dryRun}
+	addressCache := make(map[string]*addresspb.Address)
+	rowByName := make(map[string]int)
+	rowIndex := make(map[int]int)
+	nameCount := make(map[string]int)
+
+	// Родитель по имени должен быть однозначным во всем файле, поэтому имена считаются заранее
+	if index, ok := columns["name"]; ok {
+		for _, record := range records[1:] {
+			if index < len(record) {
+				if name := normalizeImportValue(record[index]); name != "" {
+					nameCount[name]++
+				}
+			}
+		}
+	}
+
+	for i, record := range records[1:] {
+		cell := func(field string) string {
+			index, ok := columns[field]
+			if !ok || index >= len(record) {
+				return ""
+			}
+
+			return strings.TrimSpace(record[index])
+		}
+
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := models.NodeImportRow{Row: i + 2}
+		node := &row.Node
+
+		node.Name = cell("name")
+		if node.Name == "" {
+			row.Errors = append(row.Errors, "name is empty")
+		}
+
+		if owner, ok := ownerMap[normalizeImportValue(cell("owner"))]; ok {
+			node.Owner = owner
+		} else {
+			row.Errors = append(row.Errors, fmt.Sprintf("owner not found (%s)", cell("owner")))
+		}
+
+		if value := cell("passive"); value != "" {
+			node.IsPassive = parseImportBool(value)
+		}
+
+		if value := cell("type"); value != "" && !node.IsPassive {
+			if nodeType, ok := nodeTypeMap[normalizeImportValue(value)]; ok {
+				node.Type = &models.Reference{ID: nodeType.ID, Value: nodeType.Value}
+			} else {
+				row.Errors = append(row.Errors, fmt.Sprintf("node type not found (%s)", value))
+			}
+		}
+
+		node.Zone = importNullString(cell("zone"))
+		node.Placement = importNullString(cell("placement"))
+		node.Supply = importNullString(cell("supply"))
+		node.Access = importNullString(cell("access"))
+		node.Description = importNullString(cell("description"))
+
+		if latitude, longitude := cell("latitude"), cell("longitude"); latitude != "" || longitude != "" {
+			if coordinatesErr := parseImportCoordinates(node, latitude, longitude); coordinatesErr != "" {
+				row.Errors = append(row.Errors, coordinatesErr)
+			}
+		}
+
+		address, addressErr := h.resolveImportAddress(ctx, cell("address"), addressCache)
+		if addressErr != "" {
+			row.Errors = append(row.Errors, addressErr)
+		} else {
+			node.Address = address
+			node.HouseId = address.House.Id
+		}
+
+		if parent := cell("parent"); parent != "" {
+			if node.IsPassive {
+				row.Errors = append(row.Errors, "passive node cannot have a parent")
+			} else if parentID, e := strconv.Atoi(parent); e == nil {
+				row.Errors = append(row.Errors, h.validateImportExistingParent(node, parentID)...)
+			} else if count := nameCount[normalizeImportValue(parent)]; count > 1 {
+				row.Errors = append(row.Errors, fmt.Sprintf("ambiguous parent, %d rows are named %s", count, parent))
+			} else if parentRow, ok := rowByName[normalizeImportValue(parent)]; ok {
+				row.ParentRow = parentRow
+				parentNode := report.Rows[rowIndex[parentRow]]
+
+				if len(parentNode.Errors) > 0 {
+					row.Errors = append(row.Errors, fmt.Sprintf("parent row %d is invalid", parentRow))
+				} else if parentNode.Node.Type != nil && node.Type != nil {
+					if _, allowed := ruleMap[[2]int{parentNode.Node.Type.ID, node.Type.ID}]; !allowed {
+						row.Errors = append(row.Errors, fmt.Sprintf("node of type %s cannot be placed under node of type %s", node.Type.Value, parentNode.Node.Type.Value))
+					}
+				}
+			} else {
+				row.Errors = append(row.Errors, fmt.Sprintf("parent not found among existing nodes or rows above (%s)", parent))
+			}
+		}
+
+		if node.Name != "" {
+			rowByName[normalizeImportValue(node.Name)] = row.Row
+		}
+
+		if len(row.Errors) == 0 {
+			report.Valid++
+		} else {
+			report.Invalid++
+		}
+
+		rowIndex[row.Row] = len(report.Rows)
+		report.Rows = append(report.Rows, row)
+	}
+
+	report.Total = len(report.Rows)
+
+	if report.Total == 0 {
+		c.Error(errors.NewHTTPError(nil, "file has no data rows", http.StatusBadRequest))
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	if report.Invalid > 0 {
+		c.Error(errors.NewHTTPError(nil, "import file contains invalid rows", http.StatusBadRequest).WithDetails(report))
+		return
+	}
+
+	if err = h.NodeRepo.ImportNodes(report.Rows, session.User.Id, time.Now().Unix()); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to import nodes", http.StatusInternalServerError))
+		return
+	}
+
+	report.Committed = true
+
+	go func() {
+		for _, row := range report.Rows {
+			if e := h.SendSingleNode(context.Background(), row.Node.ID); e != nil {
+				log.Printf("failed to send single node: %v\n", e)
+				h.Logger.Println(e)
+			}
+		}
+	}()
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *DefaultNodeHandler) validateImportExistingParent(node *models.Node, parentID int) []string {
+	parent := models.Node{ID: parentID}
+
+	if err := h.NodeRepo.GetNode(&parent); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return []string{fmt.Sprintf("parent node not found (%d)", parentID)}
+		}
+
+		return []string{fmt.Sprintf("failed to get parent node (%d)", parentID)}
+	}
+
+	if parent.IsDelete {
+		return []string{fmt.Sprintf("parent node is deleted (%d)", parentID)}
+	}
+
+	node.Parent = &models.Node{ID: parent.ID, Name: parent.Name, HouseId: parent.HouseId}
+
+	violation, err := h.NodeRepo.ValidateNodeTopology(*node)
+	if err != nil {
+		return []string{"failed to validate node topology"}
+	}
+
+	if violation != nil {
+		return []string{violation.Message}
+	}
+
+	return nil
+}
+
+// resolveImportAddress Ищет дом через сервис адресов. При нескольких совпадениях выбирается
+// точно совпадающий адрес, иначе строка считается неоднозначной
+func (h *DefaultNodeHandler) resolveImportAddress(ctx context.Context, value string, cache map[string]*addresspb.Address) (*addresspb.Address, string) {
+	if value == "" {
+		return nil, "address is empty"
+	}
+
+	key := normalizeImportValue(value)
+
+	if address, ok := cache[key]; ok {
+		return address, ""
+	}
+
+	res, err := h.AddressService.SearchAddresses(ctx, &addresspb.SearchAddressesRequest{
+		Search: value,
+		Offset: 0,
+		Limit:  5,
+	})
+	if err != nil {
+		return nil, fmt.Sprintf("failed to search address (%s)", value)
+	}
+
+	var address *addresspb.Address
+
+	if len(res.Addresses) == 1 {
+		address = res.Addresses[0]
+	} else {
+		for _, candidate := range res.Addresses {
+			if normalizeImportValue(formatAddress(candidate)) == key {
+				address = candidate
+				break
+			}
+		}
+	}
+
+	if address == nil || address.House == nil {
+		if len(res.Addresses) == 0 {
+			return nil, fmt.Sprintf("address not found (%s)", value)
+		}
+
+		return nil, fmt.Sprintf("address is ambiguous, %d matches (%s)", res.Total, value)
+	}
+
+	cache[key] = address
+
+	return address, ""
+}
+
+func readImportRecords(r io.Reader, ext string) ([][]string, error) {
+	switch ext {
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, goErrors.New("workbook has no sheets")
+		}
+
+		return f.GetRows(sheets[0])
+	case ".csv":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+
+		firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+		if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+			reader.Comma = ';'
+		}
+
+		return reader.ReadAll()
+	default:
+		return nil, fmt.Errorf("file type is unsupported (%s)", ext)
+	}
+}
+
+func normalizeImportValue(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+func parseImportBool(value string) bool {
+	switch normalizeImportValue(value) {
+	case "1", "+", "да", "yes", "true", "y", "д":
+		return true
+	}
+
+	return false
+}
+
+func importNullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// parseImportCoordinates Разбирает координаты узла, допуская запятую в качестве десятичного разделителя
+func parseImportCoordinates(node *models.Node, latitude, longitude string) string {
+	lat, err := strconv.ParseFloat(strings.ReplaceAll(latitude, ",", "."), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return fmt.Sprintf("invalid latitude (%s)", latitude)
+	}
+
+	lon, err := strconv.ParseFloat(strings.ReplaceAll(longitude, ",", "."), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return fmt.Sprintf("invalid longitude (%s)", longitude)
+	}
+
+	node.Latitude = sql.NullFloat64{Float64: lat, Valid: true}
+	node.Longitude = sql.NullFloat64{Float64: lon, Valid: true}
+
+	return ""
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type PortHandler interface {
+	HandlerGetHardwarePorts(c *gin.Context)
+	HandlerCreatePort(c *gin.Context)
+	HandlerEditPort(c *gin.Context)
+	HandlerDeletePort(c *gin.Context)
+	HandlerSetPortVlans(c *gin.Context)
+	HandlerSearchPorts(c *gin.Context)
+}
+
+type DefaultPortHandler struct {
+	Privilege      Privilege
+	PortRepo       database.PortRepository
+	HardwareRepo   database.HardwareRepository
+	EventRepo      database.EventRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+func NewPortHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) PortHandler {
+	return &DefaultPortHandler{
+		Privilege: &DefaultPrivilege{},
+		PortRepo: &database.DefaultPortRepository{
+			Database: *db,
+		},
+		HardwareRepo: &database.DefaultHardwareRepository{
+			Database: *db,
+		},
+		EventRepo: &database.DefaultEventRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+// HandlerGetHardwarePorts Возвращает порты оборудования, заполненные вручную или по SNMP
+func (h *DefaultPortHandler) HandlerGetHardwarePorts(c *gin.Context) {
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	ports, err := h.PortRepo.GetHardwarePorts(hardwareID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get ports", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, ports)
+}
+
+func (h *DefaultPortHandler) HandlerCreatePort(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardware, port, httpErr := h.bindPort(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	port.CreatedAt = time.Now().Unix()
+
+	if err := h.PortRepo.CreatePort(&port); err != nil {
+		if isUniqueViolation(err) {
+			c.Error(errors.NewHTTPError(err, "port number already exists", http.StatusConflict))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to create port", http.StatusInternalServerError))
+		return
+	}
+
+	h.createPortEvent(c, session.User.Id, hardware, fmt.Sprintf("Создание порта %d оборудования: %s", port.Number, hardware.Type.Value))
+
+	c.JSON(http.StatusOK, port)
+}
+
+func (h *DefaultPortHandler) HandlerEditPort(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardware, port, httpErr := h.bindPort(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	port.UpdatedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+
+	if err := h.PortRepo.EditPort(&port); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "port not found", http.StatusNotFound))
+			return
+		}
+
+		if isUniqueViolation(err) {
+			c.Error(errors.NewHTTPError(err, "port number already exists", http.StatusConflict))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to edit port", http.StatusInternalServerError))
+		return
+	}
+
+	h.createPortEvent(c, session.User.Id, hardware, fmt.Sprintf("Изменение порта %d оборудования: %s", port.Number, hardware.Type.Value))
+
+	c.JSON(http.StatusOK, port)
+}
+
+func (h *DefaultPortHandler) HandlerDeletePort(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	portID, err := strconv.Atoi(c.Param("portId"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(portId) to int", http.StatusBadRequest))
+		return
+	}
+
+	port := models.HardwarePort{ID: portID}
+
+	if err = h.PortRepo.GetPort(&port); err != nil || port.Hardware.ID != hardwareID {
+		if err == nil || goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "port not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get port", http.StatusInternalServerError))
+		return
+	}
+
+	hardware := models.Hardware{ID: hardwareID}
+
+	if err = h.HardwareRepo.GetHardwareByID(&hardware); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError))
+		return
+	}
+
+	if err = h.PortRepo.DeletePort(port); err != nil {
+		if isForeignKeyViolation(err) {
+			c.Error(errors.NewHTTPError(err, "port has a cable", http.StatusConflict))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to delete port", http.StatusInternalServerError))
+		return
+	}
+
+	h.createPortEvent(c, session.User.Id, hardware, fmt.Sprintf("Удаление порта %d оборудования: %s", port.Number, hardware.Type.Value))
+
+	c.JSON(http.StatusOK, port)
+}
+
+// HandlerSetPortVlans Заменяет членство порта во VLAN введенным вручную. Нетегированный VLAN порта
+// обновляется вместе с членством
+func (h *DefaultPortHandler) HandlerSetPortVlans(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	portID, err := strconv.Atoi(c.Param("portId"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(portId) to int", http.StatusBadRequest))
+		return
+	}
+
+	var vlans []models.PortVlan
+
+	if err = c.BindJSON(&vlans); err != nil {
+		c.Error(errors.NewHTTPError(err, "invalid json", http.StatusBadRequest))
+		return
+	}
+
+	if !h.PortRepo.ValidatePortVlans(vlans) {
+		c.Error(errors.NewHTTPError(nil, "invalid port vlans data", http.StatusBadRequest))
+		return
+	}
+
+	port := models.HardwarePort{ID: portID}
+
+	if err = h.PortRepo.GetPort(&port); err != nil || port.Hardware.ID != hardwareID {
+		if err == nil || goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "port not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get port", http.StatusInternalServerError))
+		return
+	}
+
+	hardware := models.Hardware{ID: hardwareID}
+
+	if err = h.HardwareRepo.GetHardwareByID(&hardware); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError))
+		return
+	}
+
+	updatedAt := time.Now().Unix()
+
+	if err = h.PortRepo.SetPortVlans(port.ID, vlans, updatedAt); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to set port vlans", http.StatusInternalServerError))
+		return
+	}
+
+	port.UntaggedVlan = sql.NullInt32{}
+
+	for i := range vlans {
+		vlans[i].Source = "MANUAL"
+		vlans[i].UpdatedAt = updatedAt
+
+		if !vlans[i].Tagged {
+			port.UntaggedVlan = sql.NullInt32{Int32: int32(vlans[i].VlanID), Valid: true}
+		}
+	}
+
+	port.UpdatedAt = sql.NullInt64{Int64: updatedAt, Valid: true}
+
+	if err = h.PortRepo.EditPort(&port); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to edit port", http.StatusInternalServerError))
+		return
+	}
+
+	port.Vlans = vlans
+
+	h.createPortEvent(c, session.User.Id, hardware, fmt.Sprintf("Изменение VLAN порта %d оборудования: %s", port.Number, hardware.Type.Value))
+
+	c.JSON(http.StatusOK, port)
+}
+
+// HandlerSearchPorts Ищет порты по описанию, чтобы ответить на вопрос "на каком порту квартира 45"
+func (h *DefaultPortHandler) HandlerSearchPorts(c *gin.Context) {
+	search := c.Query("search")
+	if search == "" {
+		c.Error(errors.NewHTTPError(nil, "query(search) is required", http.StatusBadRequest))
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(offset) to int", http.StatusBadRequest))
+		return
+	}
+
+	ports, count, err := h.PortRepo.SearchPorts(search, offset)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to search ports", http.StatusInternalServerError))
+		return
+	}
+
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for _, port := range ports {
+		if _, ok := houseIDSet[port.Hardware.Node.HouseId]; !ok {
+			houseIDSet[port.Hardware.Node.HouseId] = struct{}{}
+			houseIDs = append(houseIDs, port.Hardware.Node.HouseId)
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		res, e := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, "failed to get addresses", http.StatusInternalServerError))
+			return
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for i := range ports {
+		ports[i].Hardware.Node.Address = addressMap[ports[i].Hardware.Node.HouseId]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": ports,
+		"Count": count,
+	})
+}
+
+// bindPort Разбирает порт из тела запроса, привязывает его к оборудованию из пути и проверяет данные
+func (h *DefaultPortHandler) bindPort(c *gin.Context) (models.Hardware, models.HardwarePort, *errors.HTTPError) {
+	var port models.HardwarePort
+
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.Hardware{}, port, errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest)
+	}
+
+	if err = c.BindJSON(&port); err != nil {
+		return models.Hardware{}, port, errors.NewHTTPError(err, "invalid json", http.StatusBadRequest)
+	}
+
+	port.Hardware = models.Hardware{ID: hardwareID}
+
+	if !h.PortRepo.ValidatePort(port) {
+		return models.Hardware{}, port, errors.NewHTTPError(nil, "invalid port data", http.StatusBadRequest)
+	}
+
+	hardware := models.Hardware{ID: hardwareID}
+
+	if err = h.HardwareRepo.GetHardwareByID(&hardware); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return hardware, port, errors.NewHTTPError(err, "hardware not found", http.StatusNotFound)
+		}
+
+		return hardware, port, errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError)
+	}
+
+	return hardware, port, nil
+}
+
+func (h *DefaultPortHandler) createPortEvent(c *gin.Context, userID int32, hardware models.Hardware, description string) {
+	if err := h.EventRepo.CreateEvent(models.Event{
+		HouseId:     hardware.Node.HouseId,
+		Node:        &models.Node{ID: hardware.Node.ID},
+		Hardware:    &models.Hardware{ID: hardware.ID},
+		UserId:      userID,
+		Description: description,
+		CreatedAt:   time.Now().Unix(),
+	}); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to create event", http.StatusInternalServerError))
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+
+	return goErrors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isConstraintViolation Проверяет, что запрос нарушил ограничение или уникальный индекс с именем constraint
+func isConstraintViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+
+	return goErrors.As(err, &pqErr) && pqErr.Constraint == constraint
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+
+	return goErrors.As(err, &pqErr) && pqErr.Code == "23503"
+}
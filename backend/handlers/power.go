@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+type PowerHandler interface {
+	HandlerGetPowerAlerts(c *gin.Context)
+	HandlerGetHardwareBattery(c *gin.Context)
+}
+
+type DefaultPowerHandler struct {
+	PowerRepo      database.PowerRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+	Threshold      int
+}
+
+func NewPowerHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) PowerHandler {
+	threshold, err := strconv.Atoi(os.Getenv("POWER_CHARGE_THRESHOLD"))
+	if err != nil {
+		threshold = 50
+	}
+
+	return &DefaultPowerHandler{
+		PowerRepo: &database.DefaultPowerRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+		Threshold:      threshold,
+	}
+}
+
+// HandlerGetPowerAlerts Возвращает узлы с адресами домов, ИБП которых работает от батареи или разряжен.
+// Порог заряда в процентах берется из POWER_CHARGE_THRESHOLD, его можно переопределить параметром threshold
+func (h *DefaultPowerHandler) HandlerGetPowerAlerts(c *gin.Context) {
+	threshold, err := strconv.Atoi(c.DefaultQuery("threshold", strconv.Itoa(h.Threshold)))
+	if err != nil || threshold < 0 || threshold > 100 {
+		c.Error(errors.NewHTTPError(err, "threshold must be between 0 and 100", http.StatusBadRequest))
+		return
+	}
+
+	alerts, err := h.PowerRepo.GetPowerAlerts(threshold)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get power alerts", http.StatusInternalServerError))
+		return
+	}
+
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for _, alert := range alerts {
+		if _, ok := houseIDSet[alert.Node.HouseId]; !ok {
+			houseIDSet[alert.Node.HouseId] = struct{}{}
+			houseIDs = append(houseIDs, alert.Node.HouseId)
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		res, e := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, "failed to get addresses", http.StatusInternalServerError))
+			return
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for i := range alerts {
+		alerts[i].Node.Address = addressMap[alerts[i].Node.HouseId]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Threshold": threshold,
+		"Items":     alerts,
+		"Count":     len(alerts),
+	})
+}
+
+// HandlerGetHardwareBattery Возвращает показания батареи ИБП за период from - to (unix time),
+// по умолчанию за последние сутки
+func (h *DefaultPowerHandler) HandlerGetHardwareBattery(c *gin.Context) {
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	from, to, httpErr := parsePeriod(c, 24*time.Hour)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	readings, err := h.PowerRepo.GetBatteryReadings(hardwareID, from, to)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get battery readings", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": readings,
+		"Count": len(readings),
+	})
+}
@@ -0,0 +1,538 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"database/sql"
+	"encoding/xml"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RackHandler interface {
+	HandlerGetNodeRacks(c *gin.Context)
+	HandlerGetRack(c *gin.Context)
+	HandlerGetRackSVG(c *gin.Context)
+	HandlerCreateRack(c *gin.Context)
+	HandlerEditRack(c *gin.Context)
+	HandlerDeleteRack(c *gin.Context)
+	HandlerSetHardwarePlacement(c *gin.Context)
+	HandlerDeleteHardwarePlacement(c *gin.Context)
+}
+
+type DefaultRackHandler struct {
+	Privilege      Privilege
+	RackRepo       database.RackRepository
+	HardwareRepo   database.HardwareRepository
+	EventRepo      database.EventRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+func NewRackHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) RackHandler {
+	return &DefaultRackHandler{
+		Privilege: &DefaultPrivilege{},
+		RackRepo: &database.DefaultRackRepository{
+			Database: *db,
+		},
+		HardwareRepo: &database.DefaultHardwareRepository{
+			Database: *db,
+		},
+		EventRepo: &database.DefaultEventRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+// Размеры схемы стойки в пикселях
+const (
+	rackUnitHeight   = 20
+	rackWidth        = 240
+	rackNumberWidth  = 30
+	rackHeaderHeight = 60
+	rackSideTitle    = 20
+	rackMargin       = 20
+)
+
+type svgDocument struct {
+	XMLName xml.Name   `xml:"http://www.w3.org/2000/svg svg"`
+	Width   int        `xml:"width,attr"`
+	Height  int        `xml:"height,attr"`
+	ViewBox string     `xml:"viewBox,attr"`
+	Groups  []svgGroup `xml:"g"`
+}
+
+type svgGroup struct {
+	FontFamily string    `xml:"font-family,attr,omitempty"`
+	FontSize   int       `xml:"font-size,attr,omitempty"`
+	Rects      []svgRect `xml:"rect"`
+	Texts      []svgText `xml:"text"`
+}
+
+type svgRect struct {
+	X           int    `xml:"x,attr"`
+	Y           int    `xml:"y,attr"`
+	Width       int    `xml:"width,attr"`
+	Height      int    `xml:"height,attr"`
+	Fill        string `xml:"fill,attr"`
+	Stroke      string `xml:"stroke,attr"`
+	StrokeWidth int    `xml:"stroke-width,attr,omitempty"`
+	Title       string `xml:"title,omitempty"`
+}
+
+type svgText struct {
+	X          int    `xml:"x,attr"`
+	Y          int    `xml:"y,attr"`
+	TextAnchor string `xml:"text-anchor,attr,omitempty"`
+	FontWeight string `xml:"font-weight,attr,omitempty"`
+	Value      string `xml:",chardata"`
+}
+
+// HandlerGetNodeRacks Возвращает стойки и шкафы узла с установленным оборудованием
+func (h *DefaultRackHandler) HandlerGetNodeRacks(c *gin.Context) {
+	nodeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	racks, err := h.RackRepo.GetRacks(nodeID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get racks", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": racks,
+		"Count": len(racks),
+	})
+}
+
+func (h *DefaultRackHandler) HandlerGetRack(c *gin.Context) {
+	rack, httpErr := h.getRack(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, rack)
+}
+
+// HandlerGetRackSVG Рисует схему стойки в SVG для страницы узла и печати в исполнительную документацию.
+// По умолчанию рисуются обе стороны, query(side) оставляет только FRONT или REAR
+func (h *DefaultRackHandler) HandlerGetRackSVG(c *gin.Context) {
+	sides := []string{models.RackSideFront, models.RackSideRear}
+
+	if side := strings.ToUpper(c.Query("side")); side != "" {
+		if _, ok := models.RackSides[side]; !ok {
+			c.Error(errors.NewHTTPError(nil, "side must be FRONT or REAR", http.StatusBadRequest))
+			return
+		}
+
+		sides = []string{side}
+	}
+
+	rack, httpErr := h.getRack(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	ctx := h.Metadata.SetAuthorizationHeader(c)
+
+	res, err := h.AddressService.GetAddress(ctx, &addresspb.GetAddressRequest{HouseId: rack.Node.HouseId})
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get addresses", http.StatusInternalServerError))
+		return
+	}
+
+	rack.Node.Address = &addresspb.Address{
+		Street: res.Street,
+		House:  res.House,
+	}
+
+	data, err := xml.MarshalIndent(buildRackSVG(*rack, sides), "", "  ")
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to generate SVG", http.StatusInternalServerError))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="rack_%d.svg"`, rack.ID))
+	c.Data(http.StatusOK, "image/svg+xml", append([]byte(xml.Header), data...))
+}
+
+func (h *DefaultRackHandler) HandlerCreateRack(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	var rack models.Rack
+
+	if err := c.BindJSON(&rack); err != nil {
+		c.Error(errors.NewHTTPError(err, "invalid json", http.StatusBadRequest))
+		return
+	}
+
+	if !h.RackRepo.ValidateRack(rack) {
+		c.Error(errors.NewHTTPError(nil, "invalid rack data", http.StatusBadRequest))
+		return
+	}
+
+	rack.CreatedAt = time.Now().Unix()
+
+	if err := h.RackRepo.CreateRack(&rack); err != nil {
+		if isForeignKeyViolation(err) {
+			c.Error(errors.NewHTTPError(err, "node not found", http.StatusBadRequest))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to create rack", http.StatusInternalServerError))
+		return
+	}
+
+	rack.Placements = make([]models.RackPlacement, 0)
+
+	h.createRackEvent(c, session.User.Id, rack, nil, fmt.Sprintf("Создание: %s %s", strings.ToLower(models.RackKinds[rack.Kind]), rack.Name))
+
+	c.JSON(http.StatusOK, rack)
+}
+
+// HandlerEditRack Изменяет стойку. Высоту нельзя сделать меньше верхнего занятого юнита
+func (h *DefaultRackHandler) HandlerEditRack(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	var rack models.Rack
+
+	if err := c.BindJSON(&rack); err != nil {
+		c.Error(errors.NewHTTPError(err, "invalid json", http.StatusBadRequest))
+		return
+	}
+
+	current, err := h.RackRepo.GetRack(rack.ID)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "rack not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to get rack", http.StatusInternalServerError))
+		return
+	}
+
+	rack.Node = current.Node
+
+	if !h.RackRepo.ValidateRack(rack) {
+		c.Error(errors.NewHTTPError(nil, "invalid rack data", http.StatusBadRequest))
+		return
+	}
+
+	rack.UpdatedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+
+	conflict, err := h.RackRepo.EditRack(&rack)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "rack not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to edit rack", http.StatusInternalServerError))
+		return
+	}
+
+	if conflict != nil {
+		c.Error(errors.NewHTTPError(nil, fmt.Sprintf("unit %d is occupied by hardware %d", conflict.Unit+conflict.Height-1,
+			conflict.Hardware.ID), http.StatusConflict))
+		return
+	}
+
+	h.createRackEvent(c, session.User.Id, rack, nil, fmt.Sprintf("Изменение: %s %s", strings.ToLower(models.RackKinds[rack.Kind]), rack.Name))
+
+	c.JSON(http.StatusOK, rack)
+}
+
+// HandlerDeleteRack Удаляет пустую стойку
+func (h *DefaultRackHandler) HandlerDeleteRack(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	rack, httpErr := h.getRack(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	if len(rack.Placements) > 0 {
+		c.Error(errors.NewHTTPError(nil, "rack has hardware", http.StatusConflict))
+		return
+	}
+
+	if err := h.RackRepo.DeleteRack(rack.ID); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to delete rack", http.StatusInternalServerError))
+		return
+	}
+
+	h.createRackEvent(c, session.User.Id, *rack, nil, fmt.Sprintf("Удаление: %s %s", strings.ToLower(models.RackKinds[rack.Kind]), rack.Name))
+
+	c.JSON(http.StatusOK, rack)
+}
+
+// HandlerSetHardwarePlacement Устанавливает оборудование в стойку его узла или переносит на другое место.
+// Height по умолчанию 1 юнит
+func (h *DefaultRackHandler) HandlerSetHardwarePlacement(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardware, httpErr := h.getHardware(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	var placement models.RackPlacement
+
+	if err := c.BindJSON(&placement); err != nil {
+		c.Error(errors.NewHTTPError(err, "invalid json", http.StatusBadRequest))
+		return
+	}
+
+	if placement.Height == 0 {
+		placement.Height = 1
+	}
+
+	placement.Side = strings.ToUpper(placement.Side)
+
+	if !h.RackRepo.ValidateRackPlacement(placement) {
+		c.Error(errors.NewHTTPError(nil, "invalid placement data", http.StatusBadRequest))
+		return
+	}
+
+	placement.Hardware = *hardware
+	placement.CreatedAt = time.Now().Unix()
+
+	conflict, err := h.RackRepo.SetHardwarePlacement(&placement, hardware.Node.ID)
+	if err != nil {
+		switch {
+		case goErrors.Is(err, sql.ErrNoRows):
+			c.Error(errors.NewHTTPError(err, "rack not found", http.StatusNotFound))
+		case goErrors.Is(err, database.ErrRackNotInNode), goErrors.Is(err, database.ErrRackUnitsExceeded):
+			c.Error(errors.NewHTTPError(err, err.Error(), http.StatusBadRequest))
+		default:
+			c.Error(errors.NewHTTPError(err, "failed to set placement", http.StatusInternalServerError))
+		}
+
+		return
+	}
+
+	if conflict != nil {
+		c.Error(errors.NewHTTPError(nil, fmt.Sprintf("units %d-%d are occupied by hardware %d", conflict.Unit,
+			conflict.Unit+conflict.Height-1, conflict.Hardware.ID), http.StatusConflict))
+		return
+	}
+
+	rack := models.Rack{ID: placement.RackID, Node: hardware.Node}
+
+	h.createRackEvent(c, session.User.Id, rack, hardware, fmt.Sprintf("Установка оборудования в стойку: %s, юнит %d, %s",
+		hardware.Type.Value, placement.Unit, strings.ToLower(models.RackSides[placement.Side])))
+
+	c.JSON(http.StatusOK, placement)
+}
+
+func (h *DefaultRackHandler) HandlerDeleteHardwarePlacement(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardware, httpErr := h.getHardware(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	if err := h.RackRepo.DeleteHardwarePlacement(hardware.ID); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "hardware is not placed in a rack", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to delete placement", http.StatusInternalServerError))
+		return
+	}
+
+	h.createRackEvent(c, session.User.Id, models.Rack{Node: hardware.Node}, hardware,
+		fmt.Sprintf("Снятие оборудования со стойки: %s", hardware.Type.Value))
+
+	c.JSON(http.StatusOK, hardware)
+}
+
+func (h *DefaultRackHandler) getRack(c *gin.Context) (*models.Rack, *errors.HTTPError) {
+	rackID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest)
+	}
+
+	rack, err := h.RackRepo.GetRack(rackID)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, errors.NewHTTPError(err, "rack not found", http.StatusNotFound)
+		}
+
+		return nil, errors.NewHTTPError(err, "failed to get rack", http.StatusInternalServerError)
+	}
+
+	return rack, nil
+}
+
+func (h *DefaultRackHandler) getHardware(c *gin.Context) (*models.Hardware, *errors.HTTPError) {
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest)
+	}
+
+	hardware := models.Hardware{ID: hardwareID}
+
+	if err = h.HardwareRepo.GetHardwareByID(&hardware); err != nil || hardware.IsDelete {
+		if err == nil || goErrors.Is(err, sql.ErrNoRows) {
+			return nil, errors.NewHTTPError(err, "hardware not found", http.StatusNotFound)
+		}
+
+		return nil, errors.NewHTTPError(err, "failed to get hardware", http.StatusInternalServerError)
+	}
+
+	return &hardware, nil
+}
+
+func (h *DefaultRackHandler) createRackEvent(c *gin.Context, userID int32, rack models.Rack, hardware *models.Hardware, description string) {
+	event := models.Event{
+		HouseId:     rack.Node.HouseId,
+		Node:        &models.Node{ID: rack.Node.ID},
+		UserId:      userID,
+		Description: description,
+		CreatedAt:   time.Now().Unix(),
+	}
+
+	if hardware != nil {
+		event.Hardware = &models.Hardware{ID: hardware.ID}
+	}
+
+	if err := h.EventRepo.CreateEvent(event); err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to create event", http.StatusInternalServerError))
+	}
+}
+
+// buildRackSVG Рисует стороны стойки рядом. Юниты нумеруются снизу, как на стойке
+func buildRackSVG(rack models.Rack, sides []string) svgDocument {
+	sideWidth := rackNumberWidth + rackWidth + rackMargin
+	width := rackMargin + sideWidth*len(sides)
+	height := rackHeaderHeight + rackSideTitle + rack.Units*rackUnitHeight + rackMargin*2
+
+	header := svgGroup{
+		FontFamily: "Arial, sans-serif",
+		FontSize:   14,
+		Rects:      []svgRect{{Width: width, Height: height, Fill: "#ffffff", Stroke: "none"}},
+		Texts: []svgText{
+			{X: rackMargin, Y: 24, FontWeight: "bold", Value: fmt.Sprintf("%s %s (%dU)", models.RackKinds[rack.Kind], rack.Name, rack.Units)},
+			{X: rackMargin, Y: 44, Value: strings.TrimSpace(fmt.Sprintf("Узел %s %s", rack.Node.Name, formatAddress(rack.Node.Address)))},
+		},
+	}
+
+	groups := []svgGroup{header}
+
+	for i, side := range sides {
+		left := rackMargin + i*sideWidth
+		top := rackHeaderHeight + rackSideTitle
+
+		group := svgGroup{
+			FontFamily: "Arial, sans-serif",
+			FontSize:   11,
+			Rects: []svgRect{{
+				X: left + rackNumberWidth, Y: top, Width: rackWidth, Height: rack.Units * rackUnitHeight,
+				Fill: "#f4f4f4", Stroke: "#333333", StrokeWidth: 2,
+			}},
+			Texts: []svgText{{
+				X: left + rackNumberWidth + rackWidth/2, Y: top - 6, TextAnchor: "middle", FontWeight: "bold",
+				Value: models.RackSides[side],
+			}},
+		}
+
+		for unit := 1; unit <= rack.Units; unit++ {
+			y := top + (rack.Units-unit)*rackUnitHeight
+
+			group.Rects = append(group.Rects, svgRect{
+				X: left + rackNumberWidth, Y: y, Width: rackWidth, Height: rackUnitHeight, Fill: "none", Stroke: "#cccccc",
+			})
+			group.Texts = append(group.Texts, svgText{
+				X: left + rackNumberWidth - 6, Y: y + rackUnitHeight - 6, TextAnchor: "end", Value: strconv.Itoa(unit),
+			})
+		}
+
+		for _, placement := range rack.Placements {
+			if placement.Side != side {
+				continue
+			}
+
+			y := top + (rack.Units-placement.Unit-placement.Height+1)*rackUnitHeight
+			label := rackHardwareLabel(placement.Hardware)
+
+			group.Rects = append(group.Rects, svgRect{
+				X: left + rackNumberWidth + 2, Y: y + 2, Width: rackWidth - 4, Height: placement.Height*rackUnitHeight - 4,
+				Fill: "#cfe3f7", Stroke: "#2b6cb0", StrokeWidth: 1, Title: label,
+			})
+			group.Texts = append(group.Texts, svgText{
+				X: left + rackNumberWidth + rackWidth/2, Y: y + placement.Height*rackUnitHeight/2 + 4, TextAnchor: "middle", Value: label,
+			})
+		}
+
+		groups = append(groups, group)
+	}
+
+	return svgDocument{
+		Width:   width,
+		Height:  height,
+		ViewBox: fmt.Sprintf("0 0 %d %d", width, height),
+		Groups:  groups,
+	}
+}
+
+// rackHardwareLabel Подпись оборудования на схеме: тип, модель и IP адрес, если они есть
+func rackHardwareLabel(hardware models.Hardware) string {
+	parts := []string{hardware.Type.Value}
+
+	if hardware.Switch.Name != "" {
+		parts = append(parts, hardware.Switch.Name)
+	}
+
+	if hardware.IpAddress.Valid && hardware.IpAddress.String != "" {
+		parts = append(parts, hardware.IpAddress.String)
+	}
+
+	return strings.Join(parts, " / ")
+}
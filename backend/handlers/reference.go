@@ -4,6 +4,8 @@ import (
 	"backend/database"
 	"backend/errors"
 	"backend/models"
+	"database/sql"
+	goErrors "errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -31,7 +33,7 @@ func NewReferenceHandler(db *database.Database) ReferenceHandler {
 }
 
 func (h *DefaultReferenceHandler) HandlerReferenceRecord(c *gin.Context, isEdit bool) {
-	_, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
 
 	if !isOperatorOrHigher {
 		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
@@ -65,14 +67,19 @@ func (h *DefaultReferenceHandler) HandlerReferenceRecord(c *gin.Context, isEdit
 
 	if !isEdit {
 		record.CreatedAt = time.Now().Unix()
-		err := h.ReferenceRepo.CreateReferenceRecord(&record, strings.ToUpper(reference))
+		err := h.ReferenceRepo.CreateReferenceRecord(&record, strings.ToUpper(reference), session.User.Id)
 		if err != nil {
 			c.Error(errors.NewHTTPError(err, fmt.Sprintf("failed to create %s", reference), http.StatusInternalServerError))
 			return
 		}
 	} else {
-		err := h.ReferenceRepo.EditReferenceRecord(&record, strings.ToUpper(reference))
+		err := h.ReferenceRepo.EditReferenceRecord(&record, strings.ToUpper(reference), session.User.Id)
 		if err != nil {
+			if goErrors.Is(err, sql.ErrNoRows) {
+				c.Error(errors.NewHTTPError(err, "reference record not found", http.StatusNotFound))
+				return
+			}
+
 			c.Error(errors.NewHTTPError(err, "failed to edit reference", http.StatusInternalServerError))
 			return
 		}
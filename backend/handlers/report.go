@@ -32,7 +32,7 @@ func NewReportHandler(db *database.Database, logger *utils.Logger) ReportHandler
 }
 
 func (h *DefaultReportHandler) HandlerEditReportData(c *gin.Context) {
-	_, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
 
 	if !isOperatorOrHigher {
 		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
@@ -46,7 +46,25 @@ func (h *DefaultReportHandler) HandlerEditReportData(c *gin.Context) {
 		return
 	}
 
-	if err := h.ReportRepo.EditReportData(reportData); err != nil {
+	allReportData, err := h.ReportRepo.GetReportData()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get report data", http.StatusInternalServerError))
+		return
+	}
+
+	// Данные отчета редактируются по ключу, поэтому ID для истории берем из текущих записей
+	for _, data := range allReportData {
+		if data.Key == reportData.Key {
+			reportData.ID = data.ID
+		}
+	}
+
+	if reportData.ID == 0 {
+		c.Error(errors.NewHTTPError(nil, "report data not found", http.StatusNotFound))
+		return
+	}
+
+	if err = h.ReportRepo.EditReportData(reportData, session.User.Id); err != nil {
 		c.Error(errors.NewHTTPError(err, "failed to edit report data", http.StatusInternalServerError))
 		return
 	}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/snmp"
+	"database/sql"
+	goErrors "errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type SnmpHandler interface {
+	HandlerGetHardwareSnmp(c *gin.Context)
+	HandlerPollHardwareSnmp(c *gin.Context)
+}
+
+type DefaultSnmpHandler struct {
+	Privilege Privilege
+	SnmpRepo  database.SnmpRepository
+	Poller    snmp.Poller
+}
+
+func NewSnmpHandler(poller snmp.Poller, db *database.Database) SnmpHandler {
+	return &DefaultSnmpHandler{
+		Privilege: &DefaultPrivilege{},
+		SnmpRepo: &database.DefaultSnmpRepository{
+			Database: *db,
+		},
+		Poller: poller,
+	}
+}
+
+// HandlerGetHardwareSnmp Возвращает последние показания SNMP оборудования и состояние последнего опроса
+func (h *DefaultSnmpHandler) HandlerGetHardwareSnmp(c *gin.Context) {
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	poll, err := h.SnmpRepo.GetSnmpPoll(hardwareID)
+	if err != nil && !goErrors.Is(err, sql.ErrNoRows) {
+		c.Error(errors.NewHTTPError(err, "failed to get snmp poll", http.StatusInternalServerError))
+		return
+	}
+
+	readings, err := h.SnmpRepo.GetSnmpReadings(hardwareID, c.DefaultQuery("metric", ""))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get snmp readings", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Poll":     poll,
+		"Readings": readings,
+	})
+}
+
+// HandlerPollHardwareSnmp Опрашивает оборудование сразу, не дожидаясь фонового опроса
+func (h *DefaultSnmpHandler) HandlerPollHardwareSnmp(c *gin.Context) {
+	_, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	poll, err := h.Poller.PollHardware(hardwareID)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "hardware has no ip address or switch model", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to poll hardware", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
@@ -4,6 +4,8 @@ import (
 	"backend/database"
 	"backend/errors"
 	"backend/models"
+	"database/sql"
+	goErrors "errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"time"
@@ -40,7 +42,7 @@ func (h *DefaultSwitchHandler) HandlerGetSwitches(c *gin.Context) {
 }
 
 func (h *DefaultSwitchHandler) HandlerEditSwitch(c *gin.Context) {
-	_, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
 
 	if !isOperatorOrHigher {
 		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
@@ -59,7 +61,12 @@ func (h *DefaultSwitchHandler) HandlerEditSwitch(c *gin.Context) {
 		return
 	}
 
-	if err := h.SwitchRepo.EditSwitch(&_switch); err != nil {
+	if err := h.SwitchRepo.EditSwitch(&_switch, session.User.Id); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "switch not found", http.StatusNotFound))
+			return
+		}
+
 		c.Error(errors.NewHTTPError(err, "failed to edit switch", http.StatusInternalServerError))
 		return
 	}
@@ -68,7 +75,7 @@ func (h *DefaultSwitchHandler) HandlerEditSwitch(c *gin.Context) {
 }
 
 func (h *DefaultSwitchHandler) HandlerCreateSwitch(c *gin.Context) {
-	_, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
 
 	if !isOperatorOrHigher {
 		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
@@ -89,7 +96,7 @@ func (h *DefaultSwitchHandler) HandlerCreateSwitch(c *gin.Context) {
 
 	_switch.CreatedAt = time.Now().Unix()
 
-	if err := h.SwitchRepo.CreateSwitch(&_switch); err != nil {
+	if err := h.SwitchRepo.CreateSwitch(&_switch, session.User.Id); err != nil {
 		c.Error(errors.NewHTTPError(err, "failed to create switch", http.StatusInternalServerError))
 		return
 	}
@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/kafka"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TopologyHandler interface {
+	HandlerGetHardwareLldp(c *gin.Context)
+	HandlerGetTopologyProposals(c *gin.Context)
+	HandlerDiscoverTopology(c *gin.Context)
+	HandlerAcceptTopologyProposal(c *gin.Context)
+	HandlerRejectTopologyProposal(c *gin.Context)
+}
+
+type DefaultTopologyHandler struct {
+	Privilege        Privilege
+	TopologyRepo     database.TopologyRepository
+	NodeRepo         database.NodeRepository
+	CableRepo        database.CableRepository
+	EventRepo        database.EventRepository
+	HardwareRepo     database.HardwareRepository
+	AddressService   addresspb.AddressServiceClient
+	Metadata         utils.Metadata
+	NodeProducer     kafka.NodeProducer
+	HardwareProducer kafka.HardwareProducer
+	utils.Logger
+}
+
+func NewTopologyHandler(addressClient *addresspb.AddressServiceClient, db *database.Database, logger *utils.Logger) TopologyHandler {
+	return &DefaultTopologyHandler{
+		Privilege: &DefaultPrivilege{},
+		TopologyRepo: &database.DefaultTopologyRepository{
+			Database: *db,
+		},
+		NodeRepo: &database.DefaultNodeRepository{
+			Database: *db,
+		},
+		CableRepo: &database.DefaultCableRepository{
+			Database: *db,
+		},
+		EventRepo: &database.DefaultEventRepository{
+			Database: *db,
+		},
+		HardwareRepo: &database.DefaultHardwareRepository{
+			Database: *db,
+		},
+		AddressService:   *addressClient,
+		Metadata:         &utils.DefaultMetadata{},
+		NodeProducer:     kafka.NewNodeProducer(kafka.NewKafkaWriter("index-node")),
+		HardwareProducer: kafka.NewHardwareProducer(kafka.NewKafkaWriter("index-node")),
+		Logger:           *logger,
+	}
+}
+
+// HandlerGetHardwareLldp Возвращает таблицу соседей LLDP/CDP оборудования из последнего опроса
+func (h *DefaultTopologyHandler) HandlerGetHardwareLldp(c *gin.Context) {
+	hardwareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest))
+		return
+	}
+
+	neighbors, err := h.TopologyRepo.GetLldpNeighbors(hardwareID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get lldp neighbors", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": neighbors,
+		"Count": len(neighbors),
+	})
+}
+
+// HandlerGetTopologyProposals Возвращает предложения по топологии, по умолчанию ожидающие проверки.
+// query(status) - PENDING, ACCEPTED, REJECTED или пустое значение для всех
+func (h *DefaultTopologyHandler) HandlerGetTopologyProposals(c *gin.Context) {
+	status := strings.ToUpper(c.DefaultQuery("status", models.TopologyProposalPending))
+
+	if _, ok := models.TopologyProposalStatuses[status]; status != "" && !ok {
+		c.Error(errors.NewHTTPError(nil, "invalid status", http.StatusBadRequest))
+		return
+	}
+
+	h.getProposals(c, status, gin.H{})
+}
+
+// HandlerDiscoverTopology Запускает обнаружение по уже собранным таблицам соседей, не дожидаясь
+// фонового опроса, и возвращает ожидающие проверки предложения
+func (h *DefaultTopologyHandler) HandlerDiscoverTopology(c *gin.Context) {
+	_, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	created, err := h.TopologyRepo.DiscoverTopology(time.Now().Unix())
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to discover topology", http.StatusInternalServerError))
+		return
+	}
+
+	h.getProposals(c, models.TopologyProposalPending, gin.H{"Created": created})
+}
+
+// HandlerAcceptTopologyProposal Применяет предложение. Для PARENT узел переносится под предложенного
+// родителя с проверкой правил топологии, для LINK создается кабель между портами: среда, количество
+// волокон, длина и маркировка передаются в теле запроса, как при создании кабеля. Предложение
+// отмечается принятым в той же транзакции, что и изменение
+func (h *DefaultTopologyHandler) HandlerAcceptTopologyProposal(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	proposal, httpErr := h.getPendingProposal(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	switch proposal.Kind {
+	case models.TopologyProposalParent:
+		httpErr = h.acceptParentProposal(*proposal, session.User.Id)
+	case models.TopologyProposalLink:
+		httpErr = h.acceptLinkProposal(c, *proposal, session.User.Id)
+	}
+
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	h.sendProposal(c, proposal.ID)
+}
+
+// HandlerRejectTopologyProposal Отклоняет предложение. Отклоненное предложение не создается повторно
+// при следующих запусках обнаружения
+func (h *DefaultTopologyHandler) HandlerRejectTopologyProposal(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	proposal, httpErr := h.getPendingProposal(c)
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	if err := h.TopologyRepo.ReviewTopologyProposal(proposal.ID, models.TopologyProposalRejected, session.User.Id, time.Now().Unix()); err != nil {
+		c.Error(proposalReviewError(err, "failed to review topology proposal"))
+		return
+	}
+
+	h.sendProposal(c, proposal.ID)
+}
+
+func (h *DefaultTopologyHandler) getProposals(c *gin.Context, status string, response gin.H) {
+	proposals, err := h.TopologyRepo.GetTopologyProposals(status)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get topology proposals", http.StatusInternalServerError))
+		return
+	}
+
+	if httpErr := h.setProposalAddresses(c, proposals); httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	response["Items"] = proposals
+	response["Count"] = len(proposals)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *DefaultTopologyHandler) getPendingProposal(c *gin.Context) (*models.TopologyProposal, *errors.HTTPError) {
+	proposalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, errors.NewHTTPError(err, "failed to parse param(id) to int", http.StatusBadRequest)
+	}
+
+	proposal, err := h.TopologyRepo.GetTopologyProposal(proposalID)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, errors.NewHTTPError(err, "proposal not found", http.StatusNotFound)
+		}
+
+		return nil, errors.NewHTTPError(err, "failed to get topology proposal", http.StatusInternalServerError)
+	}
+
+	if proposal.Status != models.TopologyProposalPending {
+		return nil, errors.NewHTTPError(nil, "proposal is already reviewed", http.StatusConflict)
+	}
+
+	return proposal, nil
+}
+
+func (h *DefaultTopologyHandler) sendProposal(c *gin.Context, proposalID int) {
+	proposal, err := h.TopologyRepo.GetTopologyProposal(proposalID)
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get topology proposal", http.StatusInternalServerError))
+		return
+	}
+
+	proposals := []models.TopologyProposal{*proposal}
+
+	if httpErr := h.setProposalAddresses(c, proposals); httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, proposals[0])
+}
+
+// acceptParentProposal Переносит узел под предложенного родителя. Если parent_id узла изменился после
+// обнаружения, предложение устарело и оператор должен запустить обнаружение заново. parent_id
+// сравнивается в транзакции переноса под блокировкой узла
+func (h *DefaultTopologyHandler) acceptParentProposal(proposal models.TopologyProposal, userID int32) *errors.HTTPError {
+	node := models.Node{ID: proposal.Node.ID}
+
+	if err := h.NodeRepo.GetNode(&node); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.NewHTTPError(err, "node not found", http.StatusNotFound)
+		}
+
+		return errors.NewHTTPError(err, "failed to get node", http.StatusInternalServerError)
+	}
+
+	if node.IsDelete {
+		return errors.NewHTTPError(nil, "node is deleted", http.StatusBadRequest)
+	}
+
+	currentParentID := 0
+
+	if proposal.CurrentParent != nil {
+		currentParentID = proposal.CurrentParent.ID
+	}
+
+	node.Parent = &models.Node{ID: proposal.ProposedParent.ID}
+
+	violation, err := h.NodeRepo.ValidateNodeTopology(node)
+	if err != nil {
+		return errors.NewHTTPError(err, "failed to validate node topology", http.StatusInternalServerError)
+	}
+
+	if violation != nil {
+		return errors.NewHTTPError(nil, violation.Message, http.StatusBadRequest).WithDetails(violation)
+	}
+
+	move := models.NodeMove{ParentID: proposal.ProposedParent.ID}
+
+	items, err := h.TopologyRepo.AcceptParentProposal(proposal.ID, node.ID, currentParentID, move, userID, time.Now().Unix())
+	if err != nil {
+		if goErrors.Is(err, database.ErrNodeCycle) {
+			return nodeCycleError(err, node.ID, move.ParentID)
+		}
+
+		if goErrors.Is(err, database.ErrNodeParentChanged) {
+			return errors.NewHTTPError(err, "node parent changed since discovery", http.StatusConflict)
+		}
+
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.NewHTTPError(err, "node not found", http.StatusNotFound)
+		}
+
+		return proposalReviewError(err, "failed to move node")
+	}
+
+	go func() {
+		ctx := context.Background()
+		nodeIDs := make([]int32, 0, len(items))
+
+		for _, item := range items {
+			nodeIDs = append(nodeIDs, int32(item.Node.ID))
+
+			if e := sendSingleNode(ctx, h.NodeRepo, h.AddressService, h.NodeProducer, item.Node.ID); e != nil {
+				log.Printf("failed to send single node: %v\n", e)
+				h.Logger.Println(e)
+			}
+		}
+
+		// В индексе оборудования хранится адрес узла, поэтому оборудование ветки тоже переиндексируется
+		hardware, e := h.HardwareRepo.GetHardwareByNodeIDs(nodeIDs)
+		if e != nil {
+			log.Printf("failed to get hardware of moved nodes: %v\n", e)
+			h.Logger.Println(e)
+			return
+		}
+
+		for _, hd := range hardware {
+			if e = sendSingleHardware(ctx, h.HardwareRepo, h.AddressService, h.HardwareProducer, hd.ID); e != nil {
+				log.Printf("failed to send single hardware: %v\n", e)
+				h.Logger.Println(e)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// acceptLinkProposal Создает кабель между портами соседей и после фиксации пишет событие на оба узла
+func (h *DefaultTopologyHandler) acceptLinkProposal(c *gin.Context, proposal models.TopologyProposal, userID int32) *errors.HTTPError {
+	var cable models.Cable
+
+	if err := c.BindJSON(&cable); err != nil {
+		return errors.NewHTTPError(err, "invalid json", http.StatusBadRequest)
+	}
+
+	cable.ID = 0
+	cable.A = models.CableEndpoint{Port: &models.HardwarePort{ID: proposal.Port.ID}}
+	cable.B = models.CableEndpoint{Port: &models.HardwarePort{ID: proposal.RemotePort.ID}}
+
+	if !h.CableRepo.ValidateCable(cable) {
+		return errors.NewHTTPError(nil, "invalid cable data", http.StatusBadRequest)
+	}
+
+	for _, port := range []*models.HardwarePort{cable.A.Port, cable.B.Port} {
+		exists, err := h.CableRepo.PortHasCable(port.ID, 0)
+		if err != nil {
+			return errors.NewHTTPError(err, "failed to check port cable", http.StatusInternalServerError)
+		}
+
+		if exists {
+			return errors.NewHTTPError(nil, fmt.Sprintf("port %d already has a cable", port.ID), http.StatusConflict)
+		}
+	}
+
+	cable.CreatedAt = time.Now().Unix()
+
+	if err := h.TopologyRepo.AcceptLinkProposal(proposal.ID, &cable, userID); err != nil {
+		if goErrors.Is(err, database.ErrProposalReviewed) {
+			return proposalReviewError(err, "failed to review topology proposal")
+		}
+
+		return cableError(err, "failed to create cable")
+	}
+
+	created, err := h.CableRepo.GetCable(cable.ID)
+	if err != nil {
+		return errors.NewHTTPError(err, "failed to get cable", http.StatusInternalServerError)
+	}
+
+	description := fmt.Sprintf("Создание кабеля по данным LLDP %s: %s - %s", models.CableMediums[created.Medium], created.A.Node.Name, created.B.Node.Name)
+
+	for _, endpoint := range []models.CableEndpoint{created.A, created.B} {
+		event := models.Event{
+			HouseId:     endpoint.Node.HouseId,
+			Node:        &models.Node{ID: endpoint.Node.ID},
+			UserId:      userID,
+			Description: description,
+			CreatedAt:   time.Now().Unix(),
+		}
+
+		if endpoint.Hardware != nil {
+			event.Hardware = &models.Hardware{ID: endpoint.Hardware.ID}
+		}
+
+		if err = h.EventRepo.CreateEvent(event); err != nil {
+			return errors.NewHTTPError(err, "failed to create event", http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// setProposalAddresses Заполняет адреса всех узлов предложений одним запросом к сервису адресов
+func (h *DefaultTopologyHandler) setProposalAddresses(c *gin.Context, proposals []models.TopologyProposal) *errors.HTTPError {
+	var nodes []*models.Node
+
+	for i := range proposals {
+		proposal := &proposals[i]
+		nodes = append(nodes, &proposal.Hardware.Node, &proposal.RemoteHardware.Node)
+
+		for _, node := range []*models.Node{proposal.Node, proposal.CurrentParent, proposal.ProposedParent} {
+			if node != nil {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for _, node := range nodes {
+		if _, ok := houseIDSet[node.HouseId]; !ok {
+			houseIDSet[node.HouseId] = struct{}{}
+			houseIDs = append(houseIDs, node.HouseId)
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		res, err := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if err != nil {
+			return errors.NewHTTPError(err, "failed to get addresses", http.StatusInternalServerError)
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for _, node := range nodes {
+		node.Address = addressMap[node.HouseId]
+	}
+
+	return nil
+}
+
+// proposalReviewError Предложение, захваченное другим запросом, - конфликт, изменения не применялись
+func proposalReviewError(err error, message string) *errors.HTTPError {
+	if goErrors.Is(err, database.ErrProposalReviewed) {
+		return errors.NewHTTPError(err, "proposal is already reviewed", http.StatusConflict)
+	}
+
+	return errors.NewHTTPError(err, message, http.StatusInternalServerError)
+}
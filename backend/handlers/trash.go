@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/proto/userpb"
+	"backend/utils"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+type TrashHandler interface {
+	HandlerGetTrash(c *gin.Context)
+}
+
+type DefaultTrashHandler struct {
+	Privilege      Privilege
+	NodeRepo       database.NodeRepository
+	HardwareRepo   database.HardwareRepository
+	UserService    userpb.UserServiceClient
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+func NewTrashHandler(userClient *userpb.UserServiceClient, addressClient *addresspb.AddressServiceClient, db *database.Database) TrashHandler {
+	return &DefaultTrashHandler{
+		Privilege: &DefaultPrivilege{},
+		NodeRepo: &database.DefaultNodeRepository{
+			Database: *db,
+		},
+		HardwareRepo: &database.DefaultHardwareRepository{
+			Database: *db,
+		},
+		UserService:    *userClient,
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+func (h *DefaultTrashHandler) HandlerGetTrash(c *gin.Context) {
+	_, isAdmin, _ := h.Privilege.getPrivilege(c)
+
+	if !isAdmin {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to parse query(offset) to int", http.StatusBadRequest))
+		return
+	}
+
+	var (
+		items     []models.TrashItem
+		count     int
+		deletedBy = make(map[int]int32)
+	)
+
+	switch entity := c.DefaultQuery("type", "nodes"); entity {
+	case "nodes":
+		nodes, nodesCount, e := h.NodeRepo.GetDeletedNodes(offset)
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, "failed to get deleted nodes", http.StatusInternalServerError))
+			return
+		}
+
+		count = nodesCount
+
+		for i := range nodes {
+			items = append(items, models.TrashItem{Node: &nodes[i], DeletedAt: nodes[i].DeletedAt.Int64})
+
+			if nodes[i].DeletedBy.Valid {
+				deletedBy[len(items)-1] = nodes[i].DeletedBy.Int32
+			}
+		}
+	case "hardware":
+		hardware, hardwareCount, e := h.HardwareRepo.GetDeletedHardware(offset)
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, "failed to get deleted hardware", http.StatusInternalServerError))
+			return
+		}
+
+		count = hardwareCount
+
+		for i := range hardware {
+			items = append(items, models.TrashItem{Hardware: &hardware[i], DeletedAt: hardware[i].DeletedAt.Int64})
+
+			if hardware[i].DeletedBy.Valid {
+				deletedBy[len(items)-1] = hardware[i].DeletedBy.Int32
+			}
+		}
+	default:
+		c.Error(errors.NewHTTPError(nil, fmt.Sprintf("type is unsupported (%s)", entity), http.StatusBadRequest))
+		return
+	}
+
+	userIDSet := make(map[int32]struct{})
+	houseIDSet := make(map[int32]struct{})
+	usersMap := make(map[int32]*userpb.User)
+	addressMap := make(map[int32]*addresspb.Address)
+
+	for _, userID := range deletedBy {
+		userIDSet[userID] = struct{}{}
+	}
+
+	for _, item := range items {
+		houseIDSet[trashItemHouseID(item)] = struct{}{}
+	}
+
+	var wg sync.WaitGroup
+	errChan := make(chan error, 2)
+
+	ctx := h.Metadata.SetAuthorizationHeader(c)
+
+	if len(userIDSet) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var userIDs []int32
+			for userID := range userIDSet {
+				userIDs = append(userIDs, userID)
+			}
+
+			userRes, e := h.UserService.GetUsersByIds(ctx, &userpb.GetUsersByIdsRequest{Ids: userIDs})
+			if e != nil {
+				errChan <- errors.NewHTTPError(e, "failed to get users", http.StatusInternalServerError)
+				return
+			}
+
+			for _, user := range userRes.Users {
+				usersMap[user.Id] = user
+			}
+		}()
+	}
+
+	if len(houseIDSet) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var houseIDs []int32
+			for houseID := range houseIDSet {
+				houseIDs = append(houseIDs, houseID)
+			}
+
+			addressRes, e := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+			if e != nil {
+				errChan <- errors.NewHTTPError(e, "failed to get addresses", http.StatusInternalServerError)
+				return
+			}
+
+			for _, address := range addressRes.Addresses {
+				addressMap[address.House.Id] = address
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	for e := range errChan {
+		if e != nil {
+			c.Error(e)
+			return
+		}
+	}
+
+	for i := range items {
+		items[i].Address = addressMap[trashItemHouseID(items[i])]
+
+		if userID, ok := deletedBy[i]; ok {
+			items[i].User = usersMap[userID]
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Items": items,
+		"Count": count,
+	})
+}
+
+func trashItemHouseID(item models.TrashItem) int32 {
+	if item.Node != nil {
+		return item.Node.HouseId
+	}
+
+	return item.Hardware.Node.HouseId
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"backend/database"
+	"backend/errors"
+	"backend/models"
+	"backend/proto/addresspb"
+	"backend/utils"
+	"database/sql"
+	goErrors "errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type VlanHandler interface {
+	HandlerGetVlans(c *gin.Context)
+	HandlerCreateVlan(c *gin.Context)
+	HandlerEditVlan(c *gin.Context)
+	HandlerGetVlanReport(c *gin.Context)
+}
+
+type DefaultVlanHandler struct {
+	Privilege      Privilege
+	VlanRepo       database.VlanRepository
+	AddressService addresspb.AddressServiceClient
+	Metadata       utils.Metadata
+}
+
+func NewVlanHandler(addressClient *addresspb.AddressServiceClient, db *database.Database) VlanHandler {
+	return &DefaultVlanHandler{
+		Privilege: &DefaultPrivilege{},
+		VlanRepo: &database.DefaultVlanRepository{
+			Database: *db,
+		},
+		AddressService: *addressClient,
+		Metadata:       &utils.DefaultMetadata{},
+	}
+}
+
+func (h *DefaultVlanHandler) HandlerGetVlans(c *gin.Context) {
+	vlans, err := h.VlanRepo.GetVlans()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get vlans", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, vlans)
+}
+
+func (h *DefaultVlanHandler) HandlerCreateVlan(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	var vlan models.Vlan
+
+	if err := c.BindJSON(&vlan); err != nil {
+		c.Error(errors.NewHTTPError(err, "invalid json", http.StatusBadRequest))
+		return
+	}
+
+	if !h.VlanRepo.ValidateVlan(vlan) {
+		c.Error(errors.NewHTTPError(nil, "invalid vlan data", http.StatusBadRequest))
+		return
+	}
+
+	vlan.CreatedAt = time.Now().Unix()
+
+	if err := h.VlanRepo.CreateVlan(&vlan, session.User.Id); err != nil {
+		if isUniqueViolation(err) {
+			c.Error(errors.NewHTTPError(err, "vlan already exists", http.StatusConflict))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to create vlan", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, vlan)
+}
+
+func (h *DefaultVlanHandler) HandlerEditVlan(c *gin.Context) {
+	session, _, isOperatorOrHigher := h.Privilege.getPrivilege(c)
+
+	if !isOperatorOrHigher {
+		c.Error(errors.NewHTTPError(nil, "forbidden", http.StatusForbidden))
+		return
+	}
+
+	var vlan models.Vlan
+
+	if err := c.BindJSON(&vlan); err != nil {
+		c.Error(errors.NewHTTPError(err, "invalid json", http.StatusBadRequest))
+		return
+	}
+
+	if !h.VlanRepo.ValidateVlan(vlan) {
+		c.Error(errors.NewHTTPError(nil, "invalid vlan data", http.StatusBadRequest))
+		return
+	}
+
+	vlan.UpdatedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+
+	if err := h.VlanRepo.EditVlan(&vlan, session.User.Id); err != nil {
+		if isUniqueViolation(err) {
+			c.Error(errors.NewHTTPError(err, "vlan already exists", http.StatusConflict))
+			return
+		}
+
+		if goErrors.Is(err, sql.ErrNoRows) {
+			c.Error(errors.NewHTTPError(err, "vlan not found", http.StatusNotFound))
+			return
+		}
+
+		c.Error(errors.NewHTTPError(err, "failed to edit vlan", http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, vlan)
+}
+
+// HandlerGetVlanReport Возвращает расхождения реестра VLAN с коммутаторами в обе стороны
+func (h *DefaultVlanHandler) HandlerGetVlanReport(c *gin.Context) {
+	report, err := h.VlanRepo.GetVlanReport()
+	if err != nil {
+		c.Error(errors.NewHTTPError(err, "failed to get vlan report", http.StatusInternalServerError))
+		return
+	}
+
+	houseIDSet := make(map[int32]struct{})
+	addressMap := make(map[int32]*addresspb.Address)
+
+	var houseIDs []int32
+
+	for _, usage := range report.Unregistered {
+		for _, hardware := range usage.Hardware {
+			if _, ok := houseIDSet[hardware.Node.HouseId]; !ok {
+				houseIDSet[hardware.Node.HouseId] = struct{}{}
+				houseIDs = append(houseIDs, hardware.Node.HouseId)
+			}
+		}
+	}
+
+	if len(houseIDs) > 0 {
+		ctx := h.Metadata.SetAuthorizationHeader(c)
+
+		res, e := h.AddressService.GetAddresses(ctx, &addresspb.GetAddressesRequest{HouseIDs: houseIDs})
+		if e != nil {
+			c.Error(errors.NewHTTPError(e, "failed to get addresses", http.StatusInternalServerError))
+			return
+		}
+
+		for _, address := range res.Addresses {
+			addressMap[address.House.Id] = address
+		}
+	}
+
+	for i := range report.Unregistered {
+		for j := range report.Unregistered[i].Hardware {
+			hardware := &report.Unregistered[i].Hardware[j]
+			hardware.Node.Address = addressMap[hardware.Node.HouseId]
+		}
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -17,6 +17,11 @@ func (m *DefaultMiddleware) ErrorMiddleware() gin.HandlerFunc {
 
 				var httpErr *httpErrors.HTTPError
 				if errors.As(err.Err, &httpErr) {
+					if httpErr.Details != nil {
+						c.AbortWithStatusJSON(httpErr.Code, gin.H{"error": httpErr.Message, "details": httpErr.Details})
+						return
+					}
+
 					c.AbortWithStatusJSON(httpErr.Code, gin.H{"error": httpErr.Message})
 					return
 				}
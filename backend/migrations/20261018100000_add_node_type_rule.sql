@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Node_type_rule" (
+    id serial PRIMARY KEY,
+    parent_type_id integer NOT NULL,
+    child_type_id integer NOT NULL,
+    created_at bigint NOT NULL,
+    UNIQUE (parent_type_id, child_type_id),
+    FOREIGN KEY (parent_type_id) REFERENCES "Node_type"(id),
+    FOREIGN KEY (child_type_id) REFERENCES "Node_type"(id)
+);
+CREATE INDEX idx_node_type_rule_parent_type_id ON "Node_type_rule" (parent_type_id);
+
+INSERT INTO "Node_type_rule"(parent_type_id, child_type_id, created_at)
+SELECT pt.id, ct.id, floor(extract(epoch from now()))
+FROM (
+    VALUES
+        ('BN', 'BN'),
+        ('BN', 'DN'),
+        ('BN', 'HN'),
+        ('DN', 'DN'),
+        ('DN', 'HN'),
+        ('HN', 'HN')
+) AS r(parent_key, child_key)
+JOIN "Node_type" AS pt ON pt.key = r.parent_key
+JOIN "Node_type" AS ct ON ct.key = r.child_key;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Node_type_rule";
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "Node" ADD COLUMN IF NOT EXISTS deleted_at bigint;
+ALTER TABLE "Node" ADD COLUMN IF NOT EXISTS deleted_by integer;
+CREATE INDEX idx_node_is_delete ON "Node" (is_delete) WHERE is_delete = true;
+
+ALTER TABLE "Hardware" ADD COLUMN IF NOT EXISTS deleted_at bigint;
+ALTER TABLE "Hardware" ADD COLUMN IF NOT EXISTS deleted_by integer;
+CREATE INDEX idx_hardware_is_delete ON "Hardware" (is_delete) WHERE is_delete = true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_hardware_is_delete;
+ALTER TABLE "Hardware" DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE "Hardware" DROP COLUMN IF EXISTS deleted_at;
+
+DROP INDEX IF EXISTS idx_node_is_delete;
+ALTER TABLE "Node" DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE "Node" DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
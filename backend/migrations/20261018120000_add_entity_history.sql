@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Entity_history" (
+    id bigserial PRIMARY KEY,
+    entity character varying(32) NOT NULL,
+    entity_id integer NOT NULL,
+    version integer NOT NULL,
+    action character varying(32) NOT NULL,
+    before jsonb,
+    after jsonb,
+    user_id integer NOT NULL,
+    created_at bigint NOT NULL,
+    UNIQUE (entity, entity_id, version)
+);
+CREATE INDEX idx_entity_history_created_at ON "Entity_history" (created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Entity_history";
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "Node" ADD COLUMN IF NOT EXISTS latitude double precision;
+ALTER TABLE "Node" ADD COLUMN IF NOT EXISTS longitude double precision;
+CREATE INDEX idx_node_coordinates ON "Node" (latitude, longitude) WHERE latitude IS NOT NULL AND longitude IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_node_coordinates;
+ALTER TABLE "Node" DROP COLUMN IF EXISTS longitude;
+ALTER TABLE "Node" DROP COLUMN IF EXISTS latitude;
+-- +goose StatementEnd
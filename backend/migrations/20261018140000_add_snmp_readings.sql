@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Snmp_poll" (
+    hardware_id integer PRIMARY KEY,
+    polled_at bigint NOT NULL,
+    success_at bigint,
+    error text,
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id)
+);
+
+CREATE TABLE IF NOT EXISTS "Snmp_reading" (
+    hardware_id integer NOT NULL,
+    metric character varying(64) NOT NULL,
+    oid character varying(255) NOT NULL,
+    value text NOT NULL,
+    polled_at bigint NOT NULL,
+    PRIMARY KEY (hardware_id, metric, oid),
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Snmp_reading";
+DROP TABLE IF EXISTS "Snmp_poll";
+-- +goose StatementEnd
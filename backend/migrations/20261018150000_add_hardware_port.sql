@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Hardware_port" (
+    id serial PRIMARY KEY,
+    hardware_id integer NOT NULL,
+    number integer NOT NULL,
+    description character varying(255),
+    speed bigint,
+    mode character varying(64),
+    untagged_vlan integer,
+    link_state character varying(32),
+    source character varying(16) NOT NULL,
+    created_at bigint NOT NULL,
+    updated_at bigint,
+    UNIQUE (hardware_id, number),
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id)
+);
+CREATE INDEX idx_hardware_port_description_trgm ON "Hardware_port" USING GIN (description gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Hardware_port";
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Vlan" (
+    id serial PRIMARY KEY,
+    vlan_id integer NOT NULL UNIQUE CHECK (vlan_id BETWEEN 1 AND 4094),
+    name character varying(255) NOT NULL,
+    purpose character varying(255),
+    zone character varying(255),
+    created_at bigint NOT NULL,
+    updated_at bigint
+);
+CREATE INDEX idx_vlan_name_trgm ON "Vlan" USING GIN (name gin_trgm_ops);
+
+CREATE TABLE IF NOT EXISTS "Port_vlan" (
+    port_id integer NOT NULL,
+    vlan_id integer NOT NULL CHECK (vlan_id BETWEEN 1 AND 4094),
+    tagged boolean NOT NULL,
+    source character varying(16) NOT NULL,
+    updated_at bigint NOT NULL,
+    PRIMARY KEY (port_id, vlan_id),
+    FOREIGN KEY (port_id) REFERENCES "Hardware_port"(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_port_vlan_vlan_id ON "Port_vlan" (vlan_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Port_vlan";
+DROP TABLE IF EXISTS "Vlan";
+-- +goose StatementEnd
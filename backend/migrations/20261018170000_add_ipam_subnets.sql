@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION ip_to_inet(value text) RETURNS inet AS $$
+BEGIN
+    RETURN value::inet;
+EXCEPTION WHEN others THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE INDEX idx_hardware_ip_address_inet ON "Hardware" (ip_to_inet(ip_address));
+-- ValidateHardwareIP проверяет адрес до записи, индекс не дает параллельным запросам занять один адрес дважды
+CREATE UNIQUE INDEX idx_hardware_ip_address_unique ON "Hardware" (ip_to_inet(ip_address)) WHERE is_delete = false;
+
+CREATE TABLE IF NOT EXISTS "Subnet" (
+    id serial PRIMARY KEY,
+    network cidr NOT NULL UNIQUE,
+    gateway inet,
+    zone character varying(255),
+    vlan_id integer,
+    description character varying(255),
+    created_at bigint NOT NULL,
+    updated_at bigint,
+    FOREIGN KEY (vlan_id) REFERENCES "Vlan"(vlan_id) ON UPDATE CASCADE
+);
+CREATE INDEX idx_subnet_network ON "Subnet" USING GIST (network inet_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Subnet";
+DROP INDEX IF EXISTS idx_hardware_ip_address_unique;
+DROP INDEX IF EXISTS idx_hardware_ip_address_inet;
+DROP FUNCTION IF EXISTS ip_to_inet(text);
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Mac_entry" (
+    id bigserial PRIMARY KEY,
+    mac macaddr NOT NULL,
+    hardware_id integer NOT NULL,
+    port_number integer NOT NULL,
+    vlan_id integer NOT NULL DEFAULT 0,
+    first_seen bigint NOT NULL,
+    last_seen bigint NOT NULL,
+    UNIQUE (mac, hardware_id, port_number, vlan_id),
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id)
+);
+CREATE INDEX idx_mac_entry_hardware_last_seen ON "Mac_entry" (hardware_id, last_seen);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Mac_entry";
+-- +goose StatementEnd
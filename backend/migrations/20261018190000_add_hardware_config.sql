@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Hardware_config" (
+    id serial PRIMARY KEY,
+    hardware_id integer NOT NULL,
+    version integer NOT NULL,
+    file_path character varying(255) NOT NULL UNIQUE,
+    hash character(64) NOT NULL,
+    size integer NOT NULL,
+    user_id integer NOT NULL,
+    created_at bigint NOT NULL,
+    UNIQUE (hardware_id, version),
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Hardware_config";
+-- +goose StatementEnd
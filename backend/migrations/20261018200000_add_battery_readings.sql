@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Battery_reading" (
+    id bigserial PRIMARY KEY,
+    hardware_id integer NOT NULL,
+    status integer,
+    charge integer,
+    polled_at bigint NOT NULL,
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id)
+);
+CREATE INDEX idx_battery_reading_hardware_polled_at ON "Battery_reading" (hardware_id, polled_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Battery_reading";
+-- +goose StatementEnd
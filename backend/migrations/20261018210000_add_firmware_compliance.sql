@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "Switch" ADD COLUMN IF NOT EXISTS target_firmware character varying(255);
+ALTER TABLE "Hardware" ADD COLUMN IF NOT EXISTS firmware character varying(255);
+ALTER TABLE "Hardware" ADD COLUMN IF NOT EXISTS firmware_polled_at bigint;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "Hardware" DROP COLUMN IF EXISTS firmware_polled_at;
+ALTER TABLE "Hardware" DROP COLUMN IF EXISTS firmware;
+ALTER TABLE "Switch" DROP COLUMN IF EXISTS target_firmware;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Hardware_availability" (
+    hardware_id integer PRIMARY KEY,
+    is_up boolean NOT NULL,
+    method character varying(16) NOT NULL,
+    rtt integer,
+    uptime bigint,
+    error text,
+    first_checked_at bigint NOT NULL,
+    checked_at bigint NOT NULL,
+    changed_at bigint NOT NULL,
+    failures integer NOT NULL DEFAULT 0,
+    failed_at bigint,
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id)
+);
+
+CREATE TABLE IF NOT EXISTS "Hardware_outage" (
+    id bigserial PRIMARY KEY,
+    hardware_id integer NOT NULL,
+    cause character varying(32) NOT NULL,
+    started_at bigint NOT NULL,
+    ended_at bigint,
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id)
+);
+CREATE INDEX idx_hardware_outage_hardware_started_at ON "Hardware_outage" (hardware_id, started_at);
+CREATE INDEX idx_hardware_outage_open ON "Hardware_outage" (hardware_id) WHERE ended_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Hardware_outage";
+DROP TABLE IF EXISTS "Hardware_availability";
+-- +goose StatementEnd
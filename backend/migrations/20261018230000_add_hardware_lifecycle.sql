@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "Hardware" ADD COLUMN IF NOT EXISTS serial_number character varying(128);
+ALTER TABLE "Hardware" ADD COLUMN IF NOT EXISTS inventory_number character varying(64);
+ALTER TABLE "Hardware" ADD COLUMN IF NOT EXISTS purchase_date bigint;
+ALTER TABLE "Hardware" ADD COLUMN IF NOT EXISTS warranty_end bigint;
+ALTER TABLE "Hardware" ADD COLUMN IF NOT EXISTS status character varying(32) NOT NULL DEFAULT 'INSTALLED';
+CREATE UNIQUE INDEX idx_hardware_inventory_number ON "Hardware" (inventory_number)
+    WHERE inventory_number IS NOT NULL AND is_delete = false;
+CREATE INDEX idx_hardware_warranty_end ON "Hardware" (warranty_end) WHERE warranty_end IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS "Hardware_status_history" (
+    id bigserial PRIMARY KEY,
+    hardware_id integer NOT NULL,
+    status character varying(32) NOT NULL,
+    user_id integer,
+    created_at bigint NOT NULL,
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id)
+);
+CREATE INDEX idx_hardware_status_history_hardware ON "Hardware_status_history" (hardware_id, created_at);
+
+INSERT INTO "Hardware_status_history" (hardware_id, status, user_id, created_at)
+SELECT id, status, NULL, created_at FROM "Hardware";
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Hardware_status_history";
+DROP INDEX IF EXISTS idx_hardware_warranty_end;
+DROP INDEX IF EXISTS idx_hardware_inventory_number;
+ALTER TABLE "Hardware" DROP COLUMN IF EXISTS status;
+ALTER TABLE "Hardware" DROP COLUMN IF EXISTS warranty_end;
+ALTER TABLE "Hardware" DROP COLUMN IF EXISTS purchase_date;
+ALTER TABLE "Hardware" DROP COLUMN IF EXISTS inventory_number;
+ALTER TABLE "Hardware" DROP COLUMN IF EXISTS serial_number;
+-- +goose StatementEnd
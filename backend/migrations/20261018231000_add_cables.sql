@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Cable" (
+    id serial PRIMARY KEY,
+    a_node_id integer,
+    a_port_id integer,
+    b_node_id integer,
+    b_port_id integer,
+    medium character varying(16) NOT NULL CHECK (medium IN ('COPPER', 'SMF', 'MMF')),
+    length integer CHECK (length >= 0),
+    label character varying(64),
+    description text,
+    created_at bigint NOT NULL,
+    updated_at bigint,
+    CHECK ((a_node_id IS NULL) <> (a_port_id IS NULL)),
+    CHECK ((b_node_id IS NULL) <> (b_port_id IS NULL)),
+    FOREIGN KEY (a_node_id) REFERENCES "Node"(id),
+    FOREIGN KEY (a_port_id) REFERENCES "Hardware_port"(id),
+    FOREIGN KEY (b_node_id) REFERENCES "Node"(id),
+    FOREIGN KEY (b_port_id) REFERENCES "Hardware_port"(id)
+);
+CREATE UNIQUE INDEX idx_cable_a_port_id ON "Cable" (a_port_id) WHERE a_port_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_cable_b_port_id ON "Cable" (b_port_id) WHERE b_port_id IS NOT NULL;
+CREATE INDEX idx_cable_a_node_id ON "Cable" (a_node_id);
+CREATE INDEX idx_cable_b_node_id ON "Cable" (b_node_id);
+CREATE INDEX idx_cable_label ON "Cable" (label);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Cable";
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "Cable" ADD COLUMN IF NOT EXISTS fiber_count integer CHECK (fiber_count > 0);
+
+CREATE TABLE IF NOT EXISTS "Fiber_splice" (
+    id serial PRIMARY KEY,
+    node_id integer NOT NULL,
+    a_cable_id integer NOT NULL,
+    a_fiber integer NOT NULL CHECK (a_fiber > 0),
+    b_cable_id integer NOT NULL,
+    b_fiber integer NOT NULL CHECK (b_fiber > 0),
+    loss numeric(5, 2) CHECK (loss >= 0),
+    description text,
+    created_at bigint NOT NULL,
+    updated_at bigint,
+    CHECK (a_cable_id <> b_cable_id OR a_fiber <> b_fiber),
+    FOREIGN KEY (node_id) REFERENCES "Node"(id),
+    FOREIGN KEY (a_cable_id) REFERENCES "Cable"(id),
+    FOREIGN KEY (b_cable_id) REFERENCES "Cable"(id)
+);
+CREATE INDEX idx_fiber_splice_node_id ON "Fiber_splice" (node_id);
+CREATE INDEX idx_fiber_splice_a_cable ON "Fiber_splice" (a_cable_id, a_fiber);
+CREATE INDEX idx_fiber_splice_b_cable ON "Fiber_splice" (b_cable_id, b_fiber);
+
+CREATE TABLE IF NOT EXISTS "Fiber_attenuation" (
+    id serial PRIMARY KEY,
+    medium character varying(16) NOT NULL CHECK (medium IN ('SMF', 'MMF')),
+    wavelength integer NOT NULL CHECK (wavelength > 0),
+    per_km numeric(5, 2) NOT NULL CHECK (per_km >= 0),
+    per_splice numeric(5, 2) NOT NULL CHECK (per_splice >= 0),
+    per_connector numeric(5, 2) NOT NULL CHECK (per_connector >= 0),
+    created_at bigint NOT NULL,
+    updated_at bigint,
+    UNIQUE (medium, wavelength)
+);
+
+INSERT INTO "Fiber_attenuation"(medium, wavelength, per_km, per_splice, per_connector, created_at)
+VALUES
+    ('SMF', 1310, 0.35, 0.1, 0.5, floor(extract(epoch from now()))),
+    ('SMF', 1550, 0.22, 0.1, 0.5, floor(extract(epoch from now()))),
+    ('MMF', 850, 3.0, 0.1, 0.5, floor(extract(epoch from now()))),
+    ('MMF', 1300, 1.0, 0.1, 0.5, floor(extract(epoch from now())));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Fiber_attenuation";
+DROP TABLE IF EXISTS "Fiber_splice";
+ALTER TABLE "Cable" DROP COLUMN IF EXISTS fiber_count;
+-- +goose StatementEnd
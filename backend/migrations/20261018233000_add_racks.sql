@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "Rack" (
+    id serial PRIMARY KEY,
+    node_id integer NOT NULL,
+    name character varying(64) NOT NULL,
+    kind character varying(16) NOT NULL CHECK (kind IN ('RACK', 'CABINET')),
+    units integer NOT NULL CHECK (units > 0),
+    description text,
+    created_at bigint NOT NULL,
+    updated_at bigint,
+    FOREIGN KEY (node_id) REFERENCES "Node"(id)
+);
+CREATE INDEX idx_rack_node_id ON "Rack" (node_id);
+
+CREATE TABLE IF NOT EXISTS "Hardware_placement" (
+    hardware_id integer PRIMARY KEY,
+    rack_id integer NOT NULL,
+    unit integer NOT NULL CHECK (unit > 0),
+    height integer NOT NULL CHECK (height > 0),
+    side character varying(16) NOT NULL CHECK (side IN ('FRONT', 'REAR')),
+    created_at bigint NOT NULL,
+    updated_at bigint,
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id),
+    FOREIGN KEY (rack_id) REFERENCES "Rack"(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_hardware_placement_rack_id ON "Hardware_placement" (rack_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Hardware_placement";
+DROP TABLE IF EXISTS "Rack";
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "Switch" ADD COLUMN IF NOT EXISTS lldp_chassis_id_oid character varying(255);
+ALTER TABLE "Switch" ADD COLUMN IF NOT EXISTS lldp_sys_name_oid character varying(255);
+ALTER TABLE "Switch" ADD COLUMN IF NOT EXISTS lldp_port_id_oid character varying(255);
+ALTER TABLE "Hardware_port" ADD COLUMN IF NOT EXISTS if_name character varying(255);
+ALTER TABLE "Hardware_port" ADD COLUMN IF NOT EXISTS if_descr character varying(255);
+
+CREATE TABLE IF NOT EXISTS "Lldp_neighbor" (
+    id bigserial PRIMARY KEY,
+    hardware_id integer NOT NULL,
+    local_port integer NOT NULL,
+    local_if_index integer,
+    chassis_id character varying(255) NOT NULL DEFAULT '',
+    system_name character varying(255) NOT NULL DEFAULT '',
+    port_id character varying(255),
+    first_seen bigint NOT NULL,
+    last_seen bigint NOT NULL,
+    UNIQUE (hardware_id, local_port, chassis_id, system_name),
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id)
+);
+
+CREATE TABLE IF NOT EXISTS "Topology_proposal" (
+    id serial PRIMARY KEY,
+    kind character varying(16) NOT NULL CHECK (kind IN ('PARENT', 'LINK')),
+    status character varying(16) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'ACCEPTED', 'REJECTED')),
+    hardware_id integer NOT NULL,
+    local_port integer NOT NULL,
+    remote_hardware_id integer NOT NULL,
+    remote_port character varying(255),
+    node_id integer,
+    current_parent_id integer,
+    proposed_parent_id integer,
+    port_id integer,
+    remote_port_id integer,
+    created_at bigint NOT NULL,
+    reviewed_at bigint,
+    reviewed_by integer,
+    CHECK (kind <> 'PARENT' OR (node_id IS NOT NULL AND proposed_parent_id IS NOT NULL)),
+    CHECK (kind <> 'LINK' OR (port_id IS NOT NULL AND remote_port_id IS NOT NULL)),
+    FOREIGN KEY (hardware_id) REFERENCES "Hardware"(id),
+    FOREIGN KEY (remote_hardware_id) REFERENCES "Hardware"(id),
+    FOREIGN KEY (node_id) REFERENCES "Node"(id),
+    FOREIGN KEY (current_parent_id) REFERENCES "Node"(id),
+    FOREIGN KEY (proposed_parent_id) REFERENCES "Node"(id),
+    FOREIGN KEY (port_id) REFERENCES "Hardware_port"(id) ON DELETE CASCADE,
+    FOREIGN KEY (remote_port_id) REFERENCES "Hardware_port"(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_topology_proposal_status ON "Topology_proposal" (status);
+-- Открытое предложение с одним ключом может быть только одно, даже если обнаружение запущено параллельно
+CREATE UNIQUE INDEX idx_topology_proposal_open_parent ON "Topology_proposal"
+    (node_id, COALESCE(current_parent_id, 0), proposed_parent_id)
+    WHERE kind = 'PARENT' AND status IN ('PENDING', 'REJECTED');
+CREATE UNIQUE INDEX idx_topology_proposal_open_link ON "Topology_proposal"
+    (LEAST(port_id, remote_port_id), GREATEST(port_id, remote_port_id))
+    WHERE kind = 'LINK' AND status IN ('PENDING', 'REJECTED');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "Topology_proposal";
+DROP TABLE IF EXISTS "Lldp_neighbor";
+ALTER TABLE "Hardware_port" DROP COLUMN IF EXISTS if_descr;
+ALTER TABLE "Hardware_port" DROP COLUMN IF EXISTS if_name;
+ALTER TABLE "Switch" DROP COLUMN IF EXISTS lldp_port_id_oid;
+ALTER TABLE "Switch" DROP COLUMN IF EXISTS lldp_sys_name_oid;
+ALTER TABLE "Switch" DROP COLUMN IF EXISTS lldp_chassis_id_oid;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"backend/proto/addresspb"
+	"database/sql"
+)
+
+// Причины простоя: оборудование не отвечало на проверки или перезагрузилось между проверками
+// (uptime уменьшился). Для перезагрузки известно только время загрузки, поэтому интервал нулевой
+const (
+	OutageUnreachable = "UNREACHABLE"
+	OutageReboot      = "REBOOT"
+)
+
+// AvailabilityCheck Текущее состояние доступности оборудования по последней проверке.
+// Rtt - время ответа в миллисекундах, Uptime - время работы по UptimeOID в секундах.
+// Failures - неудачные проверки подряд, FailedAt - время первой из них
+type AvailabilityCheck struct {
+	HardwareID     int
+	IsUp           bool
+	Method         string
+	Rtt            sql.NullInt32
+	Uptime         sql.NullInt64
+	Error          sql.NullString
+	FirstCheckedAt int64
+	CheckedAt      int64
+	ChangedAt      int64
+	Failures       int
+	FailedAt       sql.NullInt64
+}
+
+type Outage struct {
+	ID        int64
+	Hardware  Hardware
+	Cause     string
+	StartedAt int64
+	EndedAt   sql.NullInt64
+	Duration  int64
+}
+
+// Availability Доступность оборудования, узла или дома за период. Monitored - сколько секунд периода
+// оборудование было под наблюдением, Downtime - сколько из них длились простои. Для узла и дома
+// время суммируется по всему их оборудованию
+type Availability struct {
+	Hardware  *Hardware
+	Node      *Node
+	HouseID   int32
+	Address   *addresspb.Address
+	Monitored int64
+	Downtime  int64
+	Outages   int
+	Percent   float64
+}
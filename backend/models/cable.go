@@ -0,0 +1,60 @@
+package models
+
+import "database/sql"
+
+// Среды передачи кабеля: медь, одномодовое и многомодовое волокно
+const (
+	CableMediumCopper = "COPPER"
+	CableMediumSMF    = "SMF"
+	CableMediumMMF    = "MMF"
+)
+
+var CableMediums = map[string]string{
+	CableMediumCopper: "Медь",
+	CableMediumSMF:    "Одномодовое волокно",
+	CableMediumMMF:    "Многомодовое волокно",
+}
+
+// CableEndpoint Конец кабеля: порт оборудования или узел без порта (например, кросс или муфта).
+// Node заполнен всегда, для порта это узел его оборудования
+type CableEndpoint struct {
+	Node     Node
+	Hardware *Hardware
+	Port     *HardwarePort
+}
+
+// Cable Кабель между двумя концами. Length - длина в метрах, FiberCount - количество волокон
+// оптического кабеля
+type Cable struct {
+	ID          int
+	A           CableEndpoint
+	B           CableEndpoint
+	Medium      string
+	FiberCount  sql.NullInt32
+	Length      sql.NullInt32
+	Label       sql.NullString
+	Description sql.NullString
+	CreatedAt   int64
+	UpdatedAt   sql.NullInt64
+}
+
+// Neighbour Соседний конец кабеля, подключенного к порту оборудования
+type Neighbour struct {
+	Cable  Cable
+	Local  CableEndpoint
+	Remote CableEndpoint
+}
+
+type CableHop struct {
+	Cable Cable
+	From  CableEndpoint
+	To    CableEndpoint
+}
+
+// CableTrace Путь по кабелям от узла до ближайшего магистрального узла. Length - суммарная длина
+// кабелей с указанной длиной
+type CableTrace struct {
+	Hops     []CableHop
+	Backbone Node
+	Length   int
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"backend/proto/addresspb"
+	"database/sql"
+)
+
+// Capacity Емкость портов оборудования, узла, дома или зоны. Total - порты по PortAmount модели коммутатора,
+// Used - занятые из них: с поднятым линком, описанием или подключенным кабелем. Если последний опрос
+// оборудования не удался или его не было (IsPolled = false), состояние линков неизвестно и остальные порты
+// считаются в Unknown, а не в Free. Для узла, дома и зоны значения суммируются по всему их оборудованию,
+// UnpolledCount - количество неопрошенного оборудования
+type Capacity struct {
+	Hardware      *Hardware
+	Node          *Node
+	HouseID       int32
+	Address       *addresspb.Address
+	Zone          sql.NullString
+	HardwareCount int
+	UnpolledCount int
+	IsPolled      bool
+	Total         int
+	Used          int
+	Unknown       int
+	Free          int
+	Utilization   float64
+}
@@ -0,0 +1,21 @@
+package models
+
+// HardwareConfig Версия конфигурации оборудования. UserId = 0 у версий, снятых фоновым заданием
+type HardwareConfig struct {
+	ID        int
+	Hardware  Hardware
+	Version   int
+	Path      string
+	Hash      string
+	Size      int
+	UserId    int32
+	CreatedAt int64
+}
+
+// ConfigBackupResult Результат снятия конфигурации. Если конфигурация не изменилась,
+// новая версия не создается и Config - последняя сохраненная версия
+type ConfigBackupResult struct {
+	Changed bool
+	Config  HardwareConfig
+	Diff    string
+}
@@ -0,0 +1,115 @@
+package models
+
+import "database/sql"
+
+const (
+	// FiberModuleSize Количество волокон в модуле (трубке) кабеля
+	FiberModuleSize = 12
+	// MaxFiberCount Наибольшее количество волокон в кабеле
+	MaxFiberCount = 288
+)
+
+// FiberColors Цветовая маркировка волокон и модулей по TIA-598, по порядку номеров
+var FiberColors = []string{
+	"Синий",
+	"Оранжевый",
+	"Зеленый",
+	"Коричневый",
+	"Серый",
+	"Белый",
+	"Красный",
+	"Черный",
+	"Желтый",
+	"Фиолетовый",
+	"Розовый",
+	"Бирюзовый",
+}
+
+// Fiber Волокно оптического кабеля. Module - номер модуля, в котором лежит волокно
+type Fiber struct {
+	Number      int
+	Color       string
+	Module      int
+	ModuleColor string
+}
+
+// NewFiber Возвращает волокно с номером number и его цветами
+func NewFiber(number int) Fiber {
+	module := (number-1)/FiberModuleSize + 1
+
+	return Fiber{
+		Number:      number,
+		Color:       FiberColors[(number-1)%FiberModuleSize],
+		Module:      module,
+		ModuleColor: FiberColors[(module-1)%len(FiberColors)],
+	}
+}
+
+// FiberEnd Волокно кабеля, сваренное в муфте
+type FiberEnd struct {
+	Cable Cable
+	Fiber Fiber
+}
+
+// CableFiber Волокно кабеля со сварками на его концах
+type CableFiber struct {
+	Fiber   Fiber
+	Splices []FiberSplice
+}
+
+// FiberSplice Сварка двух волокон в пассивном узле. Loss - измеренное затухание, если оно не указано,
+// используется значение из справочника затухания
+type FiberSplice struct {
+	ID          int
+	Node        Node
+	A           FiberEnd
+	B           FiberEnd
+	Loss        sql.NullFloat64
+	Description sql.NullString
+	CreatedAt   int64
+	UpdatedAt   sql.NullInt64
+}
+
+// FiberAttenuation Справочное затухание для среды и длины волны в дБ: на километр, на сварку и на разъем
+type FiberAttenuation struct {
+	ID           int
+	Medium       string
+	Wavelength   int
+	PerKm        float64
+	PerSplice    float64
+	PerConnector float64
+	CreatedAt    int64
+	UpdatedAt    sql.NullInt64
+}
+
+// FiberHop Участок волокна в одном кабеле. Splice - сварка в узле To, через которую путь идет дальше
+type FiberHop struct {
+	Cable  Cable
+	Fiber  Fiber
+	From   CableEndpoint
+	To     CableEndpoint
+	Splice *FiberSplice
+}
+
+// LossBudget Расчет затухания на одной длине волны в дБ. Если переданы мощность передатчика и
+// диапазон входа приемника, считаются уровень на приеме, запас и достаточность сигнала
+type LossBudget struct {
+	Wavelength    int
+	CableLoss     float64
+	SpliceLoss    float64
+	ConnectorLoss float64
+	TotalLoss     float64
+	Received      sql.NullFloat64
+	Margin        sql.NullFloat64
+	IsSufficient  sql.NullBool
+}
+
+// FiberTrace Путь волокна от одного конца до другого через сварки. Length - суммарная длина кабелей
+// в метрах, Connectors - концы пути на портах оборудования
+type FiberTrace struct {
+	Hops       []FiberHop
+	Length     int
+	Splices    int
+	Connectors int
+	Budgets    []LossBudget
+}
@@ -0,0 +1,40 @@
+package models
+
+import "database/sql"
+
+// Статусы соответствия прошивки оборудования целевой версии модели
+const (
+	FirmwareCompliant = "COMPLIANT"
+	FirmwareOutdated  = "OUTDATED"
+	FirmwareUnknown   = "UNKNOWN"
+)
+
+// FirmwareModelSummary Сводка по модели коммутатора: сколько оборудования на целевой прошивке,
+// сколько на другой и сколько еще не опрошено
+type FirmwareModelSummary struct {
+	Switch    Switch
+	Total     int
+	Compliant int
+	Outdated  int
+	Unknown   int
+	Percent   float64
+}
+
+// FirmwareComplianceGroup Несоответствующее оборудование одной модели в одной зоне у одного владельца
+type FirmwareComplianceGroup struct {
+	Switch   Switch
+	Zone     sql.NullString
+	Owner    Reference
+	Hardware []FirmwareComplianceItem
+}
+
+type FirmwareComplianceItem struct {
+	Hardware Hardware
+	Status   string
+}
+
+type FirmwareComplianceReport struct {
+	Summary []FirmwareModelSummary
+	Groups  []FirmwareComplianceGroup
+	Count   int
+}
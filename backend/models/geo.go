@@ -0,0 +1,31 @@
+package models
+
+type BBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+type NodeLink struct {
+	Child  Node
+	Parent Node
+}
+
+type GeoFeatureCollection struct {
+	Type     string       `json:"type"`
+	BBox     []float64    `json:"bbox,omitempty"`
+	Features []GeoFeature `json:"features"`
+}
+
+type GeoFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Geometry   GeoGeometry            `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type GeoGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
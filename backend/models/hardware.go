@@ -3,14 +3,23 @@ package models
 import "database/sql"
 
 type Hardware struct {
-	ID          int
-	Node        Node
-	Type        Reference
-	Switch      Switch
-	IpAddress   sql.NullString
-	MgmtVlan    sql.NullString
-	Description sql.NullString
-	CreatedAt   int64
-	UpdatedAt   sql.NullInt64
-	IsDelete    bool
+	ID               int
+	Node             Node
+	Type             Reference
+	Switch           Switch
+	IpAddress        sql.NullString
+	MgmtVlan         sql.NullString
+	Description      sql.NullString
+	CreatedAt        int64
+	UpdatedAt        sql.NullInt64
+	IsDelete         bool
+	DeletedAt        sql.NullInt64
+	DeletedBy        sql.NullInt32
+	Firmware         sql.NullString
+	FirmwarePolledAt sql.NullInt64
+	SerialNumber     sql.NullString
+	InventoryNumber  sql.NullString
+	PurchaseDate     sql.NullInt64
+	WarrantyEnd      sql.NullInt64
+	Status           string
 }
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"backend/proto/userpb"
+	"encoding/json"
+)
+
+type History struct {
+	ID        int64
+	Entity    string
+	EntityID  int
+	Version   int
+	Action    string
+	Before    json.RawMessage
+	After     json.RawMessage
+	Changes   []HistoryChange
+	UserId    int32
+	User      *userpb.User
+	CreatedAt int64
+}
+
+type HistoryChange struct {
+	Field  string
+	Before interface{}
+	After  interface{}
+}
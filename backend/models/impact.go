@@ -0,0 +1,11 @@
+package models
+
+import "backend/proto/addresspb"
+
+type Impact struct {
+	Source    string
+	SourceID  int
+	Nodes     []NodeTreeItem
+	Hardware  []Hardware
+	Addresses []*addresspb.Address
+}
@@ -0,0 +1,13 @@
+package models
+
+import "database/sql"
+
+type InventoryFilter struct {
+	HouseID        int
+	OwnerID        int
+	Zone           string
+	NodeTypeID     int
+	HardwareTypeID int
+	IsDelete       sql.NullBool
+	IsPassive      sql.NullBool
+}
@@ -0,0 +1,33 @@
+package models
+
+import "database/sql"
+
+type Subnet struct {
+	ID          int
+	Network     string
+	Gateway     sql.NullString
+	Zone        sql.NullString
+	VlanID      sql.NullInt32
+	Description sql.NullString
+	CreatedAt   int64
+	UpdatedAt   sql.NullInt64
+}
+
+// SubnetUtilization Занятость подсети: Size - число адресов для узлов без сети и broadcast,
+// Reserved - шлюз, если он не занят оборудованием
+type SubnetUtilization struct {
+	Subnet   Subnet
+	Size     int64
+	Used     int64
+	Reserved int64
+	Free     int64
+	Percent  float64
+}
+
+type IPViolation struct {
+	Code      string
+	Message   string
+	IpAddress string
+	Hardware  *Hardware
+	Subnet    *Subnet
+}
@@ -0,0 +1,35 @@
+package models
+
+import "database/sql"
+
+// Статусы жизненного цикла оборудования
+const (
+	HardwareStatusInStock    = "IN_STOCK"
+	HardwareStatusInstalled  = "INSTALLED"
+	HardwareStatusInRepair   = "IN_REPAIR"
+	HardwareStatusWrittenOff = "WRITTEN_OFF"
+)
+
+var HardwareStatuses = map[string]string{
+	HardwareStatusInStock:    "На складе",
+	HardwareStatusInstalled:  "Установлено",
+	HardwareStatusInRepair:   "В ремонте",
+	HardwareStatusWrittenOff: "Списано",
+}
+
+// HardwareStatusChange Запись истории статусов оборудования. UserId не заполнен у записей,
+// созданных при миграции
+type HardwareStatusChange struct {
+	ID         int64
+	HardwareID int
+	Status     string
+	UserId     sql.NullInt32
+	CreatedAt  int64
+}
+
+// WarrantyItem Оборудование с гарантией, которая закончится в ближайшие дни или уже закончилась
+type WarrantyItem struct {
+	Hardware  Hardware
+	DaysLeft  int
+	IsExpired bool
+}
@@ -0,0 +1,17 @@
+package models
+
+// MacEntry Запись таблицы коммутации: MAC адрес, замеченный на порту оборудования.
+// VlanID = 0, если таблица собрана без VLAN (BRIDGE-MIB). PortMacCount - число MAC адресов
+// на порту в последнем опросе, большое значение означает магистральный порт
+type MacEntry struct {
+	ID           int64
+	Mac          string
+	Hardware     Hardware
+	Port         *HardwarePort
+	PortNumber   int
+	VlanID       int
+	FirstSeen    int64
+	LastSeen     int64
+	IsCurrent    bool
+	PortMacCount int
+}
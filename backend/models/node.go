@@ -22,4 +22,50 @@ type Node struct {
 	UpdatedAt   sql.NullInt64
 	IsDelete    bool
 	IsPassive   bool
+	DeletedAt   sql.NullInt64
+	DeletedBy   sql.NullInt32
+	Latitude    sql.NullFloat64
+	Longitude   sql.NullFloat64
+}
+
+type NodeTreeItem struct {
+	Node           Node
+	Depth          int
+	HardwareAmount int
+	Children       []*NodeTreeItem
+}
+
+type NodeTypeRule struct {
+	ID         int
+	ParentType Reference
+	ChildType  Reference
+	CreatedAt  int64
+}
+
+type TopologyViolation struct {
+	Code           string
+	Message        string
+	NodeID         int
+	ParentID       int
+	ParentTypeKey  string
+	ChildTypeKey   string
+	AllowedParents []string
+	Cycle          []int
+}
+
+type NodeMove struct {
+	ParentID int
+	HouseID  int32
+}
+
+type NodeDeletePlan struct {
+	Policy          string
+	DryRun          bool
+	IsBlocked       bool
+	Node            Node
+	NewParent       *Node
+	ChildNodes      []Node
+	DeletedNodes    []Node
+	DeletedHardware []Hardware
+	ReparentedNodes []Node
 }
@@ -0,0 +1,17 @@
+package models
+
+type NodeImportRow struct {
+	Row       int
+	ParentRow int
+	Node      Node
+	Errors    []string
+}
+
+type NodeImportReport struct {
+	DryRun    bool
+	Committed bool
+	Total     int
+	Valid     int
+	Invalid   int
+	Rows      []NodeImportRow
+}
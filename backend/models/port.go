@@ -0,0 +1,20 @@
+package models
+
+import "database/sql"
+
+type HardwarePort struct {
+	ID           int
+	Hardware     Hardware
+	Number       int
+	Description  sql.NullString
+	IfName       sql.NullString
+	IfDescr      sql.NullString
+	Speed        sql.NullInt64
+	Mode         sql.NullString
+	UntaggedVlan sql.NullInt32
+	LinkState    sql.NullString
+	Source       string
+	CreatedAt    int64
+	UpdatedAt    sql.NullInt64
+	Vlans        []PortVlan
+}
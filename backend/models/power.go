@@ -0,0 +1,40 @@
+package models
+
+import "database/sql"
+
+// Состояния батареи ИБП по UPS-MIB (upsBatteryStatus), у APC (upsBasicBatteryStatus) значения те же
+const (
+	BatteryStatusUnknown  = 1
+	BatteryStatusNormal   = 2
+	BatteryStatusLow      = 3
+	BatteryStatusDepleted = 4
+)
+
+var BatteryStatuses = map[int32]string{
+	BatteryStatusUnknown:  "UNKNOWN",
+	BatteryStatusNormal:   "NORMAL",
+	BatteryStatusLow:      "LOW",
+	BatteryStatusDepleted: "DEPLETED",
+}
+
+// BatteryReading Показание батареи ИБП из одного опроса. Charge - заряд в процентах
+type BatteryReading struct {
+	ID         int64
+	HardwareID int
+	Status     sql.NullInt32
+	Charge     sql.NullInt32
+	PolledAt   int64
+}
+
+// PowerAlert Узел, ИБП которого работает от батареи или разряжен. Reasons - коды причин:
+// BATTERY_LOW, BATTERY_DEPLETED, LOW_CHARGE (заряд ниже порога) и DISCHARGING (заряд падает)
+type PowerAlert struct {
+	Node           Node
+	Hardware       Hardware
+	Status         sql.NullInt32
+	StatusName     string
+	Charge         sql.NullInt32
+	PreviousCharge sql.NullInt32
+	Reasons        []string
+	PolledAt       int64
+}
@@ -0,0 +1,53 @@
+package models
+
+import "database/sql"
+
+// Виды мест установки оборудования в узле
+const (
+	RackKindRack    = "RACK"
+	RackKindCabinet = "CABINET"
+)
+
+var RackKinds = map[string]string{
+	RackKindRack:    "Стойка",
+	RackKindCabinet: "Шкаф",
+}
+
+// Стороны стойки, с которых установлено оборудование
+const (
+	RackSideFront = "FRONT"
+	RackSideRear  = "REAR"
+)
+
+var RackSides = map[string]string{
+	RackSideFront: "Спереди",
+	RackSideRear:  "Сзади",
+}
+
+// MaxRackUnits Наибольшая высота стойки в юнитах
+const MaxRackUnits = 60
+
+// Rack Стойка или шкаф в узле. Units - высота в юнитах
+type Rack struct {
+	ID          int
+	Node        Node
+	Name        string
+	Kind        string
+	Units       int
+	Description sql.NullString
+	CreatedAt   int64
+	UpdatedAt   sql.NullInt64
+	Placements  []RackPlacement
+}
+
+// RackPlacement Установка оборудования в стойку. Unit - нижний занятый юнит (нумерация снизу с 1),
+// Height - сколько юнитов занимает оборудование
+type RackPlacement struct {
+	Hardware  Hardware
+	RackID    int
+	Unit      int
+	Height    int
+	Side      string
+	CreatedAt int64
+	UpdatedAt sql.NullInt64
+}
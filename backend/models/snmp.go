@@ -0,0 +1,20 @@
+package models
+
+import "database/sql"
+
+type SnmpReading struct {
+	HardwareID int
+	Metric     string
+	OID        string
+	Value      string
+	PolledAt   int64
+}
+
+type SnmpPoll struct {
+	HardwareID int
+	PolledAt   int64
+	SuccessAt  sql.NullInt64
+	Error      sql.NullString
+	Metrics    []string
+	Readings   []SnmpReading
+}
@@ -23,4 +23,10 @@ type Switch struct {
 	UptimeOID        sql.NullString
 	CreatedAt        int64
 	MacOID           sql.NullString
+	TargetFirmware   sql.NullString
+	// OID столбцов удаленной таблицы соседей LLDP-MIB (lldpRemChassisId, lldpRemSysName, lldpRemPortId)
+	// или CDP (cdpCacheDeviceId, cdpCacheDevicePort). Предпоследний индекс строки - локальный порт
+	LldpChassisIdOID sql.NullString
+	LldpSysNameOID   sql.NullString
+	LldpPortIdOID    sql.NullString
 }
@@ -0,0 +1,61 @@
+package models
+
+import "database/sql"
+
+// Виды предложений автоматического обнаружения топологии: родитель узла и кабель между портами
+const (
+	TopologyProposalParent = "PARENT"
+	TopologyProposalLink   = "LINK"
+)
+
+// Статусы предложений: ожидает проверки оператором, принято, отклонено
+const (
+	TopologyProposalPending  = "PENDING"
+	TopologyProposalAccepted = "ACCEPTED"
+	TopologyProposalRejected = "REJECTED"
+)
+
+var TopologyProposalStatuses = map[string]string{
+	TopologyProposalPending:  "Ожидает проверки",
+	TopologyProposalAccepted: "Принято",
+	TopologyProposalRejected: "Отклонено",
+}
+
+// LldpNeighbor Сосед из таблицы LLDP/CDP оборудования. RemoteHardware заполнено, если сосед
+// сопоставлен с известным оборудованием по chassis ID, имени системы или IP адресу. LocalPort - номер порта
+// из таблицы соседей (lldpLocPortNum для LLDP), LocalIfIndex - ifIndex этого порта, если его удалось
+// однозначно определить
+type LldpNeighbor struct {
+	ID             int64
+	Hardware       Hardware
+	LocalPort      int
+	LocalIfIndex   sql.NullInt32
+	ChassisID      string
+	SystemName     string
+	PortID         sql.NullString
+	RemoteHardware *Hardware
+	FirstSeen      int64
+	LastSeen       int64
+}
+
+// TopologyProposal Предложение изменить топологию по данным LLDP/CDP. Для PARENT узлу Node предлагается
+// родитель ProposedParent; если CurrentParent задан, это конфликт с записанным parent_id и оператор видит
+// оба варианта. Для LINK предлагается кабель между портами Port и RemotePort
+type TopologyProposal struct {
+	ID             int
+	Kind           string
+	Status         string
+	Hardware       Hardware
+	LocalPort      int
+	RemoteHardware Hardware
+	RemotePortID   sql.NullString
+	Node           *Node
+	CurrentParent  *Node
+	ProposedParent *Node
+	Port           *HardwarePort
+	RemotePort     *HardwarePort
+	IsConflict     bool
+	CreatedAt      int64
+	ReviewedAt     sql.NullInt64
+	ReviewedBy     sql.NullInt32
+}
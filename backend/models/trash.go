@@ -0,0 +1,14 @@
+package models
+
+import (
+	"backend/proto/addresspb"
+	"backend/proto/userpb"
+)
+
+type TrashItem struct {
+	Node      *Node
+	Hardware  *Hardware
+	Address   *addresspb.Address
+	User      *userpb.User
+	DeletedAt int64
+}
@@ -0,0 +1,31 @@
+package models
+
+import "database/sql"
+
+type Vlan struct {
+	ID        int
+	VlanID    int
+	Name      string
+	Purpose   sql.NullString
+	Zone      sql.NullString
+	CreatedAt int64
+	UpdatedAt sql.NullInt64
+}
+
+type PortVlan struct {
+	VlanID    int
+	Tagged    bool
+	Source    string
+	UpdatedAt int64
+}
+
+// VlanUsage VLAN, настроенный на коммутаторах, и оборудование, где он встречается
+type VlanUsage struct {
+	VlanID   int
+	Hardware []Hardware
+}
+
+type VlanReport struct {
+	Unregistered []VlanUsage
+	Unused       []Vlan
+}
@@ -0,0 +1,223 @@
+package monitor
+
+import (
+	"backend/database"
+	"backend/models"
+	"backend/snmp"
+	"backend/utils"
+	"context"
+	"database/sql"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Способы проверки доступности. MethodAuto сначала пробует ICMP, при неудаче - TCP
+const (
+	MethodICMP = "ICMP"
+	MethodTCP  = "TCP"
+	MethodAuto = "AUTO"
+)
+
+type Monitor interface {
+	Run(ctx context.Context)
+	CheckAll(ctx context.Context) error
+}
+
+type DefaultMonitor struct {
+	Client           snmp.Client
+	AvailabilityRepo database.AvailabilityRepository
+	Method           string
+	Ports            []int
+	Timeout          time.Duration
+	Interval         time.Duration
+	Workers          int
+	Failures         int
+	utils.Logger
+}
+
+func NewMonitor(db *database.Database, logger *utils.Logger) Monitor {
+	method := strings.ToUpper(os.Getenv("AVAILABILITY_METHOD"))
+	if method != MethodICMP && method != MethodTCP {
+		method = MethodAuto
+	}
+
+	var ports []int
+
+	for _, value := range strings.Split(getEnvString("AVAILABILITY_TCP_PORTS", "22,23,80,443"), ",") {
+		if port, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && port > 0 && port < 65536 {
+			ports = append(ports, port)
+		}
+	}
+
+	return &DefaultMonitor{
+		Client: snmp.NewClient(),
+		AvailabilityRepo: &database.DefaultAvailabilityRepository{
+			Database: *db,
+		},
+		Method:   method,
+		Ports:    ports,
+		Timeout:  time.Duration(getEnvInt("AVAILABILITY_TIMEOUT", 2)) * time.Second,
+		Interval: time.Duration(getEnvInt("AVAILABILITY_INTERVAL", 60)) * time.Second,
+		Workers:  getEnvInt("AVAILABILITY_WORKERS", 16),
+		Failures: getEnvInt("AVAILABILITY_FAILURES", 3),
+		Logger:   *logger,
+	}
+}
+
+// Run Периодически проверяет доступность всего оборудования. При AVAILABILITY_INTERVAL=0 проверки выключены
+func (m *DefaultMonitor) Run(ctx context.Context) {
+	if m.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.CheckAll(ctx); err != nil {
+			log.Printf("failed to check availability: %v\n", err)
+			m.Logger.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll Проверяет все оборудование с IP адресом в несколько потоков. Перед проверкой закрываются
+// простои оборудования, которое удалили или у которого убрали IP адрес
+func (m *DefaultMonitor) CheckAll(ctx context.Context) error {
+	if err := m.AvailabilityRepo.CloseUnmonitoredOutages(time.Now().Unix()); err != nil {
+		return err
+	}
+
+	targets, err := m.AvailabilityRepo.GetAvailabilityTargets()
+	if err != nil {
+		return err
+	}
+
+	workers := m.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	queue := make(chan models.Hardware)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for hd := range queue {
+				if e := m.AvailabilityRepo.SaveAvailabilityCheck(m.check(hd), m.Failures); e != nil {
+					log.Printf("failed to save availability of hardware %d: %v\n", hd.ID, e)
+					m.Logger.Println(e)
+				}
+			}
+		}()
+	}
+
+	for _, hd := range targets {
+		if net.ParseIP(strings.TrimSpace(hd.IpAddress.String)) == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			close(queue)
+			wg.Wait()
+			return ctx.Err()
+		case queue <- hd:
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+
+	return nil
+}
+
+func (m *DefaultMonitor) check(hd models.Hardware) models.AvailabilityCheck {
+	address := strings.TrimSpace(hd.IpAddress.String)
+	check := models.AvailabilityCheck{
+		HardwareID: hd.ID,
+		Method:     m.Method,
+		CheckedAt:  time.Now().Unix(),
+	}
+
+	var (
+		rtt time.Duration
+		err error
+	)
+
+	switch m.Method {
+	case MethodICMP:
+		rtt, err = pingICMP(address, m.Timeout)
+	case MethodTCP:
+		rtt, err = probeTCP(address, m.Ports, m.Timeout)
+	default:
+		check.Method = MethodICMP
+
+		if rtt, err = pingICMP(address, m.Timeout); err != nil {
+			check.Method = MethodTCP
+			rtt, err = probeTCP(address, m.Ports, m.Timeout)
+		}
+	}
+
+	if err != nil {
+		check.Error = sql.NullString{String: err.Error(), Valid: true}
+		return check
+	}
+
+	check.IsUp = true
+	check.Rtt = sql.NullInt32{Int32: int32(rtt.Milliseconds()), Valid: true}
+
+	if !hd.Switch.UptimeOID.Valid || strings.TrimSpace(hd.Switch.UptimeOID.String) == "" {
+		return check
+	}
+
+	target := snmp.Target{Address: address, Community: "public"}
+
+	if hd.Switch.CommunityRead.Valid && hd.Switch.CommunityRead.String != "" {
+		target.Community = hd.Switch.CommunityRead.String
+	}
+
+	// Ошибка SNMP не делает оборудование недоступным, просто uptime остается неизвестным
+	variables, err := m.Client.Walk(target, strings.TrimSpace(hd.Switch.UptimeOID.String))
+	if err != nil || len(variables) == 0 {
+		return check
+	}
+
+	// sysUpTime и аналоги отдают TimeTicks - сотые доли секунды
+	if ticks, e := strconv.ParseInt(variables[0].Value, 10, 64); e == nil && ticks >= 0 {
+		check.Uptime = sql.NullInt64{Int64: ticks / 100, Valid: true}
+	}
+
+	return check
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+func getEnvString(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return defaultValue
+}
@@ -0,0 +1,121 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+var icmpSequence uint32
+
+// pingICMP Отправляет ICMP echo и ждет ответа. Сначала пробует raw сокет (нужны права root или
+// CAP_NET_RAW), затем непривилегированный UDP сокет (net.ipv4.ping_group_range)
+func pingICMP(address string, timeout time.Duration) (time.Duration, error) {
+	ip := net.ParseIP(address).To4()
+	if ip == nil {
+		return 0, fmt.Errorf("invalid ipv4 address: %s", address)
+	}
+
+	var (
+		conn *icmp.PacketConn
+		dst  net.Addr
+		err  error
+	)
+
+	if conn, err = icmp.ListenPacket("ip4:icmp", "0.0.0.0"); err == nil {
+		dst = &net.IPAddr{IP: ip}
+	} else if conn, err = icmp.ListenPacket("udp4", "0.0.0.0"); err == nil {
+		dst = &net.UDPAddr{IP: ip}
+	} else {
+		return 0, err
+	}
+	defer conn.Close()
+
+	seq := int(atomic.AddUint32(&icmpSequence, 1) & 0xffff)
+
+	request, err := (&icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{ID: os.Getpid() & 0xffff, Seq: seq, Data: []byte("network-hub")},
+	}).Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+
+	if _, err = conn.WriteTo(request, dst); err != nil {
+		return 0, err
+	}
+
+	if err = conn.SetReadDeadline(start.Add(timeout)); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 1500)
+
+	for {
+		n, from, e := conn.ReadFrom(buf)
+		if e != nil {
+			return 0, e
+		}
+
+		message, e := icmp.ParseMessage(1, buf[:n])
+		if e != nil || message.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
+
+		// ID у UDP сокета подменяет ядро, поэтому ответ узнается по адресу и номеру
+		echo, ok := message.Body.(*icmp.Echo)
+		if !ok || echo.Seq != seq || !sameIP(from, ip) {
+			continue
+		}
+
+		return time.Since(start), nil
+	}
+}
+
+// probeTCP Пробует установить TCP соединение с любым из портов. Отказ в соединении (RST) тоже
+// означает, что оборудование доступно
+func probeTCP(address string, ports []int, timeout time.Duration) (time.Duration, error) {
+	if len(ports) == 0 {
+		return 0, errors.New("tcp probe ports are not set")
+	}
+
+	var lastErr error
+
+	for _, port := range ports {
+		start := time.Now()
+
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(address, strconv.Itoa(port)), timeout)
+		if err == nil {
+			conn.Close()
+			return time.Since(start), nil
+		}
+
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return time.Since(start), nil
+		}
+
+		lastErr = err
+	}
+
+	return 0, lastErr
+}
+
+func sameIP(addr net.Addr, ip net.IP) bool {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.Equal(ip)
+	case *net.UDPAddr:
+		return a.IP.Equal(ip)
+	}
+
+	return false
+}
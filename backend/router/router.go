@@ -1,14 +1,18 @@
 package router
 
 import (
+	"backend/backup"
 	"backend/database"
 	"backend/handlers"
 	"backend/kafka"
 	"backend/middleware"
+	"backend/monitor"
+	"backend/snmp"
 	"backend/utils"
 	"context"
 	"github.com/gin-gonic/gin"
 	"log"
+	"strings"
 )
 
 // Initialization Функция инициализации роутинга
@@ -30,6 +34,35 @@ func Initialization(db *database.Database) *gin.Engine {
 	handlerAuth := handlers.NewAuthHandler(userService)
 	handlerAddress := handlers.NewAddressHandler(addressService, db)
 	handlerReport := handlers.NewReportHandler(db, &logger)
+	handlerImpact := handlers.NewImpactHandler(addressService, db)
+	handlerTrash := handlers.NewTrashHandler(userService, addressService, db)
+	handlerInventory := handlers.NewInventoryHandler(addressService, db)
+	handlerHistory := handlers.NewHistoryHandler(userService, addressService, db, &logger)
+	handlerGeo := handlers.NewGeoHandler(addressService, db)
+	handlerPort := handlers.NewPortHandler(addressService, db)
+	handlerVlan := handlers.NewVlanHandler(addressService, db)
+	handlerIpam := handlers.NewIpamHandler(db)
+	handlerMac := handlers.NewMacHandler(addressService, db)
+	handlerPower := handlers.NewPowerHandler(addressService, db)
+	handlerFirmware := handlers.NewFirmwareHandler(addressService, db)
+	handlerAvailability := handlers.NewAvailabilityHandler(addressService, db)
+	handlerCable := handlers.NewCableHandler(addressService, db)
+	handlerFiber := handlers.NewFiberHandler(addressService, db)
+	handlerRack := handlers.NewRackHandler(addressService, db)
+	handlerCapacity := handlers.NewCapacityHandler(addressService, db)
+	handlerTopology := handlers.NewTopologyHandler(addressService, db, &logger)
+
+	poller := snmp.NewPoller(db, &logger) // Инициализируем фоновый SNMP опрос оборудования
+	handlerSnmp := handlers.NewSnmpHandler(poller, db)
+
+	backuper := backup.NewBackuper(db, &logger) // Инициализируем снятие конфигураций коммутаторов по TFTP
+	handlerConfig := handlers.NewConfigHandler(backuper, db)
+
+	go poller.Run(context.Background())
+	go backuper.Run(context.Background())
+
+	availabilityMonitor := monitor.NewMonitor(db, &logger) // Инициализируем проверку доступности оборудования
+	go availabilityMonitor.Run(context.Background())
 
 	go func() {
 		if err := kafka.CreateTopics(); err != nil {
@@ -73,15 +106,37 @@ func Initialization(db *database.Database) *gin.Engine {
 		nodes.GET("", handlerNode.HandlerGetNodes)
 		nodes.GET("/:id", handlerNode.HandlerGetNode)
 		nodes.GET("/search", handlerNode.HandlerGetSearchNodes)
+		nodes.GET("/geojson", handlerGeo.HandlerGetNodesGeoJSON)
+		nodes.GET("/kml", handlerGeo.HandlerGetNodesKML)
+		nodes.GET("/type-rules", handlerNode.HandlerGetNodeTypeRules)
+		nodes.POST("/type-rules", handlerNode.HandlerCreateNodeTypeRule)
+		nodes.DELETE("/type-rules/:id", handlerNode.HandlerDeleteNodeTypeRule)
 		nodes.GET("/:id/files", handlerFile.HandlerGetNodeFiles)
 		nodes.GET("/:id/images", handlerFile.HandlerGetNodeImages)
 		nodes.GET("/:id/hardware", handlerHardware.HandlerGetNodeHardware)
+		nodes.GET("/:id/tree", handlerNode.HandlerGetNodeTree)
+		nodes.GET("/:id/path", handlerNode.HandlerGetNodePath)
+		nodes.GET("/:id/impact", handlerImpact.HandlerGetNodeImpact)
+		nodes.GET("/:id/impact/excel", handlerImpact.HandlerGetNodeImpactExcel)
+		nodes.GET("/:id/cables", handlerCable.HandlerGetNodeCables)
+		nodes.GET("/:id/trace", handlerCable.HandlerGetNodeTrace)
+		nodes.GET("/:id/splices", handlerFiber.HandlerGetNodeSplices)
+		nodes.GET("/:id/racks", handlerRack.HandlerGetNodeRacks)
 		nodes.POST("", handlerNode.HandlerCreateNode)
 		nodes.PUT("", handlerNode.HandlerEditNode)
+		nodes.POST("/:id/move", handlerNode.HandlerMoveNode)
+		nodes.POST("/import", handlerNode.HandlerImportNodes)
 		nodes.GET("/:id/events/:type", func(c *gin.Context) {
 			handlerEvent.HandlerGetEvents(c, "NODE")
 		})
 		nodes.DELETE("/:id", handlerNode.HandlerDeleteNode)
+		nodes.POST("/:id/restore", handlerNode.HandlerRestoreNode)
+		nodes.GET("/:id/history", func(c *gin.Context) {
+			handlerHistory.HandlerGetHistory(c, "NODE")
+		})
+		nodes.POST("/:id/history/:version/revert", func(c *gin.Context) {
+			handlerHistory.HandlerRevertHistory(c, "NODE")
+		})
 		//nodes.GET("/index", handlerNode.HandlerIndexNodes)
 	}
 
@@ -93,6 +148,7 @@ func Initialization(db *database.Database) *gin.Engine {
 		houses.GET("/:id/files", handlerFile.HandlerGetHouseFiles)
 		houses.GET("/:id/nodes", handlerNode.HandlerGetHouseNodes)
 		houses.GET("/:id/hardware", handlerHardware.HandlerGetHouseHardware)
+		houses.GET("/:id/capacity", handlerCapacity.HandlerGetHouseCapacity)
 		houses.GET("/:id/events/:type", func(c *gin.Context) {
 			handlerEvent.HandlerGetEvents(c, "HOUSE")
 		})
@@ -104,14 +160,42 @@ func Initialization(db *database.Database) *gin.Engine {
 	{
 		hardware.GET("", handlerHardware.HandlerGetHardware)
 		hardware.GET("/search", handlerHardware.HandlerGetSearchHardware)
+		hardware.GET("/warranty", handlerHardware.HandlerGetWarrantyReport)
 		hardware.GET("/:id", handlerHardware.HandlerGetHardwareByID)
 		hardware.GET("/:id/files", handlerFile.HandlerGetHardwareFiles)
+		hardware.GET("/:id/impact", handlerImpact.HandlerGetHardwareImpact)
+		hardware.GET("/:id/impact/excel", handlerImpact.HandlerGetHardwareImpactExcel)
 		hardware.POST("", handlerHardware.HandlerCreateHardware)
 		hardware.PUT("", handlerHardware.HandlerEditHardware)
 		hardware.GET("/:id/events/:type", func(c *gin.Context) {
 			handlerEvent.HandlerGetEvents(c, "HARDWARE")
 		})
 		hardware.DELETE("/:id", handlerHardware.HandlerDeleteHardware)
+		hardware.POST("/:id/restore", handlerHardware.HandlerRestoreHardware)
+		hardware.GET("/:id/snmp", handlerSnmp.HandlerGetHardwareSnmp)
+		hardware.POST("/:id/snmp/poll", handlerSnmp.HandlerPollHardwareSnmp)
+		hardware.GET("/:id/ports", handlerPort.HandlerGetHardwarePorts)
+		hardware.POST("/:id/ports", handlerPort.HandlerCreatePort)
+		hardware.PUT("/:id/ports", handlerPort.HandlerEditPort)
+		hardware.DELETE("/:id/ports/:portId", handlerPort.HandlerDeletePort)
+		hardware.PUT("/:id/ports/:portId/vlans", handlerPort.HandlerSetPortVlans)
+		hardware.GET("/:id/status-history", handlerHardware.HandlerGetHardwareStatusHistory)
+		hardware.GET("/:id/neighbours", handlerCable.HandlerGetHardwareNeighbours)
+		hardware.GET("/:id/lldp", handlerTopology.HandlerGetHardwareLldp)
+		hardware.PUT("/:id/placement", handlerRack.HandlerSetHardwarePlacement)
+		hardware.DELETE("/:id/placement", handlerRack.HandlerDeleteHardwarePlacement)
+		hardware.GET("/:id/outages", handlerAvailability.HandlerGetHardwareOutages)
+		hardware.GET("/:id/battery", handlerPower.HandlerGetHardwareBattery)
+		hardware.GET("/:id/configs", handlerConfig.HandlerGetHardwareConfigs)
+		hardware.GET("/:id/configs/diff", handlerConfig.HandlerGetHardwareConfigDiff)
+		hardware.GET("/:id/configs/:version", handlerConfig.HandlerGetHardwareConfig)
+		hardware.POST("/:id/configs/backup", handlerConfig.HandlerBackupHardwareConfig)
+		hardware.GET("/:id/history", func(c *gin.Context) {
+			handlerHistory.HandlerGetHistory(c, "HARDWARE")
+		})
+		hardware.POST("/:id/history/:version/revert", func(c *gin.Context) {
+			handlerHistory.HandlerRevertHistory(c, "HARDWARE")
+		})
 	}
 
 	switches := routerAPI.Group("/switches")
@@ -119,6 +203,80 @@ func Initialization(db *database.Database) *gin.Engine {
 		switches.GET("", handlerSwitch.HandlerGetSwitches)
 		switches.POST("", handlerSwitch.HandlerCreateSwitch)
 		switches.PUT("", handlerSwitch.HandlerEditSwitch)
+		switches.GET("/firmware", handlerFirmware.HandlerGetFirmwareCompliance)
+		switches.GET("/firmware/excel", handlerFirmware.HandlerGetFirmwareComplianceExcel)
+		switches.GET("/:id/history", func(c *gin.Context) {
+			handlerHistory.HandlerGetHistory(c, "SWITCH")
+		})
+		switches.POST("/:id/history/:version/revert", func(c *gin.Context) {
+			handlerHistory.HandlerRevertHistory(c, "SWITCH")
+		})
+	}
+
+	cables := routerAPI.Group("/cables")
+	{
+		cables.GET("", handlerCable.HandlerGetCables)
+		cables.GET("/:id", handlerCable.HandlerGetCable)
+		cables.POST("", handlerCable.HandlerCreateCable)
+		cables.PUT("", handlerCable.HandlerEditCable)
+		cables.DELETE("/:id", handlerCable.HandlerDeleteCable)
+		cables.GET("/:id/fibers", handlerFiber.HandlerGetCableFibers)
+		cables.GET("/:id/fibers/:fiber/trace", handlerFiber.HandlerGetFiberTrace)
+	}
+
+	racks := routerAPI.Group("/racks")
+	{
+		racks.GET("/:id", handlerRack.HandlerGetRack)
+		racks.GET("/:id/svg", handlerRack.HandlerGetRackSVG)
+		racks.POST("", handlerRack.HandlerCreateRack)
+		racks.PUT("", handlerRack.HandlerEditRack)
+		racks.DELETE("/:id", handlerRack.HandlerDeleteRack)
+	}
+
+	fiber := routerAPI.Group("/fiber")
+	{
+		fiber.POST("/splices", handlerFiber.HandlerCreateFiberSplice)
+		fiber.PUT("/splices", handlerFiber.HandlerEditFiberSplice)
+		fiber.DELETE("/splices/:id", handlerFiber.HandlerDeleteFiberSplice)
+		fiber.GET("/attenuation", handlerFiber.HandlerGetFiberAttenuation)
+		fiber.PUT("/attenuation", handlerFiber.HandlerEditFiberAttenuation)
+	}
+
+	topology := routerAPI.Group("/topology")
+	{
+		topology.GET("/proposals", handlerTopology.HandlerGetTopologyProposals)
+		topology.POST("/discover", handlerTopology.HandlerDiscoverTopology)
+		topology.POST("/proposals/:id/accept", handlerTopology.HandlerAcceptTopologyProposal)
+		topology.POST("/proposals/:id/reject", handlerTopology.HandlerRejectTopologyProposal)
+	}
+
+	vlans := routerAPI.Group("/vlans")
+	{
+		vlans.GET("", handlerVlan.HandlerGetVlans)
+		vlans.POST("", handlerVlan.HandlerCreateVlan)
+		vlans.PUT("", handlerVlan.HandlerEditVlan)
+		vlans.GET("/report", handlerVlan.HandlerGetVlanReport)
+		vlans.GET("/:id/history", func(c *gin.Context) {
+			handlerHistory.HandlerGetHistory(c, "VLAN")
+		})
+		vlans.POST("/:id/history/:version/revert", func(c *gin.Context) {
+			handlerHistory.HandlerRevertHistory(c, "VLAN")
+		})
+	}
+
+	ipam := routerAPI.Group("/ipam")
+	{
+		ipam.GET("/subnets", handlerIpam.HandlerGetSubnets)
+		ipam.POST("/subnets", handlerIpam.HandlerCreateSubnet)
+		ipam.PUT("/subnets", handlerIpam.HandlerEditSubnet)
+		ipam.GET("/subnets/:id/next-free", handlerIpam.HandlerGetNextFreeIP)
+		ipam.GET("/subnets/:id/history", func(c *gin.Context) {
+			handlerHistory.HandlerGetHistory(c, "SUBNET")
+		})
+		ipam.POST("/subnets/:id/history/:version/revert", func(c *gin.Context) {
+			handlerHistory.HandlerRevertHistory(c, "SUBNET")
+		})
+		ipam.GET("/utilization", handlerIpam.HandlerGetSubnetUtilization)
 	}
 
 	files := routerAPI.Group("/files")
@@ -136,14 +294,37 @@ func Initialization(db *database.Database) *gin.Engine {
 		references.PUT("/:reference", func(c *gin.Context) {
 			handlerReference.HandlerReferenceRecord(c, true)
 		})
+		references.GET("/:reference/:id/history", func(c *gin.Context) {
+			handlerHistory.HandlerGetHistory(c, strings.ToUpper(c.Param("reference")))
+		})
+		references.POST("/:reference/:id/history/:version/revert", func(c *gin.Context) {
+			handlerHistory.HandlerRevertHistory(c, strings.ToUpper(c.Param("reference")))
+		})
 	}
 
 	report := routerAPI.Group("/report")
 	{
 		report.GET("", handlerReport.HandlerGetReportData)
 		report.PUT("", handlerReport.HandlerEditReportData)
+		report.GET("/:id/history", func(c *gin.Context) {
+			handlerHistory.HandlerGetHistory(c, "REPORT_DATA")
+		})
+		report.POST("/:id/history/:version/revert", func(c *gin.Context) {
+			handlerHistory.HandlerRevertHistory(c, "REPORT_DATA")
+		})
 	}
 
+	routerAPI.GET("/trash", handlerTrash.HandlerGetTrash)
+	routerAPI.GET("/inventory/export", handlerInventory.HandlerExportInventory)
+	routerAPI.GET("/ports/search", handlerPort.HandlerSearchPorts)
+	routerAPI.GET("/mac/:mac", handlerMac.HandlerFindMac)
+	routerAPI.GET("/alerts/power", handlerPower.HandlerGetPowerAlerts)
+	routerAPI.GET("/availability", handlerAvailability.HandlerGetAvailability)
+	routerAPI.GET("/availability/outages", handlerAvailability.HandlerGetCurrentOutages)
+	routerAPI.GET("/capacity", handlerCapacity.HandlerGetCapacity)
+	routerAPI.GET("/capacity/low", handlerCapacity.HandlerGetLowCapacity)
+	routerAPI.GET("/capacity/low/excel", handlerCapacity.HandlerGetLowCapacityExcel)
+
 	routerAPI.GET("/events", func(c *gin.Context) {
 		handlerEvent.HandlerGetEvents(c, "")
 	})
@@ -0,0 +1,56 @@
+package snmp
+
+import (
+	"backend/models"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// BuildBatteryReading Собирает показание батареи ИБП из метрик BATTERY_STATUS и BATTERY_CHARGE.
+// Возвращает nil, если ни одна из метрик не опрошена или значения не числовые
+func BuildBatteryReading(hardwareID int, readings []models.SnmpReading, polledAt int64) *models.BatteryReading {
+	reading := models.BatteryReading{
+		HardwareID: hardwareID,
+		PolledAt:   polledAt,
+	}
+
+	for _, r := range readings {
+		value, ok := parseLeadingInt(r.Value)
+		if !ok {
+			continue
+		}
+
+		switch r.Metric {
+		case "BATTERY_STATUS":
+			reading.Status = sql.NullInt32{Int32: int32(value), Valid: true}
+		case "BATTERY_CHARGE":
+			if value >= 0 && value <= 100 {
+				reading.Charge = sql.NullInt32{Int32: int32(value), Valid: true}
+			}
+		}
+	}
+
+	if !reading.Status.Valid && !reading.Charge.Valid {
+		return nil
+	}
+
+	return &reading
+}
+
+// parseLeadingInt Разбирает число в начале значения, некоторые агенты отдают заряд как "95 %"
+func parseLeadingInt(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	end := 0
+
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+
+	number, err := strconv.Atoi(value[:end])
+	if err != nil {
+		return 0, false
+	}
+
+	return number, true
+}
@@ -0,0 +1,212 @@
+package snmp
+
+import (
+	"fmt"
+	"github.com/gosnmp/gosnmp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Target struct {
+	Address   string
+	Community string
+}
+
+// Variable Значение SNMP. Для OCTET STRING в Hex дополнительно хранятся исходные байты,
+// т.к. битовая маска портов может случайно оказаться печатной строкой
+type Variable struct {
+	OID   string
+	Value string
+	Hex   string
+}
+
+type Client interface {
+	Walk(target Target, rootOID string) ([]Variable, error)
+	Set(target Target, variables []Variable) error
+}
+
+type DefaultClient struct {
+	Port    uint16
+	Timeout time.Duration
+	Retries int
+}
+
+// NewClient Создает SNMP v2c клиента. Порт, таймаут и число повторов берутся из переменных среды,
+// поэтому для проверки на симуляторе агента достаточно указать SNMP_PORT, например 1161
+func NewClient() Client {
+	return &DefaultClient{
+		Port:    uint16(getEnvInt("SNMP_PORT", 161)),
+		Timeout: time.Duration(getEnvInt("SNMP_TIMEOUT", 5)) * time.Second,
+		Retries: getEnvInt("SNMP_RETRIES", 1),
+	}
+}
+
+// Walk Обходит поддерево OID. Если OID указывает на скаляр, возвращается его значение
+func (c *DefaultClient) Walk(target Target, rootOID string) ([]Variable, error) {
+	g := &gosnmp.GoSNMP{
+		Target:             target.Address,
+		Port:               c.Port,
+		Community:          target.Community,
+		Version:            gosnmp.Version2c,
+		Timeout:            c.Timeout,
+		Retries:            c.Retries,
+		MaxRepetitions:     20,
+		ExponentialTimeout: false,
+	}
+
+	if err := g.Connect(); err != nil {
+		return nil, err
+	}
+	defer g.Conn.Close()
+
+	pdus, err := g.BulkWalkAll(rootOID)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := make([]Variable, 0, len(pdus))
+
+	for _, pdu := range pdus {
+		value, ok := formatValue(pdu)
+		if !ok {
+			continue
+		}
+
+		variable := Variable{
+			OID:   strings.TrimPrefix(pdu.Name, "."),
+			Value: value,
+		}
+
+		if data, isOctets := pdu.Value.([]byte); isOctets && pdu.Type == gosnmp.OctetString {
+			variable.Hex = formatHex(data)
+		}
+
+		variables = append(variables, variable)
+	}
+
+	return variables, nil
+}
+
+// Set Записывает значения одним SET запросом. Тип значения задается префиксом как в snmpset:
+// "i:" - INTEGER, "u:" - Gauge32, "a:" - IpAddress, "s:" - OCTET STRING. Без префикса числа
+// отправляются как INTEGER, остальное как OCTET STRING
+func (c *DefaultClient) Set(target Target, variables []Variable) error {
+	g := &gosnmp.GoSNMP{
+		Target:    target.Address,
+		Port:      c.Port,
+		Community: target.Community,
+		Version:   gosnmp.Version2c,
+		Timeout:   c.Timeout,
+		Retries:   c.Retries,
+	}
+
+	pdus := make([]gosnmp.SnmpPDU, 0, len(variables))
+
+	for _, v := range variables {
+		pdu, err := buildPDU(v)
+		if err != nil {
+			return err
+		}
+
+		pdus = append(pdus, pdu)
+	}
+
+	if err := g.Connect(); err != nil {
+		return err
+	}
+	defer g.Conn.Close()
+
+	res, err := g.Set(pdus)
+	if err != nil {
+		return err
+	}
+
+	if res.Error != gosnmp.NoError {
+		return fmt.Errorf("snmp set failed: %s (index %d)", res.Error, res.ErrorIndex)
+	}
+
+	return nil
+}
+
+func buildPDU(v Variable) (gosnmp.SnmpPDU, error) {
+	pdu := gosnmp.SnmpPDU{Name: "." + strings.Trim(strings.TrimSpace(v.OID), ".")}
+	value := v.Value
+	valueType := ""
+
+	if len(value) >= 2 && value[1] == ':' && strings.ContainsRune("iuas", rune(value[0])) {
+		valueType, value = value[:1], value[2:]
+	} else if _, err := strconv.Atoi(value); err == nil {
+		valueType = "i"
+	} else {
+		valueType = "s"
+	}
+
+	switch valueType {
+	case "i":
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return pdu, fmt.Errorf("value of %s is not an integer: %s", v.OID, value)
+		}
+
+		pdu.Type, pdu.Value = gosnmp.Integer, number
+	case "u":
+		number, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return pdu, fmt.Errorf("value of %s is not an unsigned integer: %s", v.OID, value)
+		}
+
+		pdu.Type, pdu.Value = gosnmp.Gauge32, uint32(number)
+	case "a":
+		pdu.Type, pdu.Value = gosnmp.IPAddress, value
+	default:
+		pdu.Type, pdu.Value = gosnmp.OctetString, []byte(value)
+	}
+
+	return pdu, nil
+}
+
+// formatValue Приводит значение SNMP к строке. Непечатаемые строки (например MAC адреса) выводятся в hex
+func formatValue(pdu gosnmp.SnmpPDU) (string, bool) {
+	switch pdu.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		return "", false
+	case gosnmp.OctetString:
+		data, _ := pdu.Value.([]byte)
+		text := strings.TrimRight(string(data), "\x00")
+
+		if utf8.ValidString(text) && strings.IndexFunc(text, func(r rune) bool {
+			return !unicode.IsPrint(r) && !unicode.IsSpace(r)
+		}) == -1 {
+			return strings.TrimSpace(text), true
+		}
+
+		return formatHex(data), true
+	case gosnmp.ObjectIdentifier, gosnmp.IPAddress:
+		return strings.TrimPrefix(fmt.Sprint(pdu.Value), "."), true
+	default:
+		return gosnmp.ToBigInt(pdu.Value).String(), true
+	}
+}
+
+func formatHex(data []byte) string {
+	parts := make([]string, len(data))
+
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+
+	return strings.Join(parts, ":")
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
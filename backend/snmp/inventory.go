@@ -0,0 +1,39 @@
+package snmp
+
+import (
+	"backend/models"
+	"strings"
+)
+
+const (
+	maxFirmwareLength = 255
+	maxSerialLength   = 128
+)
+
+// BuildFirmware Возвращает версию прошивки из метрики FIRMWARE или пустую строку, если она не опрошена
+func BuildFirmware(readings []models.SnmpReading) string {
+	return firstValue(readings, "FIRMWARE", maxFirmwareLength)
+}
+
+// BuildSerialNumber Возвращает серийный номер из метрики SERIAL_NUMBER или пустую строку
+func BuildSerialNumber(readings []models.SnmpReading) string {
+	return firstValue(readings, "SERIAL_NUMBER", maxSerialLength)
+}
+
+func firstValue(readings []models.SnmpReading, metric string, maxLength int) string {
+	for _, reading := range readings {
+		if reading.Metric != metric {
+			continue
+		}
+
+		value := strings.TrimSpace(reading.Value)
+
+		if len(value) > maxLength {
+			value = strings.ToValidUTF8(value[:maxLength], "")
+		}
+
+		return value
+	}
+
+	return ""
+}
@@ -0,0 +1,189 @@
+package snmp
+
+import (
+	"backend/models"
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LldpLocChassisIdOID Собственный chassis ID из LLDP-MIB. По нему соседи сопоставляются с оборудованием,
+// поэтому он опрашивается у всех моделей с OID таблицы LLDP в шаблоне
+const LldpLocChassisIdOID = "1.0.8802.1.1.2.1.3.2"
+
+// LldpLocPortIdOID и LldpLocPortDescOID Столбцы lldpLocPortTable с индексом lldpLocPortNum. Номер порта
+// в таблице соседей LLDP - это lldpLocPortNum, который не обязан совпадать с ifIndex, поэтому ifIndex
+// определяется по ID и описанию порта
+const (
+	LldpLocPortIdOID   = "1.0.8802.1.1.2.1.3.7.1.3"
+	LldpLocPortDescOID = "1.0.8802.1.1.2.1.3.7.1.4"
+)
+
+// lldpMibOID Корень LLDP-MIB. У CDP собственного chassis ID нет, соседи сопоставляются по имени
+const lldpMibOID = "1.0.8802.1.1.2"
+
+var lldpMetrics = []string{"LLDP_CHASSIS_ID", "LLDP_SYS_NAME", "LLDP_PORT_ID"}
+
+// BuildLldpNeighbors Собирает соседей из показаний LLDP_CHASSIS_ID, LLDP_SYS_NAME и LLDP_PORT_ID.
+// Строки таблицы объединяются по индексу после корня OID столбца: для lldpRemTable это
+// timeMark.localPortNum.remIndex, для cdpCacheTable - ifIndex.deviceIndex, в обоих случаях
+// предпоследний индекс - локальный порт. Строки без chassis ID и имени системы пропускаются.
+// У CDP локальный порт уже ifIndex, у LLDP ifIndex определяется через lldpLocalIfIndexes
+func BuildLldpNeighbors(readings []models.SnmpReading, sw models.Switch) []models.LldpNeighbor {
+	roots := map[string]string{
+		"LLDP_CHASSIS_ID": strings.Trim(strings.TrimSpace(sw.LldpChassisIdOID.String), "."),
+		"LLDP_SYS_NAME":   strings.Trim(strings.TrimSpace(sw.LldpSysNameOID.String), "."),
+		"LLDP_PORT_ID":    strings.Trim(strings.TrimSpace(sw.LldpPortIdOID.String), "."),
+	}
+
+	isLldpMib := strings.HasPrefix(roots["LLDP_CHASSIS_ID"], lldpMibOID+".")
+	localIfIndexes := lldpLocalIfIndexes(readings)
+
+	neighborsMap := make(map[string]*models.LldpNeighbor)
+
+	for _, reading := range readings {
+		root, ok := roots[reading.Metric]
+		if !ok || root == "" || !strings.HasPrefix(reading.OID, root+".") {
+			continue
+		}
+
+		suffix := strings.TrimPrefix(reading.OID, root+".")
+		index := strings.Split(suffix, ".")
+		if len(index) < 2 {
+			continue
+		}
+
+		port, err := strconv.Atoi(index[len(index)-2])
+		if err != nil || port <= 0 {
+			continue
+		}
+
+		neighbor, ok := neighborsMap[suffix]
+		if !ok {
+			neighbor = &models.LldpNeighbor{LocalPort: port}
+
+			if !isLldpMib {
+				neighbor.LocalIfIndex = sql.NullInt32{Int32: int32(port), Valid: true}
+			} else if ifIndex, ok := localIfIndexes[port]; ok {
+				neighbor.LocalIfIndex = sql.NullInt32{Int32: int32(ifIndex), Valid: true}
+			}
+
+			neighborsMap[suffix] = neighbor
+		}
+
+		value := strings.TrimSpace(reading.Value)
+
+		switch reading.Metric {
+		case "LLDP_CHASSIS_ID":
+			neighbor.ChassisID = strings.ToLower(value)
+		case "LLDP_SYS_NAME":
+			neighbor.SystemName = value
+		case "LLDP_PORT_ID":
+			neighbor.PortID = sql.NullString{String: value, Valid: value != ""}
+		}
+	}
+
+	seen := make(map[string]struct{})
+
+	var neighbors []models.LldpNeighbor
+
+	for _, neighbor := range neighborsMap {
+		if neighbor.ChassisID == "" && neighbor.SystemName == "" {
+			continue
+		}
+
+		// Ключ совпадает с уникальным ключом таблицы, повторы одного соседа на порту схлопываются
+		key := strconv.Itoa(neighbor.LocalPort) + "/" + neighbor.ChassisID + "/" + neighbor.SystemName
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		neighbors = append(neighbors, *neighbor)
+	}
+
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].LocalPort != neighbors[j].LocalPort {
+			return neighbors[i].LocalPort < neighbors[j].LocalPort
+		}
+
+		return neighbors[i].ChassisID+neighbors[i].SystemName < neighbors[j].ChassisID+neighbors[j].SystemName
+	})
+
+	return neighbors
+}
+
+// lldpLocalIfIndexes Сопоставляет lldpLocPortNum с ifIndex. ID порта из lldpLocPortTable, а если он
+// не совпал ни с одним интерфейсом - описание порта, сравнивается без учета регистра сначала с ifName,
+// затем с ifDescr интерфейсов из того же опроса. Если подходит несколько интерфейсов, порт не сопоставляется
+func lldpLocalIfIndexes(readings []models.SnmpReading) map[int]int {
+	ifNames := make(map[string][]int)
+	ifDescrs := make(map[string][]int)
+	localPorts := make(map[int][2]string)
+
+	for _, reading := range readings {
+		index := oidIndex(reading.OID)
+		value := strings.ToLower(strings.TrimSpace(reading.Value))
+
+		if index <= 0 || value == "" {
+			continue
+		}
+
+		switch reading.Metric {
+		case "IF_NAME":
+			ifNames[value] = append(ifNames[value], index)
+		case "IF_DESCR":
+			ifDescrs[value] = append(ifDescrs[value], index)
+		case "LLDP_LOC_PORT_ID":
+			localPort := localPorts[index]
+			localPort[0] = value
+			localPorts[index] = localPort
+		case "LLDP_LOC_PORT_DESC":
+			localPort := localPorts[index]
+			localPort[1] = value
+			localPorts[index] = localPort
+		}
+	}
+
+	ifIndexes := make(map[int]int)
+
+	for number, localPort := range localPorts {
+		for _, value := range localPort {
+			if value == "" {
+				continue
+			}
+
+			candidates := ifNames[value]
+			if len(candidates) == 0 {
+				candidates = ifDescrs[value]
+			}
+
+			if len(candidates) == 0 {
+				continue
+			}
+
+			if len(candidates) == 1 {
+				ifIndexes[number] = candidates[0]
+			}
+
+			break
+		}
+	}
+
+	return ifIndexes
+}
+
+// isLldpPolled Возвращает true, если в опросе есть хотя бы один столбец таблицы соседей.
+// Пустая таблица тоже считается опрошенной, чтобы пропавшие соседи удалялись
+func isLldpPolled(poll *models.SnmpPoll) bool {
+	for _, name := range poll.Metrics {
+		for _, m := range lldpMetrics {
+			if name == m {
+				return true
+			}
+		}
+	}
+
+	return false
+}
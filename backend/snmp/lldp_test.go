@@ -0,0 +1,180 @@
+package snmp
+
+import (
+	"backend/models"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestLldpLocalIfIndexes(t *testing.T) {
+	tests := []struct {
+		name     string
+		readings []models.SnmpReading
+		want     map[int]int
+	}{
+		{
+			name: "port id matches ifName",
+			readings: []models.SnmpReading{
+				{Metric: "LLDP_LOC_PORT_ID", OID: LldpLocPortIdOID + ".3", Value: "Gi0/3"},
+				{Metric: "IF_NAME", OID: IfNameOID + ".10103", Value: "gi0/3"},
+				{Metric: "IF_DESCR", OID: IfDescrOID + ".10104", Value: "Gi0/3"},
+			},
+			want: map[int]int{3: 10103},
+		},
+		{
+			name: "port id matches ifDescr",
+			readings: []models.SnmpReading{
+				{Metric: "LLDP_LOC_PORT_ID", OID: LldpLocPortIdOID + ".1", Value: "GigabitEthernet0/1"},
+				{Metric: "IF_NAME", OID: IfNameOID + ".10101", Value: "Gi0/1"},
+				{Metric: "IF_DESCR", OID: IfDescrOID + ".10101", Value: "GigabitEthernet0/1"},
+			},
+			want: map[int]int{1: 10101},
+		},
+		{
+			name: "port description is used when port id does not match",
+			readings: []models.SnmpReading{
+				{Metric: "LLDP_LOC_PORT_ID", OID: LldpLocPortIdOID + ".2", Value: "00:11:22:33:44:55"},
+				{Metric: "LLDP_LOC_PORT_DESC", OID: LldpLocPortDescOID + ".2", Value: "ethernet1/1/2"},
+				{Metric: "IF_NAME", OID: IfNameOID + ".52", Value: "ethernet1/1/2"},
+			},
+			want: map[int]int{2: 52},
+		},
+		{
+			name: "ambiguous interface name",
+			readings: []models.SnmpReading{
+				{Metric: "LLDP_LOC_PORT_ID", OID: LldpLocPortIdOID + ".5", Value: "port 5"},
+				{Metric: "LLDP_LOC_PORT_DESC", OID: LldpLocPortDescOID + ".5", Value: "Gi0/5"},
+				{Metric: "IF_NAME", OID: IfNameOID + ".5", Value: "port 5"},
+				{Metric: "IF_NAME", OID: IfNameOID + ".105", Value: "port 5"},
+				{Metric: "IF_NAME", OID: IfNameOID + ".205", Value: "Gi0/5"},
+			},
+			want: map[int]int{},
+		},
+		{
+			name: "no interface names",
+			readings: []models.SnmpReading{
+				{Metric: "LLDP_LOC_PORT_ID", OID: LldpLocPortIdOID + ".1", Value: "Gi0/1"},
+			},
+			want: map[int]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lldpLocalIfIndexes(tt.readings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lldpLocalIfIndexes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildLldpNeighbors(t *testing.T) {
+	const (
+		lldpChassis = "1.0.8802.1.1.2.1.4.1.1.5"
+		lldpPort    = "1.0.8802.1.1.2.1.4.1.1.7"
+		lldpSysName = "1.0.8802.1.1.2.1.4.1.1.9"
+		cdpAddress  = "1.3.6.1.4.1.9.9.23.1.2.1.1.4"
+		cdpPort     = "1.3.6.1.4.1.9.9.23.1.2.1.1.7"
+		cdpDeviceID = "1.3.6.1.4.1.9.9.23.1.2.1.1.6"
+	)
+
+	lldpSwitch := models.Switch{
+		LldpChassisIdOID: sql.NullString{String: lldpChassis, Valid: true},
+		LldpSysNameOID:   sql.NullString{String: "." + lldpSysName, Valid: true},
+		LldpPortIdOID:    sql.NullString{String: lldpPort, Valid: true},
+	}
+
+	cdpSwitch := models.Switch{
+		LldpChassisIdOID: sql.NullString{String: cdpAddress, Valid: true},
+		LldpSysNameOID:   sql.NullString{String: cdpDeviceID, Valid: true},
+		LldpPortIdOID:    sql.NullString{String: cdpPort, Valid: true},
+	}
+
+	tests := []struct {
+		name     string
+		readings []models.SnmpReading
+		sw       models.Switch
+		want     []models.LldpNeighbor
+	}{
+		{
+			name: "lldp neighbor with local ifIndex",
+			readings: []models.SnmpReading{
+				{Metric: "LLDP_CHASSIS_ID", OID: lldpChassis + ".0.3.1", Value: "AA:BB:CC:DD:EE:FF"},
+				{Metric: "LLDP_SYS_NAME", OID: lldpSysName + ".0.3.1", Value: " core-1 "},
+				{Metric: "LLDP_PORT_ID", OID: lldpPort + ".0.3.1", Value: "Gi1/0/1"},
+				{Metric: "LLDP_LOC_PORT_ID", OID: LldpLocPortIdOID + ".3", Value: "Gi0/3"},
+				{Metric: "IF_NAME", OID: IfNameOID + ".10103", Value: "Gi0/3"},
+			},
+			sw: lldpSwitch,
+			want: []models.LldpNeighbor{
+				{
+					LocalPort:    3,
+					LocalIfIndex: sql.NullInt32{Int32: 10103, Valid: true},
+					ChassisID:    "aa:bb:cc:dd:ee:ff",
+					SystemName:   "core-1",
+					PortID:       sql.NullString{String: "Gi1/0/1", Valid: true},
+				},
+			},
+		},
+		{
+			name: "lldp neighbor without local port table",
+			readings: []models.SnmpReading{
+				{Metric: "LLDP_SYS_NAME", OID: lldpSysName + ".0.7.2", Value: "access-7"},
+				{Metric: "LLDP_PORT_ID", OID: lldpPort + ".0.7.2", Value: ""},
+			},
+			sw: lldpSwitch,
+			want: []models.LldpNeighbor{
+				{LocalPort: 7, SystemName: "access-7"},
+			},
+		},
+		{
+			name: "cdp local port is ifIndex",
+			readings: []models.SnmpReading{
+				{Metric: "LLDP_CHASSIS_ID", OID: cdpAddress + ".10101.1", Value: "0a:00:00:01"},
+				{Metric: "LLDP_SYS_NAME", OID: cdpDeviceID + ".10101.1", Value: "dist-1"},
+				{Metric: "LLDP_PORT_ID", OID: cdpPort + ".10101.1", Value: "GigabitEthernet0/24"},
+			},
+			sw: cdpSwitch,
+			want: []models.LldpNeighbor{
+				{
+					LocalPort:    10101,
+					LocalIfIndex: sql.NullInt32{Int32: 10101, Valid: true},
+					ChassisID:    "0a:00:00:01",
+					SystemName:   "dist-1",
+					PortID:       sql.NullString{String: "GigabitEthernet0/24", Valid: true},
+				},
+			},
+		},
+		{
+			name: "rows without chassis and name and duplicates are skipped",
+			readings: []models.SnmpReading{
+				{Metric: "LLDP_PORT_ID", OID: lldpPort + ".0.1.1", Value: "1"},
+				{Metric: "LLDP_SYS_NAME", OID: lldpSysName + ".0.2.1", Value: "edge"},
+				{Metric: "LLDP_SYS_NAME", OID: lldpSysName + ".100.2.1", Value: "edge"},
+				{Metric: "LLDP_SYS_NAME", OID: lldpSysName + ".0.0.1", Value: "zero port"},
+				{Metric: "LLDP_SYS_NAME", OID: "1.3.6.1.2.1.1.5.0", Value: "other table"},
+			},
+			sw: lldpSwitch,
+			want: []models.LldpNeighbor{
+				{LocalPort: 2, SystemName: "edge"},
+			},
+		},
+		{
+			name: "table is not configured",
+			readings: []models.SnmpReading{
+				{Metric: "LLDP_SYS_NAME", OID: lldpSysName + ".0.2.1", Value: "edge"},
+			},
+			sw:   models.Switch{},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildLldpNeighbors(tt.readings, tt.sw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildLldpNeighbors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
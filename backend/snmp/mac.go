@@ -0,0 +1,72 @@
+package snmp
+
+import (
+	"backend/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BuildMacEntries Разбирает таблицу коммутации из показаний MAC. Последние шесть индексов OID - байты
+// MAC адреса, индекс перед ними - VLAN для Q-BRIDGE-MIB (dot1qTpFdbPort), значение - номер порта.
+// Для BRIDGE-MIB (dot1dTpFdbPort) индекс VLAN отсутствует и VlanID остается 0
+func BuildMacEntries(readings []models.SnmpReading, rootOID string) []models.MacEntry {
+	root := strings.Trim(strings.TrimSpace(rootOID), ".")
+	seen := make(map[string]struct{})
+
+	var entries []models.MacEntry
+
+	for _, reading := range readings {
+		if reading.Metric != "MAC" || !strings.HasPrefix(reading.OID, root+".") {
+			continue
+		}
+
+		port, err := strconv.Atoi(strings.TrimSpace(reading.Value))
+		if err != nil || port <= 0 {
+			continue
+		}
+
+		index := strings.Split(strings.TrimPrefix(reading.OID, root+"."), ".")
+		if len(index) < 6 {
+			continue
+		}
+
+		macParts := make([]string, 6)
+		isValid := true
+
+		for i, part := range index[len(index)-6:] {
+			b, e := strconv.Atoi(part)
+			if e != nil || b < 0 || b > 255 {
+				isValid = false
+				break
+			}
+
+			macParts[i] = fmt.Sprintf("%02x", b)
+		}
+
+		if !isValid {
+			continue
+		}
+
+		entry := models.MacEntry{
+			Mac:        strings.Join(macParts, ":"),
+			PortNumber: port,
+		}
+
+		if len(index) >= 7 {
+			if vlan, e := strconv.Atoi(index[len(index)-7]); e == nil && vlan >= 1 && vlan <= 4094 {
+				entry.VlanID = vlan
+			}
+		}
+
+		key := fmt.Sprintf("%s/%d/%d", entry.Mac, entry.PortNumber, entry.VlanID)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
@@ -0,0 +1,78 @@
+package snmp
+
+import (
+	"backend/models"
+	"reflect"
+	"testing"
+)
+
+func TestBuildMacEntries(t *testing.T) {
+	const qBridge = "1.3.6.1.2.1.17.7.1.2.2.1.2"
+	const bridge = "1.3.6.1.2.1.17.4.3.1.2"
+
+	tests := []struct {
+		name     string
+		readings []models.SnmpReading
+		rootOID  string
+		want     []models.MacEntry
+	}{
+		{
+			name: "q-bridge entry with vlan",
+			readings: []models.SnmpReading{
+				{Metric: "MAC", OID: qBridge + ".10.0.17.34.51.68.255", Value: "5"},
+			},
+			rootOID: qBridge,
+			want:    []models.MacEntry{{Mac: "00:11:22:33:44:ff", PortNumber: 5, VlanID: 10}},
+		},
+		{
+			name: "bridge entry without vlan",
+			readings: []models.SnmpReading{
+				{Metric: "MAC", OID: bridge + ".0.17.34.51.68.85", Value: " 3 "},
+			},
+			rootOID: "." + bridge + ".",
+			want:    []models.MacEntry{{Mac: "00:11:22:33:44:55", PortNumber: 3}},
+		},
+		{
+			name: "vlan index out of range is ignored",
+			readings: []models.SnmpReading{
+				{Metric: "MAC", OID: qBridge + ".5000.0.17.34.51.68.85", Value: "1"},
+			},
+			rootOID: qBridge,
+			want:    []models.MacEntry{{Mac: "00:11:22:33:44:55", PortNumber: 1}},
+		},
+		{
+			name: "duplicates are collapsed",
+			readings: []models.SnmpReading{
+				{Metric: "MAC", OID: qBridge + ".10.0.17.34.51.68.85", Value: "1"},
+				{Metric: "MAC", OID: qBridge + ".10.0.17.34.51.68.85", Value: "1"},
+				{Metric: "MAC", OID: qBridge + ".20.0.17.34.51.68.85", Value: "1"},
+			},
+			rootOID: qBridge,
+			want: []models.MacEntry{
+				{Mac: "00:11:22:33:44:55", PortNumber: 1, VlanID: 10},
+				{Mac: "00:11:22:33:44:55", PortNumber: 1, VlanID: 20},
+			},
+		},
+		{
+			name: "invalid readings are skipped",
+			readings: []models.SnmpReading{
+				{Metric: "MAC", OID: qBridge + ".10.0.17.34.51.68.85", Value: "0"},
+				{Metric: "MAC", OID: qBridge + ".10.0.17.34.51.68.85", Value: "port"},
+				{Metric: "MAC", OID: qBridge + ".10.0.17.34.51.68.256", Value: "1"},
+				{Metric: "MAC", OID: qBridge + ".17.34.51.68.85", Value: "1"},
+				{Metric: "MAC", OID: bridge + ".0.17.34.51.68.85", Value: "1"},
+				{Metric: "VLAN", OID: qBridge + ".10.0.17.34.51.68.85", Value: "1"},
+			},
+			rootOID: qBridge,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildMacEntries(tt.readings, tt.rootOID); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildMacEntries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
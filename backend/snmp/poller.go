@@ -0,0 +1,300 @@
+package snmp
+
+import (
+	"backend/database"
+	"backend/models"
+	"backend/utils"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Poller interface {
+	Run(ctx context.Context)
+	PollAll(ctx context.Context) error
+	PollHardware(hardwareID int) (*models.SnmpPoll, error)
+}
+
+type DefaultPoller struct {
+	Client       Client
+	SnmpRepo     database.SnmpRepository
+	PortRepo     database.PortRepository
+	MacRepo      database.MacRepository
+	PowerRepo    database.PowerRepository
+	TopologyRepo database.TopologyRepository
+	Interval     time.Duration
+	Workers      int
+	utils.Logger
+}
+
+type metric struct {
+	name       string
+	oid        sql.NullString
+	isTable    bool
+	isPortList bool
+}
+
+func NewPoller(db *database.Database, logger *utils.Logger) Poller {
+	return &DefaultPoller{
+		Client: NewClient(),
+		SnmpRepo: &database.DefaultSnmpRepository{
+			Database: *db,
+		},
+		PortRepo: &database.DefaultPortRepository{
+			Database: *db,
+		},
+		MacRepo: &database.DefaultMacRepository{
+			Database: *db,
+		},
+		PowerRepo: &database.DefaultPowerRepository{
+			Database: *db,
+		},
+		TopologyRepo: &database.DefaultTopologyRepository{
+			Database: *db,
+		},
+		Interval: time.Duration(getEnvInt("SNMP_POLL_INTERVAL", 300)) * time.Second,
+		Workers:  getEnvInt("SNMP_WORKERS", 8),
+		Logger:   *logger,
+	}
+}
+
+// Run Периодически опрашивает все оборудование. При SNMP_POLL_INTERVAL=0 фоновый опрос выключен
+func (p *DefaultPoller) Run(ctx context.Context) {
+	if p.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.PollAll(ctx); err != nil {
+			log.Printf("failed to poll hardware: %v\n", err)
+			p.Logger.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PollAll Опрашивает все оборудование с IP адресом и моделью коммутатора в несколько потоков,
+// затем по собранным таблицам LLDP/CDP формирует предложения по топологии
+func (p *DefaultPoller) PollAll(ctx context.Context) error {
+	targets, err := p.SnmpRepo.GetSnmpTargets(0)
+	if err != nil {
+		return err
+	}
+
+	workers := p.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	queue := make(chan models.Hardware)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for hd := range queue {
+				if _, e := p.poll(hd); e != nil {
+					log.Printf("failed to save snmp poll of hardware %d: %v\n", hd.ID, e)
+					p.Logger.Println(e)
+				}
+			}
+		}()
+	}
+
+	for _, hd := range targets {
+		select {
+		case <-ctx.Done():
+			close(queue)
+			wg.Wait()
+			return ctx.Err()
+		case queue <- hd:
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+
+	if _, err = p.TopologyRepo.DiscoverTopology(time.Now().Unix()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PollHardware Опрашивает одно оборудование вне расписания. Возвращает sql.ErrNoRows,
+// если у оборудования нет IP адреса или модели коммутатора
+func (p *DefaultPoller) PollHardware(hardwareID int) (*models.SnmpPoll, error) {
+	targets, err := p.SnmpRepo.GetSnmpTargets(hardwareID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(targets) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return p.poll(targets[0])
+}
+
+func (p *DefaultPoller) poll(hd models.Hardware) (*models.SnmpPoll, error) {
+	sw := hd.Switch
+	metrics := []metric{
+		{"FIRMWARE", sw.FirmwareOID, false, false},
+		{"SYSTEM_NAME", sw.SystemNameOID, false, false},
+		{"SERIAL_NUMBER", sw.SerialNumberOID, false, false},
+		{"UPTIME", sw.UptimeOID, false, false},
+		{"BATTERY_STATUS", sw.BatteryStatusOID, false, false},
+		{"BATTERY_CHARGE", sw.BatteryChargeOID, false, false},
+		{"PORT_DESC", sw.PortDescOID, true, false},
+		{"VLAN", sw.VlanOID, true, true},
+		{"PORT_UNTAGGED", sw.PortUntaggedOID, true, true},
+		{"SPEED", sw.SpeedOID, true, false},
+		{"PORT_MODE", sw.PortModeOID, true, false},
+		{"MAC", sw.MacOID, true, false},
+		{"LLDP_CHASSIS_ID", sw.LldpChassisIdOID, true, false},
+		{"LLDP_SYS_NAME", sw.LldpSysNameOID, true, false},
+		{"LLDP_PORT_ID", sw.LldpPortIdOID, true, false},
+	}
+
+	hasPorts := sw.PortDescOID.Valid || sw.SpeedOID.Valid || sw.PortModeOID.Valid || sw.PortUntaggedOID.Valid
+
+	// Состояние линка опрашивается только у моделей с портами в шаблоне, OID у всех одинаковый
+	if hasPorts {
+		metrics = append(metrics, metric{"LINK_STATE", sql.NullString{String: IfOperStatusOID, Valid: true}, true, false})
+	}
+
+	// Имена интерфейсов нужны для сопоставления портов с соседями и у самого оборудования, и у его соседей
+	if hasPorts || sw.LldpPortIdOID.Valid {
+		metrics = append(metrics,
+			metric{"IF_NAME", sql.NullString{String: IfNameOID, Valid: true}, true, false},
+			metric{"IF_DESCR", sql.NullString{String: IfDescrOID, Valid: true}, true, false},
+		)
+	}
+
+	if strings.HasPrefix(strings.Trim(strings.TrimSpace(sw.LldpChassisIdOID.String), "."), lldpMibOID+".") {
+		metrics = append(metrics,
+			metric{"LLDP_LOCAL_CHASSIS_ID", sql.NullString{String: LldpLocChassisIdOID, Valid: true}, false, false},
+			metric{"LLDP_LOC_PORT_ID", sql.NullString{String: LldpLocPortIdOID, Valid: true}, true, false},
+			metric{"LLDP_LOC_PORT_DESC", sql.NullString{String: LldpLocPortDescOID, Valid: true}, true, false},
+		)
+	}
+
+	target := Target{Address: hd.IpAddress.String, Community: "public"}
+
+	if sw.CommunityRead.Valid && sw.CommunityRead.String != "" {
+		target.Community = sw.CommunityRead.String
+	}
+
+	poll := &models.SnmpPoll{
+		HardwareID: hd.ID,
+		PolledAt:   time.Now().Unix(),
+	}
+
+	var errorsList []string
+
+	for _, m := range metrics {
+		if !m.oid.Valid || strings.TrimSpace(m.oid.String) == "" {
+			continue
+		}
+
+		variables, err := p.Client.Walk(target, strings.TrimSpace(m.oid.String))
+		if err != nil {
+			errorsList = append(errorsList, fmt.Sprintf("%s: %v", m.name, err))
+
+			// Если не ответил даже первый OID, агент недоступен и остальные запросы только ждут таймаут
+			if len(poll.Metrics) == 0 && len(errorsList) == 1 {
+				break
+			}
+
+			continue
+		}
+
+		if !m.isTable && len(variables) > 1 {
+			variables = variables[:1]
+		}
+
+		poll.Metrics = append(poll.Metrics, m.name)
+
+		for _, v := range variables {
+			value := v.Value
+
+			// Маски портов VLAN сохраняются всегда в hex, иначе их нельзя отличить от текста
+			if m.isPortList && v.Hex != "" {
+				value = v.Hex
+			}
+
+			poll.Readings = append(poll.Readings, models.SnmpReading{
+				HardwareID: hd.ID,
+				Metric:     m.name,
+				OID:        v.OID,
+				Value:      value,
+				PolledAt:   poll.PolledAt,
+			})
+		}
+	}
+
+	if len(poll.Metrics) > 0 {
+		poll.SuccessAt = sql.NullInt64{Int64: poll.PolledAt, Valid: true}
+	}
+
+	if len(errorsList) > 0 {
+		poll.Error = sql.NullString{String: strings.Join(errorsList, "; "), Valid: true}
+	}
+
+	if err := p.SnmpRepo.SaveSnmpPoll(poll); err != nil {
+		return nil, err
+	}
+
+	if ports := BuildPorts(poll.Readings, sw.PortAmount); len(ports) > 0 {
+		if err := p.PortRepo.SyncSnmpPorts(hd.ID, ports, poll.PolledAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if entries := BuildMacEntries(poll.Readings, sw.MacOID.String); len(entries) > 0 {
+		if err := p.MacRepo.SaveMacEntries(hd.ID, entries, poll.PolledAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if isLldpPolled(poll) {
+		neighbors := BuildLldpNeighbors(poll.Readings, sw)
+		if err := p.TopologyRepo.SaveLldpNeighbors(hd.ID, neighbors, poll.PolledAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if firmware := BuildFirmware(poll.Readings); firmware != "" {
+		if err := p.SnmpRepo.SetHardwareFirmware(hd.ID, firmware, poll.PolledAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if serial := BuildSerialNumber(poll.Readings); serial != "" {
+		if err := p.SnmpRepo.SetHardwareSerial(hd, serial, poll.PolledAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if reading := BuildBatteryReading(hd.ID, poll.Readings, poll.PolledAt); reading != nil {
+		if err := p.PowerRepo.CreateBatteryReading(*reading); err != nil {
+			return nil, err
+		}
+	}
+
+	return poll, nil
+}
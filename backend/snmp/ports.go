@@ -0,0 +1,215 @@
+package snmp
+
+import (
+	"backend/models"
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IfOperStatusOID Состояние линка из IF-MIB, поддерживается всеми коммутаторами и не требует OID в шаблоне
+const IfOperStatusOID = "1.3.6.1.2.1.2.2.1.8"
+
+// IfNameOID и IfDescrOID Имя и описание интерфейса из IF-MIB. По ним порты сопоставляются с ID портов
+// из таблиц соседей LLDP/CDP, поэтому они опрашиваются без OID в шаблоне
+const (
+	IfNameOID  = "1.3.6.1.2.1.31.1.1.1.1"
+	IfDescrOID = "1.3.6.1.2.1.2.2.1.2"
+)
+
+var ifOperStatuses = map[string]string{
+	"1": "up",
+	"2": "down",
+	"3": "testing",
+	"4": "unknown",
+	"5": "dormant",
+	"6": "notPresent",
+	"7": "lowerLayerDown",
+}
+
+// BuildPorts Собирает порты из показаний PORT_DESC, SPEED, PORT_MODE, PORT_UNTAGGED, VLAN и LINK_STATE.
+// Номер порта берется из последнего индекса OID, индексы больше portAmount (VLAN интерфейсы,
+// агрегаты) пропускаются. IF_NAME и IF_DESCR только дополняют порты из остальных показаний. PORT_UNTAGGED может быть как PVID порта, так и битовой маской портов VLAN,
+// VLAN - битовая маска портов-членов VLAN (dot1qVlanStaticEgressPorts). Членство во VLAN заполняется,
+// только если в опросе есть VLAN или PORT_UNTAGGED, иначе Vlans порта остается nil
+func BuildPorts(readings []models.SnmpReading, portAmount int) []models.HardwarePort {
+	portsMap := make(map[int]*models.HardwarePort)
+	members := make(map[int]map[int]bool) // порт -> VLAN -> тегированный
+	membershipPolled := false
+	ifNames := make(map[int]string)
+	ifDescrs := make(map[int]string)
+
+	addMember := func(number int, vlan int, tagged bool) {
+		if _, ok := members[number]; !ok {
+			members[number] = make(map[int]bool)
+		}
+
+		// Нетегированное членство важнее тегированного из маски egress
+		if isTagged, ok := members[number][vlan]; !ok || isTagged {
+			members[number][vlan] = tagged
+		}
+	}
+
+	getPort := func(number int) *models.HardwarePort {
+		if number <= 0 || (portAmount > 0 && number > portAmount) {
+			return nil
+		}
+
+		if _, ok := portsMap[number]; !ok {
+			portsMap[number] = &models.HardwarePort{Number: number, Source: "SNMP"}
+		}
+
+		return portsMap[number]
+	}
+
+	for _, reading := range readings {
+		index := oidIndex(reading.OID)
+		value := strings.TrimSpace(reading.Value)
+
+		switch reading.Metric {
+		case "PORT_DESC":
+			if port := getPort(index); port != nil && value != "" {
+				port.Description = sql.NullString{String: value, Valid: true}
+			}
+		case "SPEED":
+			if port := getPort(index); port != nil {
+				if speed, err := strconv.ParseInt(value, 10, 64); err == nil {
+					port.Speed = sql.NullInt64{Int64: normalizeSpeed(speed), Valid: true}
+				}
+			}
+		case "PORT_MODE":
+			if port := getPort(index); port != nil && value != "" {
+				port.Mode = sql.NullString{String: value, Valid: true}
+			}
+		case "LINK_STATE":
+			if port := getPort(index); port != nil {
+				if state, ok := ifOperStatuses[value]; ok {
+					port.LinkState = sql.NullString{String: state, Valid: true}
+				}
+			}
+		case "IF_NAME":
+			if value != "" {
+				ifNames[index] = value
+			}
+		case "IF_DESCR":
+			if value != "" {
+				ifDescrs[index] = value
+			}
+		case "PORT_UNTAGGED":
+			membershipPolled = true
+
+			if vlan, err := strconv.Atoi(value); err == nil {
+				if port := getPort(index); port != nil && vlan >= 1 && vlan <= 4094 {
+					port.UntaggedVlan = sql.NullInt32{Int32: int32(vlan), Valid: true}
+					addMember(index, vlan, false)
+				}
+
+				continue
+			}
+
+			if index < 1 || index > 4094 {
+				continue
+			}
+
+			for _, number := range PortList(value) {
+				if port := getPort(number); port != nil {
+					port.UntaggedVlan = sql.NullInt32{Int32: int32(index), Valid: true}
+					addMember(number, index, false)
+				}
+			}
+		case "VLAN":
+			if index < 1 || index > 4094 {
+				continue
+			}
+
+			numbers := PortList(value)
+			if numbers == nil {
+				continue
+			}
+
+			membershipPolled = true
+
+			for _, number := range numbers {
+				if port := getPort(number); port != nil {
+					addMember(number, index, true)
+				}
+			}
+		}
+	}
+
+	for number, port := range portsMap {
+		if name, ok := ifNames[number]; ok {
+			port.IfName = sql.NullString{String: name, Valid: true}
+		}
+
+		if descr, ok := ifDescrs[number]; ok {
+			port.IfDescr = sql.NullString{String: descr, Valid: true}
+		}
+	}
+
+	if membershipPolled {
+		for number, port := range portsMap {
+			port.Vlans = make([]models.PortVlan, 0, len(members[number]))
+
+			for vlan, tagged := range members[number] {
+				port.Vlans = append(port.Vlans, models.PortVlan{VlanID: vlan, Tagged: tagged, Source: "SNMP"})
+			}
+
+			sort.Slice(port.Vlans, func(i, j int) bool {
+				return port.Vlans[i].VlanID < port.Vlans[j].VlanID
+			})
+		}
+	}
+
+	ports := make([]models.HardwarePort, 0, len(portsMap))
+
+	for _, port := range portsMap {
+		ports = append(ports, *port)
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].Number < ports[j].Number
+	})
+
+	return ports
+}
+
+// PortList Разбирает битовую маску портов Q-BRIDGE-MIB (старший бит первого байта - порт 1),
+// полученную в виде шестнадцатеричной строки через двоеточие
+func PortList(value string) []int {
+	var ports []int
+
+	for i, part := range strings.Split(value, ":") {
+		b, err := strconv.ParseUint(part, 16, 8)
+		if err != nil {
+			return nil
+		}
+
+		for bit := 0; bit < 8; bit++ {
+			if b&(0x80>>bit) != 0 {
+				ports = append(ports, i*8+bit+1)
+			}
+		}
+	}
+
+	return ports
+}
+
+// normalizeSpeed Приводит скорость к Мбит/с: ifSpeed отдает бит/с, ifHighSpeed - уже Мбит/с
+func normalizeSpeed(speed int64) int64 {
+	if speed >= 1000000 {
+		return speed / 1000000
+	}
+
+	return speed
+}
+
+func oidIndex(oid string) int {
+	index, err := strconv.Atoi(oid[strings.LastIndex(oid, ".")+1:])
+	if err != nil {
+		return 0
+	}
+
+	return index
+}
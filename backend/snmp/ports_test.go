@@ -0,0 +1,140 @@
+package snmp
+
+import (
+	"backend/models"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestPortList(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []int
+	}{
+		{name: "first byte", value: "80", want: []int{1}},
+		{name: "several bytes", value: "c0:01", want: []int{1, 2, 16}},
+		{name: "empty mask", value: "00:00", want: nil},
+		{name: "not hex", value: "zz", want: nil},
+		{name: "too long part", value: "100", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PortList(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PortList(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPorts(t *testing.T) {
+	tests := []struct {
+		name       string
+		readings   []models.SnmpReading
+		portAmount int
+		want       []models.HardwarePort
+	}{
+		{
+			name: "description, speed and link state",
+			readings: []models.SnmpReading{
+				{Metric: "PORT_DESC", OID: "1.3.6.1.2.1.31.1.1.1.18.1", Value: " uplink "},
+				{Metric: "SPEED", OID: "1.3.6.1.2.1.2.2.1.5.1", Value: "1000000000"},
+				{Metric: "LINK_STATE", OID: "1.3.6.1.2.1.2.2.1.8.1", Value: "1"},
+				{Metric: "SPEED", OID: "1.3.6.1.2.1.31.1.1.1.15.2", Value: "100"},
+				{Metric: "LINK_STATE", OID: "1.3.6.1.2.1.2.2.1.8.2", Value: "9"},
+			},
+			want: []models.HardwarePort{
+				{
+					Number:      1,
+					Source:      "SNMP",
+					Description: sql.NullString{String: "uplink", Valid: true},
+					Speed:       sql.NullInt64{Int64: 1000, Valid: true},
+					LinkState:   sql.NullString{String: "up", Valid: true},
+				},
+				{Number: 2, Source: "SNMP", Speed: sql.NullInt64{Int64: 100, Valid: true}},
+			},
+		},
+		{
+			name: "ports above port amount are skipped",
+			readings: []models.SnmpReading{
+				{Metric: "LINK_STATE", OID: "1.3.6.1.2.1.2.2.1.8.24", Value: "2"},
+				{Metric: "LINK_STATE", OID: "1.3.6.1.2.1.2.2.1.8.1001", Value: "1"},
+			},
+			portAmount: 24,
+			want: []models.HardwarePort{
+				{Number: 24, Source: "SNMP", LinkState: sql.NullString{String: "down", Valid: true}},
+			},
+		},
+		{
+			name: "interface names annotate polled ports only",
+			readings: []models.SnmpReading{
+				{Metric: "LINK_STATE", OID: "1.3.6.1.2.1.2.2.1.8.3", Value: "1"},
+				{Metric: "IF_NAME", OID: "1.3.6.1.2.1.31.1.1.1.1.3", Value: "Gi0/3"},
+				{Metric: "IF_DESCR", OID: "1.3.6.1.2.1.2.2.1.2.3", Value: "GigabitEthernet0/3"},
+				{Metric: "IF_NAME", OID: "1.3.6.1.2.1.31.1.1.1.1.4", Value: "Gi0/4"},
+			},
+			want: []models.HardwarePort{
+				{
+					Number:    3,
+					Source:    "SNMP",
+					LinkState: sql.NullString{String: "up", Valid: true},
+					IfName:    sql.NullString{String: "Gi0/3", Valid: true},
+					IfDescr:   sql.NullString{String: "GigabitEthernet0/3", Valid: true},
+				},
+			},
+		},
+		{
+			name: "pvid and vlan egress mask",
+			readings: []models.SnmpReading{
+				{Metric: "PORT_UNTAGGED", OID: "1.3.6.1.2.1.17.7.1.4.5.1.1.1", Value: "10"},
+				{Metric: "VLAN", OID: "1.3.6.1.2.1.17.7.1.4.3.1.2.10", Value: "c0"},
+				{Metric: "VLAN", OID: "1.3.6.1.2.1.17.7.1.4.3.1.2.20", Value: "80"},
+			},
+			want: []models.HardwarePort{
+				{
+					Number:       1,
+					Source:       "SNMP",
+					UntaggedVlan: sql.NullInt32{Int32: 10, Valid: true},
+					Vlans: []models.PortVlan{
+						{VlanID: 10, Tagged: false, Source: "SNMP"},
+						{VlanID: 20, Tagged: true, Source: "SNMP"},
+					},
+				},
+				{
+					Number: 2,
+					Source: "SNMP",
+					Vlans:  []models.PortVlan{{VlanID: 10, Tagged: true, Source: "SNMP"}},
+				},
+			},
+		},
+		{
+			name: "untagged ports mask",
+			readings: []models.SnmpReading{
+				{Metric: "PORT_UNTAGGED", OID: "1.3.6.1.2.1.17.7.1.4.3.1.4.30", Value: "40:00"},
+			},
+			want: []models.HardwarePort{
+				{
+					Number:       2,
+					Source:       "SNMP",
+					UntaggedVlan: sql.NullInt32{Int32: 30, Valid: true},
+					Vlans:        []models.PortVlan{{VlanID: 30, Tagged: false, Source: "SNMP"}},
+				},
+			},
+		},
+		{
+			name:     "no readings",
+			readings: nil,
+			want:     []models.HardwarePort{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildPorts(tt.readings, tt.portAmount); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildPorts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}